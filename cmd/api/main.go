@@ -7,16 +7,26 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	_ "github.com/ayagmar/gojobscraper/docs"
 	"github.com/ayagmar/gojobscraper/internal/api"
+	"github.com/ayagmar/gojobscraper/internal/auth"
+	"github.com/ayagmar/gojobscraper/internal/blocklist"
 	"github.com/ayagmar/gojobscraper/internal/config"
+	"github.com/ayagmar/gojobscraper/internal/dedup"
+	"github.com/ayagmar/gojobscraper/internal/notify"
+	"github.com/ayagmar/gojobscraper/internal/ratelimit"
+	"github.com/ayagmar/gojobscraper/internal/scraper"
+	"github.com/ayagmar/gojobscraper/internal/search"
 	"github.com/ayagmar/gojobscraper/internal/storage"
+	"github.com/ayagmar/gojobscraper/internal/webhook"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
+	"github.com/google/uuid"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
@@ -25,6 +35,9 @@ import (
 // @description This is a job scraper application.
 // @termsOfService http://swagger.io/terms/
 // @BasePath /api/v1
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
 func main() {
 	if err := run(); err != nil {
 		log.Fatalf("Application error: %v", err)
@@ -52,7 +65,98 @@ func run() error {
 
 	logger := log.New(os.Stdout, "JobScraper: ", log.LstdFlags|log.Lshortfile)
 
-	router := setupRouter(jobStorage, logger)
+	detector := dedup.NewDetector(dedup.Options{
+		TitleThreshold:       cfg.Dedup.TitleThreshold,
+		DescriptionThreshold: cfg.Dedup.DescriptionThreshold,
+	})
+
+	var companyStore scraper.CompanyStore
+	if cfg.Scraper.CompanyCache.Persistent {
+		companyStore = jobStorage
+	}
+	proxies, err := newProxyPool(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize proxy pool: %w", err)
+	}
+
+	robots, err := newRobotsChecker(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize robots.txt policy: %w", err)
+	}
+
+	retry := scraper.RetryPolicy{
+		MaxAttempts:          cfg.Scraper.Retry.MaxAttempts,
+		InitialBackoff:       cfg.Scraper.Retry.InitialBackoff,
+		MaxBackoff:           cfg.Scraper.Retry.MaxBackoff,
+		RetryableStatusCodes: cfg.Scraper.Retry.RetryableStatusCodes,
+		Timeout:              cfg.Scraper.Retry.Timeout,
+	}
+	if err := retry.Validate(); err != nil {
+		return fmt.Errorf("invalid scraper retry policy: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if proxies != nil {
+		go proxies.RunHealthChecks(ctx, cfg.Scraper.Proxies.HealthCheckInterval)
+	}
+
+	webhooks := webhook.NewDispatcher(jobStorage, webhook.Config{
+		MaxAttempts:    cfg.Webhooks.MaxAttempts,
+		InitialBackoff: cfg.Webhooks.InitialBackoff,
+		MaxBackoff:     cfg.Webhooks.MaxBackoff,
+		Timeout:        cfg.Webhooks.Timeout,
+		Workers:        cfg.Webhooks.Workers,
+	}, logger)
+	webhooks.Start(ctx)
+
+	digester, err := newDigester(cfg, jobStorage, logger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize email digests: %w", err)
+	}
+	if digester != nil {
+		go digester.Run(ctx, cfg.Notifications.CheckInterval)
+	}
+
+	alerts := notify.NewAlerter(jobStorage, notify.AlertConfig{
+		BatchWindow:        cfg.Notifications.Chat.BatchWindow,
+		MaxMessagesPerHour: cfg.Notifications.Chat.MaxMessagesPerHour,
+		MaxJobsPerMessage:  cfg.Notifications.Chat.MaxJobsPerMessage,
+		MaxPending:         cfg.Notifications.Chat.MaxPending,
+		Timeout:            cfg.Notifications.Chat.Timeout,
+	}, logger)
+
+	if err := bootstrapAdmin(jobStorage, logger); err != nil {
+		return fmt.Errorf("failed to create the first admin user: %w", err)
+	}
+
+	searches := search.NewEngine(jobStorage, logger)
+	blocked := blocklist.New(jobStorage, logger)
+
+	scraperOptions := scraper.Options{
+		CompanyCache:      scraper.NewCompanyCache(cfg.Scraper.CompanyCache.TTL, companyStore),
+		Limiter:           newDomainLimiter(cfg),
+		Proxies:           proxies,
+		Robots:            robots,
+		Retry:             retry,
+		PartialResults:    cfg.Scraper.PartialResults,
+		DetailConcurrency: cfg.Scraper.DetailConcurrency,
+		Batch: scraper.BatchPolicy{
+			Size:          cfg.Scraper.SaveBatch.Size,
+			FlushInterval: cfg.Scraper.SaveBatch.FlushInterval,
+		},
+	}
+
+	var limiter, ipLimiter *ratelimit.Limiter
+	if cfg.RateLimit.RequestsPerMinute > 0 {
+		limiter = ratelimit.New(cfg.RateLimit.RequestsPerMinute, cfg.RateLimit.Burst)
+	}
+	if cfg.RateLimit.IPRequestsPerMinute > 0 {
+		ipLimiter = ratelimit.New(cfg.RateLimit.IPRequestsPerMinute, cfg.RateLimit.IPBurst)
+	}
+
+	router := setupRouter(jobStorage, detector, scraperOptions, webhooks, digester, alerts, searches, blocked, limiter, ipLimiter, cfg.RateLimit.ScrapePagesPerDay, cfg.Server.AllowedOrigins, logger)
 
 	srv := &http.Server{
 		Addr:         cfg.Server.Address,
@@ -68,21 +172,216 @@ func run() error {
 	return nil
 }
 
-func setupRouter(jobStorage storage.JobStorage, logger *log.Logger) *chi.Mux {
+func newDomainLimiter(cfg *config.Config) *scraper.DomainLimiter {
+	politeness := func(c config.PolitenessConfig) scraper.Politeness {
+		return scraper.Politeness{RequestsPerSecond: c.RequestsPerSecond, Burst: c.Burst, Jitter: c.Jitter}
+	}
+
+	sources := make(map[scraper.ScraperType]scraper.Politeness, len(cfg.Scraper.Politeness.Sources))
+	for source, c := range cfg.Scraper.Politeness.Sources {
+		sources[scraper.ScraperType(source)] = politeness(c)
+	}
+
+	backoff := scraper.BlockBackoff{
+		Initial: cfg.Scraper.BlockBackoff.Initial,
+		Max:     cfg.Scraper.BlockBackoff.Max,
+	}
+
+	return scraper.NewDomainLimiter(politeness(cfg.Scraper.Politeness.Default), sources, backoff)
+}
+
+func newRobotsChecker(cfg *config.Config) (*scraper.RobotsChecker, error) {
+	sources := make(map[scraper.ScraperType]scraper.RobotsPolicy, len(cfg.Scraper.Robots.Sources))
+	for source, policy := range cfg.Scraper.Robots.Sources {
+		sources[scraper.ScraperType(source)] = scraper.RobotsPolicy(policy)
+	}
+
+	return scraper.NewRobotsChecker(scraper.RobotsPolicy(cfg.Scraper.Robots.DefaultPolicy), sources, cfg.Scraper.Robots.CacheTTL)
+}
+
+// newDigester builds the email digester. It returns nil when no SMTP server
+// is configured.
+func newDigester(cfg *config.Config, store notify.SubscriberStore, logger *log.Logger) (*notify.Digester, error) {
+	smtpCfg := cfg.Notifications.SMTP
+	if smtpCfg.Host == "" {
+		return nil, nil
+	}
+
+	mailer, err := notify.NewSMTPMailer(notify.SMTPConfig{
+		Host:     smtpCfg.Host,
+		Port:     smtpCfg.Port,
+		Username: smtpCfg.Username,
+		Password: smtpCfg.Password,
+		From:     smtpCfg.From,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return notify.NewDigester(store, mailer, strings.TrimSuffix(cfg.Notifications.BaseURL, "/"), logger), nil
+}
+
+// bootstrapAdmin creates an admin user and logs its API key when no user
+// exists yet, so that a new deployment can be administered. The records
+// created before users existed are given to that admin.
+func bootstrapAdmin(store storage.UserStorage, logger *log.Logger) error {
+	count, err := store.CountUsers()
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	admin := auth.User{
+		ID:        uuid.New().String(),
+		Name:      "admin",
+		Role:      auth.RoleAdmin,
+		CreatedAt: time.Now(),
+	}
+	if err := store.SaveUser(admin); err != nil {
+		return err
+	}
+
+	key, plaintext, err := auth.NewAPIKey(admin.ID, "bootstrap", nil)
+	if err != nil {
+		return err
+	}
+	if err := store.SaveAPIKey(key); err != nil {
+		return err
+	}
+
+	if err := store.ClaimUnowned(admin.ID); err != nil {
+		return err
+	}
+
+	logger.Printf("Created admin user %s with API key %s. Store the key now, it is not shown again.", admin.ID, plaintext)
+	return nil
+}
+
+// newProxyPool builds the proxy pool from the configured URLs and proxy file.
+// It returns nil when no proxy is configured.
+func newProxyPool(cfg *config.Config) (*scraper.ProxyPool, error) {
+	proxyCfg := cfg.Scraper.Proxies
+	urls := append([]string(nil), proxyCfg.URLs...)
+	if proxyCfg.File != "" {
+		fromFile, err := scraper.LoadProxyList(proxyCfg.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read proxy file: %w", err)
+		}
+		urls = append(urls, fromFile...)
+	}
+	if len(urls) == 0 {
+		return nil, nil
+	}
+
+	return scraper.NewProxyPool(scraper.ProxyPoolConfig{
+		URLs:           urls,
+		Strategy:       scraper.ProxyStrategy(proxyCfg.Strategy),
+		BanDuration:    proxyCfg.BanDuration,
+		MaxFailures:    proxyCfg.MaxFailures,
+		HealthCheckURL: proxyCfg.HealthCheckURL,
+	})
+}
+
+func setupRouter(jobStorage storage.Storage, detector *dedup.Detector, scraperOptions scraper.Options, webhooks *webhook.Dispatcher, digester *notify.Digester, alerts *notify.Alerter, searches *search.Engine, blocked *blocklist.Blocklist, limiter, ipLimiter *ratelimit.Limiter, scrapeQuota int, allowedOrigins []string, logger *log.Logger) *chi.Mux {
 	r := chi.NewRouter()
 
 	r.Use(middleware.RequestID)
 	r.Use(middleware.RealIP)
+	r.Use(api.StripAPIKey)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
-	r.Use(middleware.Timeout(60 * time.Second))
 	r.Use(render.SetContentType(render.ContentTypeJSON))
 
-	handler := api.NewHandler(jobStorage, logger)
+	handler := api.NewHandler(jobStorage, detector, scraperOptions, webhooks, digester, alerts, searches, blocked, limiter, ipLimiter, scrapeQuota, allowedOrigins, logger)
 
 	r.Route("/api/v1", func(r chi.Router) {
-		r.Get("/jobs", handler.GetJobs)
-		r.Post("/scrape", handler.StartScraping)
+		// Unsubscribe links are followed from digest emails, without an API
+		// key. Only POST unsubscribes: GET serves a confirmation page.
+		r.With(handler.RateLimitIP, middleware.Timeout(60*time.Second)).Get("/subscribers/unsubscribe", handler.UnsubscribePage)
+		r.With(handler.RateLimitIP, middleware.Timeout(60*time.Second)).Post("/subscribers/unsubscribe", handler.Unsubscribe)
+		// Feed readers authenticate with the feed token of the search.
+		r.With(handler.RateLimitIP, middleware.Timeout(60*time.Second)).Get("/feeds/{searchID}.atom", handler.GetAtomFeed)
+		r.With(handler.RateLimitIP, middleware.Timeout(60*time.Second)).Get("/feeds/{searchID}.rss", handler.GetRSSFeed)
+
+		r.Group(func(r chi.Router) {
+			r.Use(handler.Authenticate)
+			r.Use(handler.RateLimit)
+
+			r.Group(func(r chi.Router) {
+				r.Use(middleware.Timeout(60 * time.Second))
+
+				r.Get("/me", handler.GetMe)
+				r.Get("/me/quota", handler.GetQuota)
+				r.Get("/users/{id}/keys", handler.GetAPIKeys)
+				r.Post("/users/{id}/keys", handler.CreateAPIKey)
+				r.Delete("/users/{id}/keys/{keyID}", handler.RevokeAPIKey)
+				r.Post("/users/{id}/keys/{keyID}/rotate", handler.RotateAPIKey)
+				r.Get("/jobs", handler.GetJobs)
+				r.Post("/scrape", handler.StartScraping)
+				r.Get("/companies", handler.GetCompanies)
+				r.Get("/companies/{id}", handler.GetCompany)
+				r.Get("/companies/{id}/jobs", handler.GetCompanyJobs)
+				r.Get("/proxies", handler.GetProxies)
+				r.Get("/scrapes", handler.GetRuns)
+				r.Get("/scrapes/{id}", handler.GetRun)
+				r.Post("/subscribers", handler.CreateSubscriber)
+				r.Get("/subscribers", handler.GetSubscribers)
+				r.Get("/subscribers/{id}", handler.GetSubscriber)
+				r.Delete("/subscribers/{id}", handler.DeleteSubscriber)
+				r.Post("/subscribers/{id}/digest", handler.SendDigest)
+				r.Post("/searches", handler.CreateSearch)
+				r.Get("/searches", handler.GetSearches)
+				r.Get("/searches/{id}", handler.GetSearch)
+				r.Delete("/searches/{id}", handler.DeleteSearch)
+				r.Get("/searches/{id}/matches", handler.GetSearchMatches)
+				r.Post("/searches/{id}/feed-token", handler.ResetFeedToken)
+				r.Get("/blocklist", handler.GetBlockRules)
+				r.Get("/applications", handler.GetApplications)
+				r.Put("/jobs/{id}/application", handler.PutApplication)
+				r.Get("/jobs/{id}/application", handler.GetApplication)
+				r.Delete("/jobs/{id}/application", handler.DeleteApplication)
+
+				// Users, shared settings and destructive operations.
+				r.Group(func(r chi.Router) {
+					r.Use(handler.RequireAdmin)
+
+					r.Delete("/jobs", handler.ClearJobs)
+					r.Post("/users", handler.CreateUser)
+					r.Get("/users", handler.GetUsers)
+					r.Get("/users/{id}", handler.GetUser)
+					r.Delete("/users/{id}", handler.DeleteUser)
+					r.Post("/webhooks", handler.CreateWebhook)
+					r.Get("/webhooks", handler.GetWebhooks)
+					r.Get("/webhooks/{id}", handler.GetWebhook)
+					r.Delete("/webhooks/{id}", handler.DeleteWebhook)
+					r.Get("/webhooks/{id}/deliveries", handler.GetWebhookDeliveries)
+					r.Post("/webhooks/{id}/deliveries/{deliveryID}/redeliver", handler.RedeliverWebhook)
+					r.Post("/alerts", handler.CreateAlertRule)
+					r.Get("/alerts", handler.GetAlertRules)
+					r.Get("/alerts/{id}", handler.GetAlertRule)
+					r.Delete("/alerts/{id}", handler.DeleteAlertRule)
+					r.Post("/alerts/{id}/test", handler.TestAlertRule)
+					r.Post("/blocklist", handler.CreateBlockRule)
+					r.Delete("/blocklist/{id}", handler.DeleteBlockRule)
+				})
+			})
+
+			// Exports stay open for as long as the file takes to download.
+			r.Get("/jobs/export", handler.ExportJobs)
+		})
+
+		// Event streams stay open for as long as the scrape they follow.
+		// Browsers open them without custom headers, so the API key may be
+		// passed in the query.
+		r.Group(func(r chi.Router) {
+			r.Use(handler.AuthenticateStream)
+			r.Use(handler.RateLimit)
+
+			r.Get("/scrapes/{id}/events", handler.GetRunEvents)
+			r.Get("/jobs/stream", handler.StreamJobs)
+		})
 	})
 
 	r.Get("/swagger/*", httpSwagger.Handler(
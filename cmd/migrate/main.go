@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/ayagmar/gojobscraper/internal/config"
+	"github.com/ayagmar/gojobscraper/internal/storage"
+)
+
+const usage = `Usage: migrate <command> [argument]
+
+Commands:
+  up [version]   apply pending migrations, up to version if given
+  down [steps]   revert the last applied migration, or the last steps migrations
+  status         list migrations and whether they have been applied`
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		log.Fatalf("Migration error: %v", err)
+	}
+}
+
+func run(args []string) error {
+	if len(args) == 0 || len(args) > 2 {
+		return errors.New(usage)
+	}
+
+	command := args[0]
+	argument := 0
+	if len(args) == 2 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid argument %q: must be a positive integer", args[1])
+		}
+		argument = n
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	migrator, err := storage.NewMongoDBMigrator(cfg.Database.URL, cfg.Database.Name)
+	if err != nil {
+		return fmt.Errorf("failed to initialize migrator: %w", err)
+	}
+	defer func(migrator storage.Migrator) {
+		err := migrator.Close()
+		if err != nil {
+			log.Printf("Error closing migrator: %v", err)
+		}
+	}(migrator)
+
+	ctx := context.Background()
+
+	switch command {
+	case "up":
+		versions, err := migrator.Up(ctx, argument)
+		if err != nil {
+			return err
+		}
+		log.Printf("Applied %d migrations %v", len(versions), versions)
+	case "down":
+		if argument == 0 {
+			argument = 1
+		}
+		versions, err := migrator.Down(ctx, argument)
+		if err != nil {
+			return err
+		}
+		log.Printf("Reverted %d migrations %v", len(versions), versions)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.AppliedAt != nil {
+				state = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%4d  %-28s  %s\n", status.Version, state, status.Description)
+		}
+	default:
+		return fmt.Errorf("unknown command %q\n%s", command, usage)
+	}
+
+	return nil
+}
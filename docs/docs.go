@@ -16,29 +16,37 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/jobs": {
+        "/alerts": {
             "get": {
-                "description": "Get a list of jobs",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Get the chat alert rules. Requires the admin role.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "jobScraper"
+                    "alerts"
+                ],
+                "summary": "Get alert rules",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
-                "summary": "Get jobs",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/scraper.JobPosting"
+                                "$ref": "#/definitions/notify.AlertRule"
                             }
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -46,11 +54,9 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/scrape": {
+            },
             "post": {
-                "description": "Start scraping jobs based on the provided configuration",
+                "description": "Post the newly scraped jobs matching the filter to a chat incoming webhook. Matching jobs are collected for the batch window and posted as one message listing their title, company, location and link; messages beyond the hourly limit are held back until it allows. Requires the admin role.",
                 "consumes": [
                     "application/json"
                 ],
@@ -58,122 +64,3513 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "jobScraper"
+                    "alerts"
+                ],
+                "summary": "Create alert rule",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
-                "summary": "Start scraping",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "JobPosting Title",
-                        "name": "jobTitle",
-                        "in": "query",
-                        "required": true
+                        "description": "Alert rule",
+                        "name": "rule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.AlertRuleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/notify.AlertRule"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/alerts/{id}": {
+            "get": {
+                "description": "Get a chat alert rule. Requires the admin role.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "alerts"
+                ],
+                "summary": "Get alert rule",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "parameters": [
                     {
                         "type": "string",
-                        "description": "Country",
-                        "name": "country",
-                        "in": "query",
+                        "description": "Alert rule ID",
+                        "name": "id",
+                        "in": "path",
                         "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/notify.AlertRule"
+                        }
                     },
-                    {
-                        "type": "integer",
-                        "default": 1,
-                        "description": "Number of Pages",
-                        "name": "pages",
-                        "in": "query"
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
                     },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Remove a chat alert rule. Jobs already batched for it are still posted. Requires the admin role.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "alerts"
+                ],
+                "summary": "Delete alert rule",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "parameters": [
                     {
-                        "enum": [
-                            "indeed",
-                            "linkedin"
-                        ],
                         "type": "string",
-                        "description": "Source of job listings (indeed or linkedin)",
-                        "name": "source",
-                        "in": "query",
+                        "description": "Alert rule ID",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "202": {
-                        "description": "Accepted",
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/api.SuccessResponse"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
-                    "501": {
-                        "description": "Not Implemented",
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "api.ErrorResponse": {
-            "type": "object",
-            "properties": {
-                "error": {
-                    "type": "string"
-                },
-                "status": {
-                    "type": "string"
-                }
-            }
         },
-        "api.SuccessResponse": {
-            "type": "object",
-            "properties": {
-                "message": {
-                    "type": "string"
+        "/alerts/{id}/test": {
+            "post": {
+                "description": "Post a sample job to the rule's webhook right away, outside its rate limit, to check the URL and format. Requires the admin role.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "alerts"
+                ],
+                "summary": "Test alert rule",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Alert rule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
                 }
             }
         },
-        "scraper.CompanyDetails": {
-            "description": "Company details",
-            "type": "object",
-            "properties": {
-                "industry": {
-                    "type": "string"
-                },
-                "name": {
-                    "type": "string"
-                },
-                "platform_company_url": {
-                    "type": "string"
-                },
-                "url": {
-                    "type": "string"
+        "/applications": {
+            "get": {
+                "description": "Get the applications tracked by the current user with their job postings, most recently updated first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "applications"
+                ],
+                "summary": "Get applications",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "parameters": [
+                    {
+                        "enum": [
+                            "saved",
+                            "applied",
+                            "interviewing",
+                            "offer",
+                            "rejected"
+                        ],
+                        "type": "string",
+                        "description": "Pipeline status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tag",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only applications with a reminder due before this RFC 3339 time",
+                        "name": "remind_before",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/tracking.Application"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
                 }
             }
         },
-        "scraper.JobPosting": {
-            "description": "Job posting details",
-            "type": "object",
-            "properties": {
-                "company_details": {
-                    "$ref": "#/definitions/scraper.CompanyDetails"
-                },
-                "createdAt": {
-                    "type": "string"
-                },
-                "description": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "string"
-                },
-                "location": {
-                    "type": "string"
+        "/blocklist": {
+            "get": {
+                "description": "Get the blocklist rules applied to scraped postings",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "blocklist"
+                ],
+                "summary": "Get blocklist rules",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/blocklist.Rule"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Block postings by company name, company name regular expression, title keyword or company website domain. Blocked postings are either dropped before they are stored or stored hidden with the reason. Rules apply to jobs scraped from then on. Requires the admin role.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "blocklist"
+                ],
+                "summary": "Create blocklist rule",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "parameters": [
+                    {
+                        "description": "Blocklist rule",
+                        "name": "rule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.BlockRuleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/blocklist.Rule"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/blocklist/{id}": {
+            "delete": {
+                "description": "Remove a blocklist rule. Postings it hid stay hidden until they are scraped again. Requires the admin role.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "blocklist"
+                ],
+                "summary": "Delete blocklist rule",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Blocklist rule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/companies": {
+            "get": {
+                "description": "Get the list of companies referenced by scraped jobs",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "companies"
+                ],
+                "summary": "Get companies",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/scraper.Company"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/companies/{id}": {
+            "get": {
+                "description": "Get a company by ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "companies"
+                ],
+                "summary": "Get company",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Company ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/scraper.Company"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/companies/{id}/jobs": {
+            "get": {
+                "description": "Get the jobs posted by a company. Postings hidden by the blocklist are left out unless include_hidden is set.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "companies"
+                ],
+                "summary": "Get company jobs",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Company ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Include postings hidden by the blocklist",
+                        "name": "include_hidden",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/scraper.JobPosting"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/feeds/{searchID}.atom": {
+            "get": {
+                "description": "Get the most recent jobs matched by a saved search as an Atom feed, for feed readers. The feed is authenticated by the feed token of the search rather than an API key. Conditional requests with If-None-Match or If-Modified-Since are answered with 304 Not Modified while no new job matched.",
+                "produces": [
+                    "application/atom+xml"
+                ],
+                "tags": [
+                    "searches"
+                ],
+                "summary": "Get saved search Atom feed",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Saved search ID",
+                        "name": "searchID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Feed token of the saved search",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/feeds/{searchID}.rss": {
+            "get": {
+                "description": "Get the most recent jobs matched by a saved search as an RSS 2.0 feed, for feed readers. The feed is authenticated by the feed token of the search rather than an API key. Conditional requests with If-None-Match or If-Modified-Since are answered with 304 Not Modified while no new job matched.",
+                "produces": [
+                    "application/rss+xml"
+                ],
+                "tags": [
+                    "searches"
+                ],
+                "summary": "Get saved search RSS feed",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Saved search ID",
+                        "name": "searchID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Feed token of the saved search",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs": {
+            "get": {
+                "description": "Get a list of jobs matching the filters. With dedupe enabled, postings of the same role are collapsed into a canonical job listing the others under duplicates. Postings hidden by the blocklist are left out unless include_hidden is set.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobScraper"
+                ],
+                "summary": "Get jobs",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "parameters": [
+                    {
+                        "enum": [
+                            "indeed",
+                            "linkedin"
+                        ],
+                        "type": "string",
+                        "description": "Source of job listings (indeed or linkedin)",
+                        "name": "source",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Whole words in the title, summary, description or company name",
+                        "name": "keyword",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Whole words of the location",
+                        "name": "location",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Collapse duplicate postings",
+                        "name": "dedupe",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Include postings hidden by the blocklist",
+                        "name": "include_hidden",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/scraper.JobPosting"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete every stored job posting. Applications and search matches are kept. Requires the admin role.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobScraper"
+                ],
+                "summary": "Clear jobs",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs/export": {
+            "get": {
+                "description": "Download the jobs matching the filters as a CSV, NDJSON or XLSX file, newest first. Jobs are streamed from the database as the file is written, so exports are not limited in size. CSV and XLSX files have one column per field, with the company details flattened into company_* columns; NDJSON files have one job per line with the fields of GET /jobs. Duplicate collapsing is not available for exports.",
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "jobScraper"
+                ],
+                "summary": "Export jobs",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "parameters": [
+                    {
+                        "enum": [
+                            "csv",
+                            "ndjson",
+                            "xlsx"
+                        ],
+                        "type": "string",
+                        "description": "File format",
+                        "name": "format",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "indeed",
+                            "linkedin"
+                        ],
+                        "type": "string",
+                        "description": "Source of job listings (indeed or linkedin)",
+                        "name": "source",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Whole words in the title, summary, description or company name",
+                        "name": "keyword",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Whole words of the location",
+                        "name": "location",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Include postings hidden by the blocklist",
+                        "name": "include_hidden",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs/stream": {
+            "get": {
+                "description": "Open a WebSocket receiving every newly inserted job posting that matches the filter, as JSON messages of type \"job\". Updates of known postings are not sent. Messages of type \"ping\" are sent while the feed is idle. Connections from web pages of origins other than the API's own and the configured allowed origins are refused with 403.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobScraper"
+                ],
+                "summary": "Stream new jobs",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "parameters": [
+                    {
+                        "enum": [
+                            "indeed",
+                            "linkedin"
+                        ],
+                        "type": "string",
+                        "description": "Source of job listings (indeed or linkedin)",
+                        "name": "source",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Whole words in the title, summary, description or company name",
+                        "name": "keyword",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Whole words of the location",
+                        "name": "location",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "API key, for clients that cannot set headers",
+                        "name": "api_key",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols",
+                        "schema": {
+                            "$ref": "#/definitions/api.JobStreamMessage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs/{id}/application": {
+            "get": {
+                "description": "Get the application the current user tracks for a job posting",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "applications"
+                ],
+                "summary": "Get job application",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/tracking.Application"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Create or replace the status, notes, tags and reminder the current user tracks for a job posting. Status changes are added to the application history. Tracking data is stored apart from the posting, so re-scrapes leave it intact.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "applications"
+                ],
+                "summary": "Set job application",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Application",
+                        "name": "application",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.ApplicationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/tracking.Application"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Stop tracking the application to a job posting",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "applications"
+                ],
+                "summary": "Delete job application",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me": {
+            "get": {
+                "description": "Get the user owning the API key of the request",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get current user",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/auth.User"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/quota": {
+            "get": {
+                "description": "Get how many pages the current user may still scrape today. Quotas reset at midnight UTC; admins have none.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get scrape quota",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.QuotaResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/proxies": {
+            "get": {
+                "description": "Get usage and health statistics of the scraper proxy pool",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobScraper"
+                ],
+                "summary": "Get proxies",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/scraper.ProxyStats"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/scrape": {
+            "post": {
+                "description": "Start scraping jobs based on the provided configuration. The pages count against the daily scrape quota of the user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobScraper"
+                ],
+                "summary": "Start scraping",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "JobPosting Title",
+                        "name": "jobTitle",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Country",
+                        "name": "country",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Number of Pages",
+                        "name": "pages",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "indeed",
+                            "linkedin"
+                        ],
+                        "type": "string",
+                        "description": "Source of job listings (indeed or linkedin)",
+                        "name": "source",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/api.ScrapeStartedResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/scrapes": {
+            "get": {
+                "description": "Get the scrape runs of the current user, most recent first. Admins get the runs of every user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scrapes"
+                ],
+                "summary": "Get scrape runs",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/scraper.ScrapeRun"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/scrapes/{id}": {
+            "get": {
+                "description": "Get the status and results of a scrape run. The runs of other users are not found, except by admins.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scrapes"
+                ],
+                "summary": "Get scrape run",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Scrape run ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/scraper.ScrapeRun"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/scrapes/{id}/events": {
+            "get": {
+                "description": "Stream the progress of a scrape run as Server-Sent Events: page_visited, job_parsed, job_saved, error and finished. Clients reconnecting with Last-Event-ID receive the events they missed. Runs whose events are no longer kept only send their finished event. The runs of other users are not found, except by admins.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "scrapes"
+                ],
+                "summary": "Stream scrape run events",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Scrape run ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the last event received",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "API key, for clients that cannot set headers",
+                        "name": "api_key",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/scraper.ScrapeEvent"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/searches": {
+            "get": {
+                "description": "Get the saved searches of the current user, or every saved search for admins",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "searches"
+                ],
+                "summary": "Get saved searches",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/search.SavedSearch"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Save a search. Every job saved from then on is evaluated against its criteria and recorded as a match when it satisfies them. Seniority is inferred from the job title and salaries are read from the summary and description. The feed token authenticates the Atom and RSS feeds of the search.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "searches"
+                ],
+                "summary": "Create saved search",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "parameters": [
+                    {
+                        "description": "Saved search",
+                        "name": "search",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.SearchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/search.SavedSearch"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/searches/{id}": {
+            "get": {
+                "description": "Get a saved search",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "searches"
+                ],
+                "summary": "Get saved search",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Saved search ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/search.SavedSearch"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Remove a saved search and its matches",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "searches"
+                ],
+                "summary": "Delete saved search",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Saved search ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/searches/{id}/feed-token": {
+            "post": {
+                "description": "Replace the token of the Atom and RSS feeds of a saved search, revoking the feed URLs handed out so far. Searches saved before feeds were available get their first token this way.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "searches"
+                ],
+                "summary": "Reset saved search feed token",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Saved search ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/search.SavedSearch"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/searches/{id}/matches": {
+            "get": {
+                "description": "Get the most recent jobs matched by a saved search, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "searches"
+                ],
+                "summary": "Get saved search matches",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Saved search ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Maximum number of matches",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/search.Match"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscribers": {
+            "get": {
+                "description": "Get the email digest subscribers of the current user, or every subscriber for admins",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscribers"
+                ],
+                "summary": "Get subscribers",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/notify.Subscriber"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Subscribe an email address to digests of the jobs first seen since the previous digest that match the filter, grouped by company",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscribers"
+                ],
+                "summary": "Create subscriber",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "parameters": [
+                    {
+                        "description": "Subscriber",
+                        "name": "subscriber",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.SubscriberRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/notify.Subscriber"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscribers/unsubscribe": {
+            "get": {
+                "description": "Show a page asking to confirm the unsubscribe. Following the link does not unsubscribe, so that mail scanners fetching the links of emails do not unsubscribe their recipients.",
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "subscribers"
+                ],
+                "summary": "Confirm unsubscribe",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Unsubscribe token",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Stop the digests of the subscriber the token was issued to. Mail clients send List-Unsubscribe=One-Click as the form body, which is not required.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "subscribers"
+                ],
+                "summary": "Unsubscribe",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Unsubscribe token",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscribers/{id}": {
+            "get": {
+                "description": "Get an email digest subscriber",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscribers"
+                ],
+                "summary": "Get subscriber",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscriber ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/notify.Subscriber"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Remove an email digest subscriber",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscribers"
+                ],
+                "summary": "Delete subscriber",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscriber ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscribers/{id}/digest": {
+            "post": {
+                "description": "Send the subscriber's digest now instead of at its next scheduled time. The next digest is rescheduled from now.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscribers"
+                ],
+                "summary": "Send digest",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscriber ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.DigestSentResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "get": {
+                "description": "Get the API users. Requires the admin role.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get users",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/auth.User"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create an API user along with its first API key. Requires the admin role.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Create user",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "parameters": [
+                    {
+                        "description": "User",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.UserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/api.UserCreatedResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}": {
+            "get": {
+                "description": "Get an API user. Requires the admin role.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get user",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/auth.User"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Remove an API user, revoke its API keys and delete its subscribers and saved searches, along with their feed tokens. The applications of the user are kept. Requires the admin role.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Delete user",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/keys": {
+            "get": {
+                "description": "Get the API keys of a user, including revoked and expired ones. Users may list their own keys, admins the keys of every user.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get API keys",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/auth.APIKey"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create an API key for a user. The key is only returned in this response. Users may create their own keys, admins keys for every user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Create API key",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "API key",
+                        "name": "key",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.APIKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIKeyCreatedResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/keys/{keyID}": {
+            "delete": {
+                "description": "Revoke an API key. It stops working immediately and stays listed as revoked.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Revoke API key",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "API key ID",
+                        "name": "keyID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/keys/{keyID}/rotate": {
+            "post": {
+                "description": "Create a new API key with the name and expiry of an existing one and revoke the existing key. The new key is only returned in this response.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Rotate API key",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "API key ID",
+                        "name": "keyID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIKeyCreatedResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "description": "Get the registered webhooks. Requires the admin role.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Get webhooks",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/webhook.Webhook"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Register an endpoint receiving signed JSON POSTs for run.finished, run.failed and jobs.new events. Each request carries X-Webhook-Event, X-Webhook-Delivery, X-Webhook-Timestamp and X-Webhook-Signature headers; the signature is \"sha256=\" followed by the hex HMAC-SHA256 of the timestamp, a dot and the body, keyed by the secret. The secret is only returned by this endpoint. Requires the admin role.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Create webhook",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "parameters": [
+                    {
+                        "description": "Webhook",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.WebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/api.WebhookCreatedResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}": {
+            "get": {
+                "description": "Get a registered webhook. Requires the admin role.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Get webhook",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/webhook.Webhook"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Remove a webhook. Its pending deliveries are dropped; its delivery log is kept. Requires the admin role.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Delete webhook",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries": {
+            "get": {
+                "description": "Get the 100 most recent deliveries of a webhook with their attempts, newest first. Requires the admin role.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Get webhook deliveries",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/webhook.Delivery"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries/{deliveryID}/redeliver": {
+            "post": {
+                "description": "Send the payload of a past delivery again, as a new delivery with its own attempts. Requires the admin role.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Redeliver webhook delivery",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Delivery ID",
+                        "name": "deliveryID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/webhook.Delivery"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "api.APIKeyCreatedResponse": {
+            "description": "New API key. The key itself is only returned once.",
+            "type": "object",
+            "properties": {
+                "api_key": {
+                    "$ref": "#/definitions/auth.APIKey"
+                },
+                "key": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.APIKeyRequest": {
+            "description": "API key",
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "description": "ExpiresAt is when the key stops working. Omit it for a key that does\nnot expire.",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.AlertRuleRequest": {
+            "description": "Chat alert rule",
+            "type": "object",
+            "properties": {
+                "batch_window_seconds": {
+                    "description": "BatchWindowSeconds is how long matching jobs are collected before\nthey are posted in a single message. Zero uses the configured default.",
+                    "type": "integer"
+                },
+                "filter": {
+                    "$ref": "#/definitions/filter.JobFilter"
+                },
+                "format": {
+                    "$ref": "#/definitions/notify.ChatFormat"
+                },
+                "max_messages_per_hour": {
+                    "description": "MaxMessagesPerHour caps the messages posted for the rule. Zero uses\nthe configured default.",
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "url": {
+                    "description": "URL is the Slack, Discord or other incoming webhook URL.",
+                    "type": "string"
+                }
+            }
+        },
+        "api.ApplicationRequest": {
+            "description": "Application tracking data",
+            "type": "object",
+            "properties": {
+                "notes": {
+                    "type": "string"
+                },
+                "remind_at": {
+                    "description": "RemindAt is when to follow up on the application. Omit it to clear\nthe reminder.",
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/tracking.Status"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "api.BlockRuleRequest": {
+            "description": "Blocklist rule",
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "Action defaults to hide.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/blocklist.Action"
+                        }
+                    ]
+                },
+                "kind": {
+                    "$ref": "#/definitions/blocklist.Kind"
+                },
+                "pattern": {
+                    "description": "Pattern is a company name, a regular expression matched against\ncompany names, a title keyword or a company website domain, depending\non the kind.",
+                    "type": "string"
+                },
+                "reason": {
+                    "description": "Reason is recorded on the postings the rule hides.",
+                    "type": "string"
+                }
+            }
+        },
+        "api.DigestSentResponse": {
+            "description": "Result of sending a digest",
+            "type": "object",
+            "properties": {
+                "jobs": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.JobStreamMessage": {
+            "description": "Message of the live job feed",
+            "type": "object",
+            "properties": {
+                "job": {
+                    "$ref": "#/definitions/scraper.JobPosting"
+                },
+                "type": {
+                    "description": "Type is \"job\" for a newly inserted posting and \"ping\" for keep-alives.",
+                    "type": "string"
+                }
+            }
+        },
+        "api.QuotaResponse": {
+            "description": "Daily scrape quota",
+            "type": "object",
+            "properties": {
+                "pages_per_day": {
+                    "description": "PagesPerDay is zero when the user has no quota.",
+                    "type": "integer"
+                },
+                "pages_remaining": {
+                    "type": "integer"
+                },
+                "pages_used": {
+                    "type": "integer"
+                },
+                "reset_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.ScrapeStartedResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "run_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.SearchRequest": {
+            "description": "Saved search",
+            "type": "object",
+            "properties": {
+                "criteria": {
+                    "$ref": "#/definitions/filter.Criteria"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.SubscriberRequest": {
+            "description": "Email digest subscription",
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "filter": {
+                    "$ref": "#/definitions/filter.JobFilter"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "schedule": {
+                    "$ref": "#/definitions/notify.Schedule"
+                }
+            }
+        },
+        "api.SuccessResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.UserCreatedResponse": {
+            "description": "New API user. The key itself is only returned once.",
+            "type": "object",
+            "properties": {
+                "api_key": {
+                    "$ref": "#/definitions/auth.APIKey"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/auth.User"
+                }
+            }
+        },
+        "api.UserRequest": {
+            "description": "API user",
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "role": {
+                    "description": "Role defaults to user.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/auth.Role"
+                        }
+                    ]
+                }
+            }
+        },
+        "api.WebhookCreatedResponse": {
+            "description": "Created webhook and the secret its deliveries are signed with",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/webhook.EventType"
+                    }
+                },
+                "filter": {
+                    "description": "Filter selects the jobs sent in jobs.new deliveries.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/filter.JobFilter"
+                        }
+                    ]
+                },
+                "id": {
+                    "type": "string"
+                },
+                "secret": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.WebhookRequest": {
+            "description": "Webhook registration",
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/webhook.EventType"
+                    }
+                },
+                "filter": {
+                    "description": "Filter selects the jobs sent in jobs.new deliveries.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/filter.JobFilter"
+                        }
+                    ]
+                },
+                "secret": {
+                    "description": "Secret signs the deliveries. A random secret is generated when empty.",
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.APIKey": {
+            "description": "API key, without its secret",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "description": "Prefix is the start of the key, to tell keys apart.",
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.Role": {
+            "description": "User role",
+            "type": "string",
+            "enum": [
+                "user",
+                "admin"
+            ],
+            "x-enum-varnames": [
+                "RoleUser",
+                "RoleAdmin"
+            ]
+        },
+        "auth.User": {
+            "description": "API user",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "role": {
+                    "$ref": "#/definitions/auth.Role"
+                }
+            }
+        },
+        "blocklist.Action": {
+            "description": "Blocklist rule action",
+            "type": "string",
+            "enum": [
+                "drop",
+                "hide"
+            ],
+            "x-enum-varnames": [
+                "ActionDrop",
+                "ActionHide"
+            ]
+        },
+        "blocklist.Kind": {
+            "description": "Blocklist rule kind",
+            "type": "string",
+            "enum": [
+                "company",
+                "company_regex",
+                "title_keyword",
+                "domain"
+            ],
+            "x-enum-varnames": [
+                "KindCompany",
+                "KindCompanyRegex",
+                "KindTitleKeyword",
+                "KindDomain"
+            ]
+        },
+        "blocklist.Rule": {
+            "description": "Blocklist rule",
+            "type": "object",
+            "properties": {
+                "action": {
+                    "$ref": "#/definitions/blocklist.Action"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "kind": {
+                    "$ref": "#/definitions/blocklist.Kind"
+                },
+                "pattern": {
+                    "type": "string"
+                },
+                "reason": {
+                    "description": "Reason is recorded on the postings the rule hides.",
+                    "type": "string"
+                }
+            }
+        },
+        "filter.Criteria": {
+            "description": "Saved search criteria",
+            "type": "object",
+            "properties": {
+                "companies": {
+                    "description": "Companies are matched as whole words against the company name,\nignoring case.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "exclude_keywords": {
+                    "description": "ExcludeKeywords must not appear as whole words in the title, summary,\ndescription or company name.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "keywords": {
+                    "description": "Keywords must all appear as whole words in the title, summary,\ndescription or company name, ignoring case.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "locations": {
+                    "description": "Locations are matched as whole words against the location, ignoring\ncase.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "min_salary": {
+                    "description": "MinSalary is the yearly salary floor, in the currency of the posting.\nPostings are matched on the top of their salary range.",
+                    "type": "number"
+                },
+                "salary_required": {
+                    "description": "SalaryRequired excludes postings without a salary when MinSalary is\nset. Otherwise they are kept.",
+                    "type": "boolean"
+                },
+                "seniority": {
+                    "description": "Seniority is inferred from the job title.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/filter.Seniority"
+                    }
+                },
+                "sources": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/scraper.ScraperType"
+                    }
+                }
+            }
+        },
+        "filter.JobFilter": {
+            "type": "object",
+            "properties": {
+                "keyword": {
+                    "description": "Keyword must appear as whole words in the title, summary, description\nor company name, ignoring case.",
+                    "type": "string"
+                },
+                "location": {
+                    "description": "Location must appear as whole words in the location, ignoring case.",
+                    "type": "string"
+                },
+                "source": {
+                    "description": "Source restricts postings to a single source.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/scraper.ScraperType"
+                        }
+                    ]
+                }
+            }
+        },
+        "filter.Seniority": {
+            "description": "Seniority of a role",
+            "type": "string",
+            "enum": [
+                "intern",
+                "junior",
+                "mid",
+                "senior",
+                "lead"
+            ],
+            "x-enum-varnames": [
+                "SeniorityIntern",
+                "SeniorityJunior",
+                "SeniorityMid",
+                "SenioritySenior",
+                "SeniorityLead"
+            ]
+        },
+        "notify.AlertRule": {
+            "description": "Chat alert rule",
+            "type": "object",
+            "properties": {
+                "batch_window_seconds": {
+                    "description": "BatchWindowSeconds is how long matching jobs are collected before\nthey are posted in a single message. Zero uses the configured default.",
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "filter": {
+                    "$ref": "#/definitions/filter.JobFilter"
+                },
+                "format": {
+                    "$ref": "#/definitions/notify.ChatFormat"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "max_messages_per_hour": {
+                    "description": "MaxMessagesPerHour caps the messages posted for the rule. Jobs\nmatched while the limit is reached are posted once it allows. Zero\nuses the configured default.",
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "url": {
+                    "description": "URL is the incoming webhook URL messages are posted to.",
+                    "type": "string"
+                }
+            }
+        },
+        "notify.ChatFormat": {
+            "description": "Chat webhook payload format",
+            "type": "string",
+            "enum": [
+                "slack",
+                "discord",
+                "generic"
+            ],
+            "x-enum-varnames": [
+                "FormatSlack",
+                "FormatDiscord",
+                "FormatGeneric"
+            ]
+        },
+        "notify.Frequency": {
+            "description": "Digest frequency",
+            "type": "string",
+            "enum": [
+                "hourly",
+                "daily",
+                "weekly"
+            ],
+            "x-enum-varnames": [
+                "Hourly",
+                "Daily",
+                "Weekly"
+            ]
+        },
+        "notify.Schedule": {
+            "description": "Digest schedule",
+            "type": "object",
+            "properties": {
+                "frequency": {
+                    "$ref": "#/definitions/notify.Frequency"
+                },
+                "hour": {
+                    "description": "Hour is the hour of the day daily and weekly digests are sent at.",
+                    "type": "integer"
+                },
+                "timezone": {
+                    "description": "Timezone is the IANA time zone Hour and Weekday are in. Defaults to UTC.",
+                    "type": "string"
+                },
+                "weekday": {
+                    "description": "Weekday is the day weekly digests are sent on, 0 being Sunday.",
+                    "type": "integer"
+                }
+            }
+        },
+        "notify.Subscriber": {
+            "description": "Email digest subscriber",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "failed_digests": {
+                    "description": "FailedDigests counts the digests that failed to send in a row. Their\nretries are spaced out.",
+                    "type": "integer"
+                },
+                "filter": {
+                    "$ref": "#/definitions/filter.JobFilter"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_digest_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "next_digest_at": {
+                    "type": "string"
+                },
+                "owner_id": {
+                    "type": "string"
+                },
+                "schedule": {
+                    "$ref": "#/definitions/notify.Schedule"
+                },
+                "unsubscribed": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "scraper.Company": {
+            "description": "Company shared by job postings",
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "headquarters": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "industry": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "platform_company_url": {
+                    "type": "string"
+                },
+                "rating": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "string"
+                },
+                "source": {
+                    "$ref": "#/definitions/scraper.ScraperType"
+                },
+                "updatedAt": {
+                    "description": "UpdatedAt is when the details were last fetched from the company\npage. It is zero for companies only known from their postings.",
+                    "type": "string"
+                },
+                "website": {
+                    "type": "string"
+                }
+            }
+        },
+        "scraper.CompanyDetails": {
+            "description": "Company details",
+            "type": "object",
+            "properties": {
+                "headquarters": {
+                    "type": "string"
+                },
+                "industry": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "platform_company_url": {
+                    "type": "string"
+                },
+                "rating": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "scraper.DuplicateRef": {
+            "description": "Reference to a duplicate job posting",
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "platform_job_id": {
+                    "type": "string"
+                },
+                "source": {
+                    "$ref": "#/definitions/scraper.ScraperType"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "scraper.EventType": {
+            "description": "Type of scrape progress event",
+            "type": "string",
+            "enum": [
+                "page_visited",
+                "job_parsed",
+                "job_saved",
+                "error",
+                "finished"
+            ],
+            "x-enum-varnames": [
+                "EventPageVisited",
+                "EventJobParsed",
+                "EventJobSaved",
+                "EventError",
+                "EventFinished"
+            ]
+        },
+        "scraper.JobPosting": {
+            "description": "Job posting details",
+            "type": "object",
+            "properties": {
+                "company_details": {
+                    "$ref": "#/definitions/scraper.CompanyDetails"
+                },
+                "company_id": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "description": "CreatedAt is when the posting was first saved.",
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "duplicates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/scraper.DuplicateRef"
+                    }
+                },
+                "hidden": {
+                    "description": "Hidden postings matched a blocklist rule. They are stored but left\nout of listings and notifications.",
+                    "type": "boolean"
+                },
+                "hidden_reason": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "incomplete": {
+                    "type": "boolean"
+                },
+                "location": {
+                    "type": "string"
                 },
                 "platform_job_id": {
                     "type": "string"
@@ -192,6 +3589,165 @@ const docTemplate = `{
                 }
             }
         },
+        "scraper.ProxyStats": {
+            "description": "Proxy usage and health",
+            "type": "object",
+            "properties": {
+                "banned_until": {
+                    "type": "string"
+                },
+                "bans": {
+                    "type": "integer"
+                },
+                "failures": {
+                    "type": "integer"
+                },
+                "last_checked": {
+                    "type": "string"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "requests": {
+                    "type": "integer"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "scraper.RunStatus": {
+            "description": "State of a scrape run",
+            "type": "string",
+            "enum": [
+                "running",
+                "succeeded",
+                "failed",
+                "blocked"
+            ],
+            "x-enum-varnames": [
+                "RunRunning",
+                "RunSucceeded",
+                "RunFailed",
+                "RunBlocked"
+            ]
+        },
+        "scraper.RunSummary": {
+            "description": "Counters collected during a scrape",
+            "type": "object",
+            "properties": {
+                "blocked_jobs": {
+                    "description": "BlockedJobs counts the postings dropped or hidden by the blocklist.",
+                    "type": "integer"
+                },
+                "company_cache_hits": {
+                    "type": "integer"
+                },
+                "company_cache_misses": {
+                    "type": "integer"
+                },
+                "connections_opened": {
+                    "type": "integer"
+                },
+                "connections_reused": {
+                    "type": "integer"
+                },
+                "incomplete_jobs": {
+                    "type": "integer"
+                },
+                "robots_disallowed": {
+                    "type": "integer"
+                },
+                "robots_policy": {
+                    "description": "RobotsPolicy is the robots.txt policy the scrape ran under.",
+                    "type": "string",
+                    "enum": [
+                        "obey",
+                        "warn",
+                        "ignore"
+                    ]
+                }
+            }
+        },
+        "scraper.ScrapeEvent": {
+            "description": "Scrape progress event",
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "description": "ID orders the events of a run. It is assigned when the event is\npublished, not by the scraper, and is omitted from finished events\nrebuilt after the events of the run were dropped.",
+                    "type": "integer"
+                },
+                "job_id": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "run": {
+                    "$ref": "#/definitions/scraper.ScrapeRun"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/scraper.EventType"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "scraper.ScrapeRun": {
+            "description": "Scrape run status and results",
+            "type": "object",
+            "properties": {
+                "country": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "job_title": {
+                    "type": "string"
+                },
+                "jobs_scraped": {
+                    "type": "integer"
+                },
+                "pages": {
+                    "type": "integer"
+                },
+                "source": {
+                    "$ref": "#/definitions/scraper.ScraperType"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/scraper.RunStatus"
+                },
+                "summary": {
+                    "$ref": "#/definitions/scraper.RunSummary"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
         "scraper.ScraperType": {
             "description": "Type of job scraper",
             "type": "string",
@@ -203,6 +3759,282 @@ const docTemplate = `{
                 "Indeed",
                 "LinkedIn"
             ]
+        },
+        "search.Match": {
+            "description": "Job posting matched by a saved search",
+            "type": "object",
+            "properties": {
+                "job": {
+                    "description": "Job is the matched posting, attached when matches are read.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/scraper.JobPosting"
+                        }
+                    ]
+                },
+                "job_id": {
+                    "description": "JobID is the ID of the posting when the match was last read or\nrecorded. A posting scraped again after being cleared gets a new ID.",
+                    "type": "string"
+                },
+                "matched_at": {
+                    "type": "string"
+                },
+                "platform_job_id": {
+                    "type": "string"
+                },
+                "search_id": {
+                    "type": "string"
+                },
+                "source": {
+                    "description": "Source and PlatformJobID identify the matched posting across\nre-scrapes.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/scraper.ScraperType"
+                        }
+                    ]
+                }
+            }
+        },
+        "search.SavedSearch": {
+            "description": "Saved search",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "criteria": {
+                    "$ref": "#/definitions/filter.Criteria"
+                },
+                "feed_token": {
+                    "description": "FeedToken authenticates the Atom and RSS feeds of the search.",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "owner_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "tracking.Application": {
+            "description": "Application to a job posting",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "history": {
+                    "description": "History lists the statuses of the application, oldest first.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/tracking.StatusChange"
+                    }
+                },
+                "job": {
+                    "description": "Job is the posting applied to, attached when applications are listed.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/scraper.JobPosting"
+                        }
+                    ]
+                },
+                "job_id": {
+                    "description": "JobID is the ID of the posting when the application was last read or\nsaved. A posting scraped again after being cleared gets a new ID.",
+                    "type": "string"
+                },
+                "notes": {
+                    "type": "string"
+                },
+                "owner_id": {
+                    "type": "string"
+                },
+                "platform_job_id": {
+                    "type": "string"
+                },
+                "remind_at": {
+                    "description": "RemindAt is when to follow up on the application.",
+                    "type": "string"
+                },
+                "source": {
+                    "description": "Source and PlatformJobID identify the posting applied to across\nre-scrapes.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/scraper.ScraperType"
+                        }
+                    ]
+                },
+                "status": {
+                    "$ref": "#/definitions/tracking.Status"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "tracking.Status": {
+            "description": "Application pipeline status",
+            "type": "string",
+            "enum": [
+                "saved",
+                "applied",
+                "interviewing",
+                "offer",
+                "rejected"
+            ],
+            "x-enum-varnames": [
+                "StatusSaved",
+                "StatusApplied",
+                "StatusInterviewing",
+                "StatusOffer",
+                "StatusRejected"
+            ]
+        },
+        "tracking.StatusChange": {
+            "description": "Application status change",
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/tracking.Status"
+                }
+            }
+        },
+        "webhook.Attempt": {
+            "description": "Webhook delivery attempt",
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "response_body": {
+                    "type": "string"
+                },
+                "status_code": {
+                    "type": "integer"
+                }
+            }
+        },
+        "webhook.Delivery": {
+            "description": "Webhook delivery and its attempts",
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/webhook.Attempt"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "delivered_at": {
+                    "type": "string"
+                },
+                "event": {
+                    "$ref": "#/definitions/webhook.EventType"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "object"
+                },
+                "redelivery_of": {
+                    "description": "RedeliveryOf is the ID of the delivery this one repeats, if any.",
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/webhook.DeliveryStatus"
+                },
+                "webhook_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "webhook.DeliveryStatus": {
+            "description": "State of a webhook delivery",
+            "type": "string",
+            "enum": [
+                "pending",
+                "succeeded",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "DeliveryPending",
+                "DeliverySucceeded",
+                "DeliveryFailed"
+            ]
+        },
+        "webhook.EventType": {
+            "description": "Type of webhook event",
+            "type": "string",
+            "enum": [
+                "run.finished",
+                "run.failed",
+                "jobs.new"
+            ],
+            "x-enum-varnames": [
+                "EventRunFinished",
+                "EventRunFailed",
+                "EventJobsNew"
+            ]
+        },
+        "webhook.Webhook": {
+            "description": "Registered webhook endpoint",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/webhook.EventType"
+                    }
+                },
+                "filter": {
+                    "description": "Filter selects the jobs sent in jobs.new deliveries.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/filter.JobFilter"
+                        }
+                    ]
+                },
+                "id": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
         }
     }
 }`
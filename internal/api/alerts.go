@@ -0,0 +1,195 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ayagmar/gojobscraper/internal/filter"
+	"github.com/ayagmar/gojobscraper/internal/notify"
+	"github.com/ayagmar/gojobscraper/internal/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+// AlertRuleRequest creates a chat alert rule
+// @Description Chat alert rule
+type AlertRuleRequest struct {
+	Name string `json:"name"`
+	// URL is the Slack, Discord or other incoming webhook URL.
+	URL    string            `json:"url"`
+	Format notify.ChatFormat `json:"format"`
+	Filter filter.JobFilter  `json:"filter"`
+	// BatchWindowSeconds is how long matching jobs are collected before
+	// they are posted in a single message. Zero uses the configured default.
+	BatchWindowSeconds int `json:"batch_window_seconds,omitempty"`
+	// MaxMessagesPerHour caps the messages posted for the rule. Zero uses
+	// the configured default.
+	MaxMessagesPerHour int `json:"max_messages_per_hour,omitempty"`
+}
+
+func (req *AlertRuleRequest) Bind(r *http.Request) error {
+	if req.Name == "" {
+		return errors.New("missing name")
+	}
+	target, err := url.Parse(req.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return errors.New("invalid url. Must be an absolute http or https URL")
+	}
+	if !notify.ValidChatFormat(req.Format) {
+		return errors.New("invalid format. Must be 'slack', 'discord' or 'generic'")
+	}
+	if req.Filter.Source != "" && !isValidScraperType(req.Filter.Source) {
+		return errors.New("invalid filter source. Must be 'indeed' or 'linkedin'")
+	}
+	if req.BatchWindowSeconds < 0 || req.MaxMessagesPerHour < 0 {
+		return errors.New("batch_window_seconds and max_messages_per_hour must not be negative")
+	}
+	return nil
+}
+
+// CreateAlertRule handles POST requests creating a chat alert rule.
+// @Summary Create alert rule
+// @Description Post the newly scraped jobs matching the filter to a chat incoming webhook. Matching jobs are collected for the batch window and posted as one message listing their title, company, location and link; messages beyond the hourly limit are held back until it allows. Requires the admin role.
+// @Tags alerts
+// @Accept json
+// @Produce json
+// @Param rule body AlertRuleRequest true "Alert rule"
+// @Success 201 {object} notify.AlertRule
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /alerts [post]
+func (h *Handler) CreateAlertRule(w http.ResponseWriter, r *http.Request) {
+	var req AlertRuleRequest
+	if err := render.Bind(r, &req); err != nil {
+		if err := render.Render(w, r, ErrInvalidRequest(err)); err != nil {
+			h.logger.Printf("Error rendering response: %v", err)
+		}
+		return
+	}
+
+	rule := notify.AlertRule{
+		ID:                 uuid.New().String(),
+		Name:               req.Name,
+		URL:                req.URL,
+		Format:             req.Format,
+		Filter:             req.Filter,
+		BatchWindowSeconds: req.BatchWindowSeconds,
+		MaxMessagesPerHour: req.MaxMessagesPerHour,
+		CreatedAt:          time.Now(),
+	}
+	if err := h.storage.SaveAlertRule(rule); err != nil {
+		h.renderAlertError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, rule)
+}
+
+// GetAlertRules handles GET requests for retrieving chat alert rules.
+// @Summary Get alert rules
+// @Description Get the chat alert rules. Requires the admin role.
+// @Tags alerts
+// @Produce json
+// @Success 200 {array} notify.AlertRule
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /alerts [get]
+func (h *Handler) GetAlertRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.storage.GetAlertRules()
+	if err != nil {
+		h.renderAlertError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, rules)
+}
+
+// GetAlertRule handles GET requests for retrieving a single alert rule.
+// @Summary Get alert rule
+// @Description Get a chat alert rule. Requires the admin role.
+// @Tags alerts
+// @Produce json
+// @Param id path string true "Alert rule ID"
+// @Success 200 {object} notify.AlertRule
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /alerts/{id} [get]
+func (h *Handler) GetAlertRule(w http.ResponseWriter, r *http.Request) {
+	rule, err := h.storage.GetAlertRule(chi.URLParam(r, "id"))
+	if err != nil {
+		h.renderAlertError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, rule)
+}
+
+// DeleteAlertRule handles DELETE requests removing an alert rule.
+// @Summary Delete alert rule
+// @Description Remove a chat alert rule. Jobs already batched for it are still posted. Requires the admin role.
+// @Tags alerts
+// @Produce json
+// @Param id path string true "Alert rule ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /alerts/{id} [delete]
+func (h *Handler) DeleteAlertRule(w http.ResponseWriter, r *http.Request) {
+	if err := h.storage.DeleteAlertRule(chi.URLParam(r, "id")); err != nil {
+		h.renderAlertError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, SuccessResponse{Message: "Alert rule deleted"})
+}
+
+// TestAlertRule handles POST requests posting a sample message for a rule.
+// @Summary Test alert rule
+// @Description Post a sample job to the rule's webhook right away, outside its rate limit, to check the URL and format. Requires the admin role.
+// @Tags alerts
+// @Produce json
+// @Param id path string true "Alert rule ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /alerts/{id}/test [post]
+func (h *Handler) TestAlertRule(w http.ResponseWriter, r *http.Request) {
+	rule, err := h.storage.GetAlertRule(chi.URLParam(r, "id"))
+	if err != nil {
+		h.renderAlertError(w, r, err)
+		return
+	}
+
+	if err := h.alerts.Test(rule); err != nil {
+		h.renderAlertError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, SuccessResponse{Message: "Test message posted"})
+}
+
+func (h *Handler) renderAlertError(w http.ResponseWriter, r *http.Request, err error) {
+	renderer := ErrInternalServer(err)
+	if errors.Is(err, storage.ErrNotFound) {
+		renderer = ErrNotFound(errors.New("alert rule not found"))
+	} else {
+		h.logger.Printf("Error handling alert rule request: %v", err)
+	}
+
+	if err := render.Render(w, r, renderer); err != nil {
+		h.logger.Printf("Error rendering response: %v", err)
+	}
+}
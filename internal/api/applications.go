@@ -0,0 +1,222 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ayagmar/gojobscraper/internal/storage"
+	"github.com/ayagmar/gojobscraper/internal/tracking"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// ApplicationRequest sets the tracking data of a job posting
+// @Description Application tracking data
+type ApplicationRequest struct {
+	Status tracking.Status `json:"status"`
+	Notes  string          `json:"notes,omitempty"`
+	Tags   []string        `json:"tags,omitempty"`
+	// RemindAt is when to follow up on the application. Omit it to clear
+	// the reminder.
+	RemindAt *time.Time `json:"remind_at,omitempty"`
+}
+
+func (req *ApplicationRequest) Bind(r *http.Request) error {
+	if !tracking.ValidStatus(req.Status) {
+		return fmt.Errorf("invalid status %q. Must be one of saved, applied, interviewing, offer or rejected", req.Status)
+	}
+	req.Tags = tracking.NormalizeTags(req.Tags)
+	return nil
+}
+
+// PutApplication handles PUT requests setting the application of a job.
+// @Summary Set job application
+// @Description Create or replace the status, notes, tags and reminder the current user tracks for a job posting. Status changes are added to the application history. Tracking data is stored apart from the posting, so re-scrapes leave it intact.
+// @Tags applications
+// @Accept json
+// @Produce json
+// @Param id path string true "Job ID"
+// @Param application body ApplicationRequest true "Application"
+// @Success 200 {object} tracking.Application
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /jobs/{id}/application [put]
+func (h *Handler) PutApplication(w http.ResponseWriter, r *http.Request) {
+	var req ApplicationRequest
+	if err := render.Bind(r, &req); err != nil {
+		if err := render.Render(w, r, ErrInvalidRequest(err)); err != nil {
+			h.logger.Printf("Error rendering response: %v", err)
+		}
+		return
+	}
+
+	job, err := h.storage.GetJob(chi.URLParam(r, "id"))
+	if err != nil {
+		h.renderApplicationJobError(w, r, err)
+		return
+	}
+
+	now := time.Now()
+	owner := currentUser(r).ID
+	application, err := h.storage.GetApplication(owner, job.Source, job.PlatformJobId)
+	if errors.Is(err, storage.ErrNotFound) {
+		application = tracking.Application{
+			Source:        job.Source,
+			PlatformJobID: job.PlatformJobId,
+			OwnerID:       owner,
+			CreatedAt:     now,
+		}
+	} else if err != nil {
+		h.renderApplicationError(w, r, err)
+		return
+	}
+
+	application.JobID = job.ID
+	application.SetStatus(req.Status, now)
+	application.Notes = req.Notes
+	application.Tags = req.Tags
+	application.RemindAt = req.RemindAt
+	application.UpdatedAt = now
+	if err := h.storage.SaveApplication(application); err != nil {
+		h.renderApplicationError(w, r, err)
+		return
+	}
+
+	application.Job = &job
+	render.JSON(w, r, application)
+}
+
+// GetApplication handles GET requests for the application of a job.
+// @Summary Get job application
+// @Description Get the application the current user tracks for a job posting
+// @Tags applications
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 200 {object} tracking.Application
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /jobs/{id}/application [get]
+func (h *Handler) GetApplication(w http.ResponseWriter, r *http.Request) {
+	job, err := h.storage.GetJob(chi.URLParam(r, "id"))
+	if err != nil {
+		h.renderApplicationJobError(w, r, err)
+		return
+	}
+
+	application, err := h.storage.GetApplication(currentUser(r).ID, job.Source, job.PlatformJobId)
+	if err != nil {
+		h.renderApplicationError(w, r, err)
+		return
+	}
+
+	application.JobID = job.ID
+	render.JSON(w, r, application)
+}
+
+// DeleteApplication handles DELETE requests removing the application of a job.
+// @Summary Delete job application
+// @Description Stop tracking the application to a job posting
+// @Tags applications
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /jobs/{id}/application [delete]
+func (h *Handler) DeleteApplication(w http.ResponseWriter, r *http.Request) {
+	job, err := h.storage.GetJob(chi.URLParam(r, "id"))
+	if err != nil {
+		h.renderApplicationJobError(w, r, err)
+		return
+	}
+
+	if err := h.storage.DeleteApplication(currentUser(r).ID, job.Source, job.PlatformJobId); err != nil {
+		h.renderApplicationError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, SuccessResponse{Message: "Application deleted"})
+}
+
+// GetApplications handles GET requests for querying tracked applications.
+// @Summary Get applications
+// @Description Get the applications tracked by the current user with their job postings, most recently updated first
+// @Tags applications
+// @Produce json
+// @Param status query string false "Pipeline status" Enums(saved, applied, interviewing, offer, rejected)
+// @Param tag query string false "Tag"
+// @Param remind_before query string false "Only applications with a reminder due before this RFC 3339 time"
+// @Success 200 {array} tracking.Application
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /applications [get]
+func (h *Handler) GetApplications(w http.ResponseWriter, r *http.Request) {
+	query, err := parseApplicationQuery(r)
+	if err != nil {
+		if err := render.Render(w, r, ErrInvalidRequest(err)); err != nil {
+			h.logger.Printf("Error rendering response: %v", err)
+		}
+		return
+	}
+
+	applications, err := h.storage.GetApplications(query)
+	if err != nil {
+		h.renderApplicationError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, applications)
+}
+
+func parseApplicationQuery(r *http.Request) (tracking.Query, error) {
+	params := r.URL.Query()
+	query := tracking.Query{
+		Status:  tracking.Status(params.Get("status")),
+		Tag:     params.Get("tag"),
+		OwnerID: currentUser(r).ID,
+	}
+	if query.Status != "" && !tracking.ValidStatus(query.Status) {
+		return tracking.Query{}, errors.New("invalid status. Must be one of saved, applied, interviewing, offer or rejected")
+	}
+	if remindBefore := params.Get("remind_before"); remindBefore != "" {
+		t, err := time.Parse(time.RFC3339, remindBefore)
+		if err != nil {
+			return tracking.Query{}, errors.New("invalid remind_before. Must be an RFC 3339 time")
+		}
+		query.RemindBefore = &t
+	}
+	return query, nil
+}
+
+// renderApplicationJobError renders the error of looking up the job posting
+// of an application.
+func (h *Handler) renderApplicationJobError(w http.ResponseWriter, r *http.Request, err error) {
+	if !errors.Is(err, storage.ErrNotFound) {
+		h.renderApplicationError(w, r, err)
+		return
+	}
+
+	if err := render.Render(w, r, ErrNotFound(errors.New("job not found"))); err != nil {
+		h.logger.Printf("Error rendering response: %v", err)
+	}
+}
+
+func (h *Handler) renderApplicationError(w http.ResponseWriter, r *http.Request, err error) {
+	renderer := ErrInternalServer(err)
+	if errors.Is(err, storage.ErrNotFound) {
+		renderer = ErrNotFound(errors.New("application not found"))
+	} else {
+		h.logger.Printf("Error handling application request: %v", err)
+	}
+
+	if err := render.Render(w, r, renderer); err != nil {
+		h.logger.Printf("Error rendering response: %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ayagmar/gojobscraper/internal/auth"
+	"github.com/ayagmar/gojobscraper/internal/storage"
+	"github.com/go-chi/render"
+)
+
+// Authenticate is a middleware rejecting requests without a valid API key.
+// The key is read from a bearer Authorization header or the X-API-Key
+// header. The key and the user owning it are added to the request context.
+// Rejected requests count against the per-IP rate limit of RateLimitIP.
+func (h *Handler) Authenticate(next http.Handler) http.Handler {
+	return h.authenticate(next, false)
+}
+
+// AuthenticateStream is Authenticate for event streams, which browsers open
+// without custom headers. It also accepts the key in the api_key query
+// parameter.
+func (h *Handler) AuthenticateStream(next http.Handler) http.Handler {
+	return h.authenticate(next, true)
+}
+
+func (h *Handler) authenticate(next http.Handler, queryKey bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := apiKeyFromRequest(r, queryKey)
+		if key == "" {
+			h.renderUnauthorized(w, r, errors.New("missing API key"))
+			return
+		}
+
+		user, apiKey, err := auth.Authenticate(h.storage, key, time.Now())
+		if errors.Is(err, auth.ErrInvalidKey) || errors.Is(err, storage.ErrNotFound) {
+			h.renderUnauthorized(w, r, auth.ErrInvalidKey)
+			return
+		}
+		if err != nil {
+			h.logger.Printf("Error authenticating request: %v", err)
+			if err := render.Render(w, r, ErrInternalServer(err)); err != nil {
+				h.logger.Printf("Error rendering response: %v", err)
+			}
+			return
+		}
+
+		ctx := auth.WithAPIKey(auth.WithUser(r.Context(), user), apiKey)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// StripAPIKey is a middleware removing the api_key query parameter from the
+// request URI, so that request logs do not record API keys. It must run
+// before middleware.Logger. The parsed URL is left intact.
+func StripAPIKey(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if !query.Has("api_key") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		query.Del("api_key")
+		stripped := *r.URL
+		stripped.RawQuery = query.Encode()
+		r = r.WithContext(r.Context())
+		r.RequestURI = stripped.RequestURI()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireAdmin is a middleware restricting routes to admins. It must run
+// after Authenticate.
+func (h *Handler) RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !currentUser(r).IsAdmin() {
+			if err := render.Render(w, r, ErrForbidden(errors.New("admin role required"))); err != nil {
+				h.logger.Printf("Error rendering response: %v", err)
+			}
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// renderUnauthorized renders a 401 response, or a 429 response once the
+// client's IP address is over the limit of ipLimiter.
+func (h *Handler) renderUnauthorized(w http.ResponseWriter, r *http.Request, err error) {
+	if h.ipLimiter != nil && !h.allow(w, r, h.ipLimiter, "ip:"+clientIP(r)) {
+		return
+	}
+
+	w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+	if err := render.Render(w, r, ErrUnauthorized(err)); err != nil {
+		h.logger.Printf("Error rendering response: %v", err)
+	}
+}
+
+func apiKeyFromRequest(r *http.Request, queryKey bool) string {
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, token, ok := strings.Cut(header, " ")
+		if ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+	}
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	if queryKey {
+		return r.URL.Query().Get("api_key")
+	}
+	return ""
+}
+
+// currentUser returns the user authenticated by Authenticate.
+func currentUser(r *http.Request) auth.User {
+	user, _ := auth.UserFromContext(r.Context())
+	return user
+}
+
+// canAccess reports whether user may read and change a record owned by
+// ownerID. Admins may access every record.
+func canAccess(user auth.User, ownerID string) bool {
+	return user.IsAdmin() || (ownerID != "" && ownerID == user.ID)
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ayagmar/gojobscraper/internal/blocklist"
+	"github.com/ayagmar/gojobscraper/internal/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+// BlockRuleRequest creates a blocklist rule
+// @Description Blocklist rule
+type BlockRuleRequest struct {
+	Kind blocklist.Kind `json:"kind"`
+	// Pattern is a company name, a regular expression matched against
+	// company names, a title keyword or a company website domain, depending
+	// on the kind.
+	Pattern string `json:"pattern"`
+	// Action defaults to hide.
+	Action blocklist.Action `json:"action,omitempty"`
+	// Reason is recorded on the postings the rule hides.
+	Reason string `json:"reason,omitempty"`
+}
+
+func (req *BlockRuleRequest) Bind(r *http.Request) error {
+	if req.Action == "" {
+		req.Action = blocklist.ActionHide
+	}
+	return req.rule().Validate()
+}
+
+func (req *BlockRuleRequest) rule() blocklist.Rule {
+	return blocklist.Rule{
+		Kind:    req.Kind,
+		Pattern: req.Pattern,
+		Action:  req.Action,
+		Reason:  req.Reason,
+	}
+}
+
+// CreateBlockRule handles POST requests adding a blocklist rule.
+// @Summary Create blocklist rule
+// @Description Block postings by company name, company name regular expression, title keyword or company website domain. Blocked postings are either dropped before they are stored or stored hidden with the reason. Rules apply to jobs scraped from then on. Requires the admin role.
+// @Tags blocklist
+// @Accept json
+// @Produce json
+// @Param rule body BlockRuleRequest true "Blocklist rule"
+// @Success 201 {object} blocklist.Rule
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /blocklist [post]
+func (h *Handler) CreateBlockRule(w http.ResponseWriter, r *http.Request) {
+	var req BlockRuleRequest
+	if err := render.Bind(r, &req); err != nil {
+		if err := render.Render(w, r, ErrInvalidRequest(err)); err != nil {
+			h.logger.Printf("Error rendering response: %v", err)
+		}
+		return
+	}
+
+	rule := req.rule()
+	rule.ID = uuid.New().String()
+	rule.CreatedAt = time.Now()
+	if err := h.storage.SaveBlockRule(rule); err != nil {
+		h.renderBlockRuleError(w, r, err)
+		return
+	}
+	h.blocklist.Reload()
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, rule)
+}
+
+// GetBlockRules handles GET requests for retrieving the blocklist.
+// @Summary Get blocklist rules
+// @Description Get the blocklist rules applied to scraped postings
+// @Tags blocklist
+// @Produce json
+// @Success 200 {array} blocklist.Rule
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /blocklist [get]
+func (h *Handler) GetBlockRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.storage.GetBlockRules()
+	if err != nil {
+		h.renderBlockRuleError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, rules)
+}
+
+// DeleteBlockRule handles DELETE requests removing a blocklist rule.
+// @Summary Delete blocklist rule
+// @Description Remove a blocklist rule. Postings it hid stay hidden until they are scraped again. Requires the admin role.
+// @Tags blocklist
+// @Produce json
+// @Param id path string true "Blocklist rule ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /blocklist/{id} [delete]
+func (h *Handler) DeleteBlockRule(w http.ResponseWriter, r *http.Request) {
+	if err := h.storage.DeleteBlockRule(chi.URLParam(r, "id")); err != nil {
+		h.renderBlockRuleError(w, r, err)
+		return
+	}
+	h.blocklist.Reload()
+
+	render.JSON(w, r, SuccessResponse{Message: "Blocklist rule deleted"})
+}
+
+func (h *Handler) renderBlockRuleError(w http.ResponseWriter, r *http.Request, err error) {
+	renderer := ErrInternalServer(err)
+	if errors.Is(err, storage.ErrNotFound) {
+		renderer = ErrNotFound(errors.New("blocklist rule not found"))
+	} else {
+		h.logger.Printf("Error handling blocklist request: %v", err)
+	}
+
+	if err := render.Render(w, r, renderer); err != nil {
+		h.logger.Printf("Error rendering response: %v", err)
+	}
+}
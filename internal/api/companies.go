@@ -0,0 +1,107 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ayagmar/gojobscraper/internal/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// GetCompanies handles GET requests for retrieving companies.
+// @Summary Get companies
+// @Description Get the list of companies referenced by scraped jobs
+// @Tags companies
+// @Accept json
+// @Produce json
+// @Success 200 {array} scraper.Company
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /companies [get]
+func (h *Handler) GetCompanies(w http.ResponseWriter, r *http.Request) {
+	companies, err := h.storage.GetCompanies()
+	if err != nil {
+		h.logger.Printf("Error retrieving companies: %v", err)
+		err := render.Render(w, r, ErrInternalServer(err))
+		if err != nil {
+			return
+		}
+		return
+	}
+
+	render.JSON(w, r, companies)
+}
+
+// GetCompany handles GET requests for retrieving a single company.
+// @Summary Get company
+// @Description Get a company by ID
+// @Tags companies
+// @Accept json
+// @Produce json
+// @Param id path string true "Company ID"
+// @Success 200 {object} scraper.Company
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /companies/{id} [get]
+func (h *Handler) GetCompany(w http.ResponseWriter, r *http.Request) {
+	company, err := h.storage.GetCompany(chi.URLParam(r, "id"))
+	if err != nil {
+		h.renderCompanyError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, company)
+}
+
+// GetCompanyJobs handles GET requests for retrieving the jobs of a company.
+// @Summary Get company jobs
+// @Description Get the jobs posted by a company. Postings hidden by the blocklist are left out unless include_hidden is set.
+// @Tags companies
+// @Accept json
+// @Produce json
+// @Param id path string true "Company ID"
+// @Param include_hidden query bool false "Include postings hidden by the blocklist" default(false)
+// @Success 200 {array} scraper.JobPosting
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /companies/{id}/jobs [get]
+func (h *Handler) GetCompanyJobs(w http.ResponseWriter, r *http.Request) {
+	includeHidden, err := parseIncludeHidden(r)
+	if err != nil {
+		if err := render.Render(w, r, ErrInvalidRequest(err)); err != nil {
+			h.logger.Printf("Error rendering response: %v", err)
+		}
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	if _, err := h.storage.GetCompany(id); err != nil {
+		h.renderCompanyError(w, r, err)
+		return
+	}
+
+	jobs, err := h.storage.GetJobsByCompany(id, includeHidden)
+	if err != nil {
+		h.renderCompanyError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, jobs)
+}
+
+func (h *Handler) renderCompanyError(w http.ResponseWriter, r *http.Request, err error) {
+	renderer := ErrInternalServer(err)
+	if errors.Is(err, storage.ErrNotFound) {
+		renderer = ErrNotFound(errors.New("company not found"))
+	} else {
+		h.logger.Printf("Error retrieving company: %v", err)
+	}
+
+	if err := render.Render(w, r, renderer); err != nil {
+		h.logger.Printf("Error rendering response: %v", err)
+	}
+}
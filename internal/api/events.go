@@ -0,0 +1,286 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ayagmar/gojobscraper/internal/scraper"
+	"github.com/ayagmar/gojobscraper/internal/storage"
+	"github.com/go-chi/render"
+)
+
+const (
+	// maxRunEvents is how many events of a run are kept for clients that
+	// reconnect with Last-Event-ID.
+	maxRunEvents = 1000
+	// runEventsRetention is how long the events of a finished run are kept.
+	runEventsRetention = 10 * time.Minute
+	// subscriberBuffer is how many events a slow client may lag behind before
+	// it is disconnected. It catches up by reconnecting with Last-Event-ID.
+	subscriberBuffer = 64
+	// eventKeepAlive is the interval of comments sent on idle streams so that
+	// proxies do not close them.
+	eventKeepAlive = 15 * time.Second
+)
+
+// runEvents keeps the recent progress events of scrape runs and fans them out
+// to the clients following those runs.
+type runEvents struct {
+	mu      sync.Mutex
+	streams map[string]*eventStream
+}
+
+type eventStream struct {
+	nextID      int64
+	events      []scraper.ScrapeEvent
+	subscribers map[chan scraper.ScrapeEvent]struct{}
+	finished    bool
+}
+
+func newRunEvents() *runEvents {
+	return &runEvents{streams: make(map[string]*eventStream)}
+}
+
+// open starts recording the events of a run.
+func (e *runEvents) open(runID string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.streams[runID] = &eventStream{subscribers: make(map[chan scraper.ScrapeEvent]struct{})}
+}
+
+// publish numbers event and sends it to the run's subscribers. A finished
+// event ends the stream: subscribers are released and the events are dropped
+// after runEventsRetention.
+func (e *runEvents) publish(runID string, event scraper.ScrapeEvent) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	stream, ok := e.streams[runID]
+	if !ok || stream.finished {
+		return
+	}
+
+	stream.nextID++
+	event.ID = stream.nextID
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	stream.events = append(stream.events, event)
+	if len(stream.events) > maxRunEvents {
+		stream.events = stream.events[len(stream.events)-maxRunEvents:]
+	}
+
+	for ch := range stream.subscribers {
+		select {
+		case ch <- event:
+		default:
+			delete(stream.subscribers, ch)
+			close(ch)
+		}
+	}
+
+	if event.Type == scraper.EventFinished {
+		stream.finished = true
+		for ch := range stream.subscribers {
+			delete(stream.subscribers, ch)
+			close(ch)
+		}
+		time.AfterFunc(runEventsRetention, func() {
+			e.mu.Lock()
+			defer e.mu.Unlock()
+			if e.streams[runID] == stream {
+				delete(e.streams, runID)
+			}
+		})
+	}
+}
+
+// subscribe returns the recorded events of a run after lastID and, unless
+// the run has finished, a channel of its next events. The channel is closed
+// when the run finishes or the subscriber falls too far behind. found is
+// false if no events of the run are recorded.
+func (e *runEvents) subscribe(runID string, lastID int64) (backlog []scraper.ScrapeEvent, next chan scraper.ScrapeEvent, found bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	stream, ok := e.streams[runID]
+	if !ok {
+		return nil, nil, false
+	}
+
+	for _, event := range stream.events {
+		if event.ID > lastID {
+			backlog = append(backlog, event)
+		}
+	}
+	if stream.finished {
+		return backlog, nil, true
+	}
+
+	next = make(chan scraper.ScrapeEvent, subscriberBuffer)
+	stream.subscribers[next] = struct{}{}
+	return backlog, next, true
+}
+
+// unsubscribe stops sending events to next if it is still subscribed.
+func (e *runEvents) unsubscribe(runID string, next chan scraper.ScrapeEvent) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	stream, ok := e.streams[runID]
+	if !ok {
+		return
+	}
+	if _, ok := stream.subscribers[next]; ok {
+		delete(stream.subscribers, next)
+		close(next)
+	}
+}
+
+// GetRunEvents handles GET requests for following the progress of a scrape run.
+// @Summary Stream scrape run events
+// @Description Stream the progress of a scrape run as Server-Sent Events: page_visited, job_parsed, job_saved, error and finished. Clients reconnecting with Last-Event-ID receive the events they missed. Runs whose events are no longer kept only send their finished event. The runs of other users are not found, except by admins.
+// @Tags scrapes
+// @Produce text/event-stream
+// @Param id path string true "Scrape run ID"
+// @Param Last-Event-ID header int false "ID of the last event received"
+// @Param api_key query string false "API key, for clients that cannot set headers"
+// @Success 200 {object} scraper.ScrapeEvent
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /scrapes/{id}/events [get]
+func (h *Handler) GetRunEvents(w http.ResponseWriter, r *http.Request) {
+	var lastID int64
+	if lastIDStr := r.Header.Get("Last-Event-ID"); lastIDStr != "" {
+		var err error
+		lastID, err = strconv.ParseInt(lastIDStr, 10, 64)
+		if err != nil {
+			if err := render.Render(w, r, ErrInvalidRequest(errors.New("invalid Last-Event-ID. Must be an integer"))); err != nil {
+				h.logger.Printf("Error rendering response: %v", err)
+			}
+			return
+		}
+	}
+
+	run, err := h.ownedRun(r)
+	if err != nil {
+		h.renderRunEventsError(w, r, err)
+		return
+	}
+
+	backlog, next, found := h.events.subscribe(run.ID, lastID)
+	if !found {
+		finished, err := finishedRunEvent(run)
+		if err != nil {
+			h.renderRunEventsError(w, r, err)
+			return
+		}
+		backlog = []scraper.ScrapeEvent{finished}
+	}
+	if next != nil {
+		defer h.events.unsubscribe(run.ID, next)
+	}
+
+	// The stream lasts as long as the scrape, past the server write timeout.
+	controller := http.NewResponseController(w)
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		h.logger.Printf("Error clearing write deadline: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	for _, event := range backlog {
+		if err := writeEvent(w, event); err != nil {
+			return
+		}
+	}
+	if err := controller.Flush(); err != nil || next == nil {
+		return
+	}
+
+	keepAlive := time.NewTicker(eventKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-next:
+			if !ok {
+				return
+			}
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		if err := controller.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// finishedRunEvent rebuilds the finished event of a run whose events are no
+// longer recorded. The event has no ID: the last ID of the run is not known,
+// and clients keep the one they have when an event comes without one.
+func finishedRunEvent(run scraper.ScrapeRun) (scraper.ScrapeEvent, error) {
+	if run.FinishedAt == nil {
+		return scraper.ScrapeEvent{}, errNoRunEvents
+	}
+
+	return scraper.ScrapeEvent{
+		Type:  scraper.EventFinished,
+		Time:  *run.FinishedAt,
+		Count: run.JobsScraped,
+		Error: run.Error,
+		Run:   &run,
+	}, nil
+}
+
+// errNoRunEvents is returned for runs that are still running but whose events
+// are not recorded, such as runs interrupted by a restart.
+var errNoRunEvents = errors.New("no events recorded for scrape run")
+
+func (h *Handler) renderRunEventsError(w http.ResponseWriter, r *http.Request, err error) {
+	renderer := ErrInternalServer(err)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		renderer = ErrNotFound(errors.New("scrape run not found"))
+	case errors.Is(err, errNoRunEvents):
+		renderer = ErrNotFound(err)
+	default:
+		h.logger.Printf("Error retrieving scrape run: %v", err)
+	}
+
+	if err := render.Render(w, r, renderer); err != nil {
+		h.logger.Printf("Error rendering response: %v", err)
+	}
+}
+
+func writeEvent(w http.ResponseWriter, event scraper.ScrapeEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if event.ID > 0 {
+		if _, err := fmt.Fprintf(w, "id: %d\n", event.ID); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+	return err
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ayagmar/gojobscraper/internal/export"
+	"github.com/ayagmar/gojobscraper/internal/scraper"
+	"github.com/go-chi/render"
+)
+
+// ExportJobs handles GET requests downloading jobs as a file.
+// @Summary Export jobs
+// @Description Download the jobs matching the filters as a CSV, NDJSON or XLSX file, newest first. Jobs are streamed from the database as the file is written, so exports are not limited in size. CSV and XLSX files have one column per field, with the company details flattened into company_* columns; NDJSON files have one job per line with the fields of GET /jobs. Duplicate collapsing is not available for exports.
+// @Tags jobScraper
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string true "File format" Enums(csv, ndjson, xlsx)
+// @Param source query string false "Source of job listings (indeed or linkedin)" Enums(indeed, linkedin)
+// @Param keyword query string false "Whole words in the title, summary, description or company name"
+// @Param location query string false "Whole words of the location"
+// @Param include_hidden query bool false "Include postings hidden by the blocklist" default(false)
+// @Success 200 {file} file
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /jobs/export [get]
+func (h *Handler) ExportJobs(w http.ResponseWriter, r *http.Request) {
+	format := export.Format(r.URL.Query().Get("format"))
+	if !export.ValidFormat(format) {
+		if err := render.Render(w, r, ErrInvalidRequest(errors.New("invalid format. Must be 'csv', 'ndjson' or 'xlsx'"))); err != nil {
+			h.logger.Printf("Error rendering response: %v", err)
+		}
+		return
+	}
+
+	jobFilter, err := parseJobFilter(r)
+	if err != nil {
+		if err := render.Render(w, r, ErrInvalidRequest(err)); err != nil {
+			h.logger.Printf("Error rendering response: %v", err)
+		}
+		return
+	}
+
+	includeHidden, err := parseIncludeHidden(r)
+	if err != nil {
+		if err := render.Render(w, r, ErrInvalidRequest(err)); err != nil {
+			h.logger.Printf("Error rendering response: %v", err)
+		}
+		return
+	}
+
+	// Large exports outlive the server write timeout.
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Printf("Error clearing export write deadline: %v", err)
+	}
+
+	body := &sentCounter{w: w}
+	writer, err := export.NewWriter(format, body)
+	if err != nil {
+		h.failExport(w, r, body, 0, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="jobs-%s.%s"`, time.Now().UTC().Format("20060102-150405"), format))
+
+	exported := 0
+	err = h.storage.EachJob(r.Context(), jobFilter, includeHidden, func(job scraper.JobPosting) error {
+		exported++
+		return writer.Write(job)
+	})
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		h.failExport(w, r, body, exported, err)
+	}
+}
+
+// failExport reports an export error with a 500 response when nothing was
+// sent yet. Otherwise it aborts the response, so that the client does not
+// take the truncated file for a complete one.
+func (h *Handler) failExport(w http.ResponseWriter, r *http.Request, body *sentCounter, exported int, err error) {
+	h.logger.Printf("Error exporting jobs after %d jobs: %v", exported, err)
+	if body.sent > 0 {
+		panic(http.ErrAbortHandler)
+	}
+
+	w.Header().Del("Content-Disposition")
+	if err := render.Render(w, r, ErrInternalServer(err)); err != nil {
+		h.logger.Printf("Error rendering response: %v", err)
+	}
+}
+
+// sentCounter counts the bytes written to the response.
+type sentCounter struct {
+	w    io.Writer
+	sent int64
+}
+
+func (c *sentCounter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.sent += int64(n)
+	return n, err
+}
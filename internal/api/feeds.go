@@ -0,0 +1,107 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+
+	"github.com/ayagmar/gojobscraper/internal/feed"
+	"github.com/ayagmar/gojobscraper/internal/search"
+	"github.com/ayagmar/gojobscraper/internal/storage"
+	"github.com/go-chi/chi/v5"
+)
+
+// feedEntries is the number of recent matches listed in feeds.
+const feedEntries = 50
+
+// GetAtomFeed handles GET requests for the Atom feed of a saved search.
+// @Summary Get saved search Atom feed
+// @Description Get the most recent jobs matched by a saved search as an Atom feed, for feed readers. The feed is authenticated by the feed token of the search rather than an API key. Conditional requests with If-None-Match or If-Modified-Since are answered with 304 Not Modified while no new job matched.
+// @Tags searches
+// @Produce application/atom+xml
+// @Param searchID path string true "Saved search ID"
+// @Param token query string true "Feed token of the saved search"
+// @Success 200 {string} string
+// @Success 304 {string} string
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /feeds/{searchID}.atom [get]
+func (h *Handler) GetAtomFeed(w http.ResponseWriter, r *http.Request) {
+	h.serveFeed(w, r, "application/atom+xml; charset=utf-8", feed.Feed.Atom)
+}
+
+// GetRSSFeed handles GET requests for the RSS feed of a saved search.
+// @Summary Get saved search RSS feed
+// @Description Get the most recent jobs matched by a saved search as an RSS 2.0 feed, for feed readers. The feed is authenticated by the feed token of the search rather than an API key. Conditional requests with If-None-Match or If-Modified-Since are answered with 304 Not Modified while no new job matched.
+// @Tags searches
+// @Produce application/rss+xml
+// @Param searchID path string true "Saved search ID"
+// @Param token query string true "Feed token of the saved search"
+// @Success 200 {string} string
+// @Success 304 {string} string
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /feeds/{searchID}.rss [get]
+func (h *Handler) GetRSSFeed(w http.ResponseWriter, r *http.Request) {
+	h.serveFeed(w, r, "application/rss+xml; charset=utf-8", feed.Feed.RSS)
+}
+
+// serveFeed renders the feed of the saved search named by the request with
+// encode. Conditional requests are handled by http.ServeContent.
+func (h *Handler) serveFeed(w http.ResponseWriter, r *http.Request, contentType string, encode func(feed.Feed) ([]byte, error)) {
+	s, err := h.feedSearch(r)
+	if err != nil {
+		h.renderSearchError(w, r, err)
+		return
+	}
+
+	matches, err := h.storage.GetSearchMatches(s.ID, feedEntries)
+	if err != nil {
+		h.renderSearchError(w, r, err)
+		return
+	}
+
+	f := feed.New(s, matches, feedURL(r))
+	body, err := encode(f)
+	if err != nil {
+		h.renderSearchError(w, r, err)
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("ETag", fmt.Sprintf(`"%s"`, hex.EncodeToString(sum[:16])))
+	http.ServeContent(w, r, "", f.Updated, bytes.NewReader(body))
+}
+
+// feedSearch returns the saved search named by the request path if the token
+// query parameter is its feed token. Otherwise the search is reported as not
+// found, so that feed URLs do not reveal which searches exist.
+func (h *Handler) feedSearch(r *http.Request) (search.SavedSearch, error) {
+	s, err := h.storage.GetSearch(chi.URLParam(r, "searchID"))
+	if err != nil {
+		return search.SavedSearch{}, err
+	}
+
+	token := r.URL.Query().Get("token")
+	if s.FeedToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(s.FeedToken)) != 1 {
+		return search.SavedSearch{}, storage.ErrNotFound
+	}
+	return s, nil
+}
+
+// feedURL returns the URL a feed was requested at, which feeds link to as
+// their own.
+func feedURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+	return fmt.Sprintf("%s://%s%s", scheme, r.Host, r.URL.RequestURI())
+}
@@ -2,36 +2,115 @@ package api
 
 import (
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/ayagmar/gojobscraper/internal/blocklist"
+	"github.com/ayagmar/gojobscraper/internal/dedup"
+	"github.com/ayagmar/gojobscraper/internal/filter"
+	"github.com/ayagmar/gojobscraper/internal/notify"
+	"github.com/ayagmar/gojobscraper/internal/ratelimit"
 	"github.com/ayagmar/gojobscraper/internal/scraper"
+	"github.com/ayagmar/gojobscraper/internal/search"
 	"github.com/ayagmar/gojobscraper/internal/storage"
+	"github.com/ayagmar/gojobscraper/internal/webhook"
 	"github.com/go-chi/render"
 )
 
 // Handler manages HTTP requests for the job scraper API.
 type Handler struct {
-	storage storage.JobStorage
-	logger  *log.Logger
+	storage        storage.Storage
+	detector       *dedup.Detector
+	scraperOptions scraper.Options
+	webhooks       *webhook.Dispatcher
+	digester       *notify.Digester
+	alerts         *notify.Alerter
+	searches       *search.Engine
+	blocklist      *blocklist.Blocklist
+	limiter        *ratelimit.Limiter
+	ipLimiter      *ratelimit.Limiter
+	scrapeQuota    int
+	allowedOrigins []string
+	events         *runEvents
+	feed           *jobFeed
+	logger         *log.Logger
 }
 
-// NewHandler creates a new Handler instance.
-func NewHandler(storage storage.JobStorage, logger *log.Logger) *Handler {
-	return &Handler{storage: storage, logger: logger}
+// NewHandler creates a new Handler instance. digester is nil when email
+// digests are not configured. limiter, which limits API keys, and ipLimiter,
+// which limits the requests without a valid API key per IP address, are nil
+// when those requests are not rate limited. scrapeQuota is the number of pages a user other than an admin may
+// scrape per UTC day, or zero for no quota. allowedOrigins are the origins of
+// other sites whose pages may open the job stream.
+func NewHandler(storage storage.Storage, detector *dedup.Detector, scraperOptions scraper.Options, webhooks *webhook.Dispatcher, digester *notify.Digester, alerts *notify.Alerter, searches *search.Engine, blocklist *blocklist.Blocklist, limiter, ipLimiter *ratelimit.Limiter, scrapeQuota int, allowedOrigins []string, logger *log.Logger) *Handler {
+	return &Handler{
+		storage:        storage,
+		detector:       detector,
+		scraperOptions: scraperOptions,
+		webhooks:       webhooks,
+		digester:       digester,
+		alerts:         alerts,
+		searches:       searches,
+		blocklist:      blocklist,
+		limiter:        limiter,
+		ipLimiter:      ipLimiter,
+		scrapeQuota:    scrapeQuota,
+		allowedOrigins: allowedOrigins,
+		events:         newRunEvents(),
+		feed:           newJobFeed(),
+		logger:         logger,
+	}
 }
 
 // GetJobs handles GET requests for retrieving jobs.
 // @Summary Get jobs
-// @Description Get a list of jobs
+// @Description Get a list of jobs matching the filters. With dedupe enabled, postings of the same role are collapsed into a canonical job listing the others under duplicates. Postings hidden by the blocklist are left out unless include_hidden is set.
 // @Tags jobScraper
 // @Accept json
 // @Produce json
+// @Param source query string false "Source of job listings (indeed or linkedin)" Enums(indeed, linkedin)
+// @Param keyword query string false "Whole words in the title, summary, description or company name"
+// @Param location query string false "Whole words of the location"
+// @Param dedupe query bool false "Collapse duplicate postings" default(false)
+// @Param include_hidden query bool false "Include postings hidden by the blocklist" default(false)
 // @Success 200 {array} scraper.JobPosting
+// @Failure 400 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
 // @Router /jobs [get]
 func (h *Handler) GetJobs(w http.ResponseWriter, r *http.Request) {
+	dedupe := false
+	if dedupeStr := r.URL.Query().Get("dedupe"); dedupeStr != "" {
+		var err error
+		dedupe, err = strconv.ParseBool(dedupeStr)
+		if err != nil {
+			err := render.Render(w, r, ErrInvalidRequest(errors.New("invalid dedupe value. Must be a boolean")))
+			if err != nil {
+				return
+			}
+			return
+		}
+	}
+
+	includeHidden, err := parseIncludeHidden(r)
+	if err != nil {
+		if err := render.Render(w, r, ErrInvalidRequest(err)); err != nil {
+			h.logger.Printf("Error rendering response: %v", err)
+		}
+		return
+	}
+
+	jobFilter, err := parseJobFilter(r)
+	if err != nil {
+		if err := render.Render(w, r, ErrInvalidRequest(err)); err != nil {
+			h.logger.Printf("Error rendering response: %v", err)
+		}
+		return
+	}
+
 	jobs, err := h.storage.GetJobs()
 	if err != nil {
 		h.logger.Printf("Error retrieving jobs: %v", err)
@@ -42,12 +121,44 @@ func (h *Handler) GetJobs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !includeHidden {
+		jobs = visibleJobs(jobs)
+	}
+	jobs = matchingJobs(jobs, jobFilter)
+
+	if dedupe {
+		jobs = h.detector.Collapse(jobs)
+	}
+
 	render.JSON(w, r, jobs)
 }
 
+// ClearJobs handles DELETE requests removing every stored job.
+// @Summary Clear jobs
+// @Description Delete every stored job posting. Applications and search matches are kept. Requires the admin role.
+// @Tags jobScraper
+// @Produce json
+// @Success 200 {object} SuccessResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /jobs [delete]
+func (h *Handler) ClearJobs(w http.ResponseWriter, r *http.Request) {
+	if err := h.storage.ClearJobs(); err != nil {
+		h.logger.Printf("Error clearing jobs: %v", err)
+		if err := render.Render(w, r, ErrInternalServer(err)); err != nil {
+			h.logger.Printf("Error rendering response: %v", err)
+		}
+		return
+	}
+
+	h.logger.Printf("Jobs cleared by user %s", currentUser(r).ID)
+	render.JSON(w, r, SuccessResponse{Message: "Jobs cleared"})
+}
+
 // StartScraping handles POST requests to initiate job scraping.
 // @Summary Start scraping
-// @Description Start scraping jobs based on the provided configuration
+// @Description Start scraping jobs based on the provided configuration. The pages count against the daily scrape quota of the user.
 // @Tags jobScraper
 // @Accept json
 // @Produce json
@@ -55,9 +166,12 @@ func (h *Handler) GetJobs(w http.ResponseWriter, r *http.Request) {
 // @Param country query string true "Country"
 // @Param pages query int false "Number of Pages" default(1)
 // @Param source query string true "Source of job listings (indeed or linkedin)" Enums(indeed, linkedin)
-// @Success 202 {object} SuccessResponse
+// @Success 202 {object} ScrapeStartedResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
 // @Failure 501 {object} ErrorResponse
+// @Security ApiKeyAuth
 // @Router /scrape [post]
 func (h *Handler) StartScraping(w http.ResponseWriter, r *http.Request) {
 	config, err := h.parseScrapingConfig(r)
@@ -69,34 +183,181 @@ func (h *Handler) StartScraping(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	go h.scrapeAndSaveJobs(config)
+	now := time.Now()
+	if !h.reserveScrapeQuota(w, r, config.Pages, now) {
+		return
+	}
+
+	run := scraper.NewScrapeRun(config)
+	run.UserID = currentUser(r).ID
+	if err := h.storage.SaveRun(run); err != nil {
+		h.logger.Printf("Error saving scrape run: %v", err)
+		h.releaseScrapeQuota(r, config.Pages, now)
+		err := render.Render(w, r, ErrInternalServer(err))
+		if err != nil {
+			return
+		}
+		return
+	}
+
+	h.events.open(run.ID)
+	go h.scrapeAndSaveJobs(run, config)
 
 	render.Status(r, http.StatusAccepted)
-	render.JSON(w, r, SuccessResponse{Message: "Scraping started"})
+	render.JSON(w, r, ScrapeStartedResponse{Message: "Scraping started", RunID: run.ID})
 }
 
-func (h *Handler) scrapeAndSaveJobs(config scraper.ScrapeConfig) {
-	h.logger.Printf("Starting scraping for job title: %s, country: %s, pages: %d, source: %s",
-		config.JobTitle, config.Country, config.Pages, config.Source)
+// GetProxies handles GET requests for retrieving proxy pool statistics.
+// @Summary Get proxies
+// @Description Get usage and health statistics of the scraper proxy pool
+// @Tags jobScraper
+// @Accept json
+// @Produce json
+// @Success 200 {array} scraper.ProxyStats
+// @Security ApiKeyAuth
+// @Router /proxies [get]
+func (h *Handler) GetProxies(w http.ResponseWriter, r *http.Request) {
+	stats := []scraper.ProxyStats{}
+	if h.scraperOptions.Proxies != nil {
+		stats = h.scraperOptions.Proxies.Stats()
+	}
 
-	scraper, err := scraper.NewScraper(config.Source)
-	if err != nil {
-		h.logger.Printf("Error creating scraper: %v", err)
-		return
+	render.JSON(w, r, stats)
+}
+
+func (h *Handler) scrapeAndSaveJobs(run scraper.ScrapeRun, config scraper.ScrapeConfig) {
+	h.logger.Printf("Starting scraping run %s for job title: %s, country: %s, pages: %d, source: %s",
+		run.ID, config.JobTitle, config.Country, config.Pages, config.Source)
+
+	saved, summary, err := h.scrapeJobs(&run, config)
+	run.Finish(saved, summary, err)
+	if err := h.storage.SaveRun(run); err != nil {
+		h.logger.Printf("Error saving scrape run %s: %v", run.ID, err)
 	}
+	h.events.publish(run.ID, scraper.ScrapeEvent{Type: scraper.EventFinished, Count: saved, Error: run.Error, Run: &run})
+	h.webhooks.RunFinished(run)
+
+	switch run.Status {
+	case scraper.RunBlocked:
+		h.logger.Printf("Scraping run %s was blocked by %s after %d jobs: %s", run.ID, config.Source, saved, run.Error)
+	case scraper.RunFailed:
+		h.logger.Printf("Scraping run %s failed: %s", run.ID, run.Error)
+	default:
+		h.logger.Printf("Successfully scraped %d jobs from %s (company cache: %d hits, %d misses; connections: %d opened, %d reused)",
+			saved, config.Source, summary.CompanyCacheHits, summary.CompanyCacheMisses,
+			summary.ConnectionsOpened, summary.ConnectionsReused)
+	}
+}
 
-	jobs, err := scraper.Scrape(config)
+// scrapeJobs streams the postings for config into storage in micro-batches
+// and returns how many were saved. After every batch the run's progress is
+// saved, so postings scraped before a block or a crash are kept and visible.
+func (h *Handler) scrapeJobs(run *scraper.ScrapeRun, config scraper.ScrapeConfig) (int, scraper.RunSummary, error) {
+	opts := h.scraperOptions
+	opts.Events = func(event scraper.ScrapeEvent) {
+		h.events.publish(run.ID, event)
+	}
+
+	jobScraper, err := scraper.NewScraper(config.Source, opts)
 	if err != nil {
-		h.logger.Printf("Error scraping %s: %v", config.Source, err)
-		return
+		return 0, scraper.RunSummary{}, fmt.Errorf("error creating scraper: %w", err)
+	}
+
+	blocked := 0
+	batcher := scraper.NewJobBatcher(h.scraperOptions.Batch, func(jobs []scraper.JobPosting) error {
+		// The batcher retries failed batches, so nothing is counted before
+		// the postings are saved.
+		jobs, dropped := h.blocklist.Apply(jobs)
+		if len(jobs) == 0 {
+			blocked += dropped
+			return nil
+		}
+
+		// Postings inserted before an error are published now: when the
+		// batch is retried they already exist and are not returned again.
+		inserted, err := h.storage.SaveJobs(jobs)
+		h.publishJobs(visibleJobs(inserted))
+		if err != nil {
+			return err
+		}
+		blocked += dropped
+		for _, job := range jobs {
+			if job.Hidden {
+				blocked++
+			}
+		}
+		run.JobsScraped += len(jobs)
+		if err := h.storage.SaveRun(*run); err != nil {
+			h.logger.Printf("Error saving progress of scrape run %s: %v", run.ID, err)
+		}
+		h.events.publish(run.ID, scraper.ScrapeEvent{Type: scraper.EventJobSaved, Count: run.JobsScraped})
+		return nil
+	})
+
+	scrapeErr := jobScraper.Stream(config, batcher.Add)
+	saveErr := batcher.Close()
+
+	// Postings dropped by the blocklist are not counted as saved.
+	summary := jobScraper.Summary()
+	summary.BlockedJobs = blocked
+	if saveErr != nil {
+		return run.JobsScraped, summary, fmt.Errorf("error saving jobs: %w", saveErr)
 	}
+	if scrapeErr != nil {
+		return run.JobsScraped, summary, fmt.Errorf("error scraping %s: %w", config.Source, scrapeErr)
+	}
+
+	return run.JobsScraped, summary, nil
+}
 
-	if err := h.storage.SaveJobs(jobs); err != nil {
-		h.logger.Printf("Error saving jobs: %v", err)
+// publishJobs passes newly saved postings to the job stream, webhooks,
+// alerts and saved searches.
+func (h *Handler) publishJobs(jobs []scraper.JobPosting) {
+	if len(jobs) == 0 {
 		return
 	}
+	h.feed.publish(jobs)
+	h.webhooks.JobsSaved(jobs)
+	h.alerts.JobsSaved(jobs)
+	h.searches.JobsSaved(jobs)
+}
+
+// visibleJobs returns the postings that are not hidden by the blocklist.
+func visibleJobs(jobs []scraper.JobPosting) []scraper.JobPosting {
+	visible := jobs[:0:0]
+	for _, job := range jobs {
+		if !job.Hidden {
+			visible = append(visible, job)
+		}
+	}
+	return visible
+}
+
+// matchingJobs returns the jobs matching jobFilter.
+func matchingJobs(jobs []scraper.JobPosting, jobFilter filter.JobFilter) []scraper.JobPosting {
+	if jobFilter == (filter.JobFilter{}) {
+		return jobs
+	}
+	matching := jobs[:0:0]
+	for _, job := range jobs {
+		if jobFilter.Match(job) {
+			matching = append(matching, job)
+		}
+	}
+	return matching
+}
 
-	h.logger.Printf("Successfully scraped %d jobs from %s", len(jobs), config.Source)
+// parseIncludeHidden reads the include_hidden query parameter.
+func parseIncludeHidden(r *http.Request) (bool, error) {
+	includeHiddenStr := r.URL.Query().Get("include_hidden")
+	if includeHiddenStr == "" {
+		return false, nil
+	}
+	includeHidden, err := strconv.ParseBool(includeHiddenStr)
+	if err != nil {
+		return false, errors.New("invalid include_hidden value. Must be a boolean")
+	}
+	return includeHidden, nil
 }
 
 func (h *Handler) parseScrapingConfig(r *http.Request) (scraper.ScrapeConfig, error) {
@@ -159,6 +420,38 @@ func ErrInternalServer(err error) render.Renderer {
 	}
 }
 
+func ErrNotFound(err error) render.Renderer {
+	return &ErrorResponse{
+		HTTPStatusCode: http.StatusNotFound,
+		StatusText:     "Not found",
+		ErrorText:      err.Error(),
+	}
+}
+
+func ErrUnauthorized(err error) render.Renderer {
+	return &ErrorResponse{
+		HTTPStatusCode: http.StatusUnauthorized,
+		StatusText:     "Unauthorized",
+		ErrorText:      err.Error(),
+	}
+}
+
+func ErrForbidden(err error) render.Renderer {
+	return &ErrorResponse{
+		HTTPStatusCode: http.StatusForbidden,
+		StatusText:     "Forbidden",
+		ErrorText:      err.Error(),
+	}
+}
+
+func ErrTooManyRequests(err error) render.Renderer {
+	return &ErrorResponse{
+		HTTPStatusCode: http.StatusTooManyRequests,
+		StatusText:     "Too many requests",
+		ErrorText:      err.Error(),
+	}
+}
+
 func ErrNotImplemented(err error) render.Renderer {
 	return &ErrorResponse{
 		HTTPStatusCode: http.StatusNotImplemented,
@@ -170,3 +463,8 @@ func ErrNotImplemented(err error) render.Renderer {
 type SuccessResponse struct {
 	Message string `json:"message"`
 }
+
+type ScrapeStartedResponse struct {
+	Message string `json:"message"`
+	RunID   string `json:"run_id"`
+}
@@ -0,0 +1,187 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ayagmar/gojobscraper/internal/auth"
+	"github.com/ayagmar/gojobscraper/internal/ratelimit"
+	"github.com/go-chi/render"
+)
+
+// QuotaResponse describes the daily scrape quota of a user
+// @Description Daily scrape quota
+type QuotaResponse struct {
+	// PagesPerDay is zero when the user has no quota.
+	PagesPerDay    int       `json:"pages_per_day"`
+	PagesUsed      int       `json:"pages_used"`
+	PagesRemaining int       `json:"pages_remaining"`
+	ResetAt        time.Time `json:"reset_at"`
+}
+
+// RateLimit is a middleware limiting the request rate of every API key. It
+// runs after Authenticate. The limit is reported in X-RateLimit-* headers and
+// requests over it are rejected with 429 and a Retry-After header.
+func (h *Handler) RateLimit(next http.Handler) http.Handler {
+	if h.limiter == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.allow(w, r, h.limiter, rateLimitClient(r)) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// RateLimitIP is a middleware limiting the request rate of routes used
+// without an API key per IP address, like RateLimit. Authenticate counts the
+// requests it rejects against the same limit.
+func (h *Handler) RateLimitIP(next http.Handler) http.Handler {
+	if h.ipLimiter == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.allow(w, r, h.ipLimiter, "ip:"+clientIP(r)) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// allow takes a token of client from limiter and reports the limit in the
+// response headers. It renders a 429 response and returns false when the
+// client is over the limit.
+func (h *Handler) allow(w http.ResponseWriter, r *http.Request, limiter *ratelimit.Limiter, client string) bool {
+	decision := limiter.Allow(client, time.Now())
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(decision.Limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(decision.Reset.Unix(), 10))
+	if decision.Allowed {
+		return true
+	}
+
+	setRetryAfter(w, decision.RetryAfter)
+	if err := render.Render(w, r, ErrTooManyRequests(errors.New("rate limit exceeded"))); err != nil {
+		h.logger.Printf("Error rendering response: %v", err)
+	}
+	return false
+}
+
+// GetQuota handles GET requests for the scrape quota of the current user.
+// @Summary Get scrape quota
+// @Description Get how many pages the current user may still scrape today. Quotas reset at midnight UTC; admins have none.
+// @Tags users
+// @Produce json
+// @Success 200 {object} QuotaResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /me/quota [get]
+func (h *Handler) GetQuota(w http.ResponseWriter, r *http.Request) {
+	quota, err := h.scrapeQuotaOf(currentUser(r), time.Now())
+	if err != nil {
+		h.logger.Printf("Error reading scrape quota: %v", err)
+		if err := render.Render(w, r, ErrInternalServer(err)); err != nil {
+			h.logger.Printf("Error rendering response: %v", err)
+		}
+		return
+	}
+
+	render.JSON(w, r, quota)
+}
+
+// reserveScrapeQuota reserves the given number of pages from the quota of
+// the current user for the day of now. It renders a 429 response and
+// returns false when they would exceed the quota.
+func (h *Handler) reserveScrapeQuota(w http.ResponseWriter, r *http.Request, pages int, now time.Time) bool {
+	user := currentUser(r)
+	if h.scrapeQuota == 0 || user.IsAdmin() {
+		return true
+	}
+
+	reserved, err := h.storage.ReserveScrapePages(user.ID, quotaDay(now), pages, h.scrapeQuota)
+	if err == nil && reserved {
+		return true
+	}
+	var quota QuotaResponse
+	if err == nil {
+		quota, err = h.scrapeQuotaOf(user, now)
+	}
+	if err != nil {
+		h.logger.Printf("Error reserving scrape quota: %v", err)
+		if err := render.Render(w, r, ErrInternalServer(err)); err != nil {
+			h.logger.Printf("Error rendering response: %v", err)
+		}
+		return false
+	}
+
+	setRetryAfter(w, quota.ResetAt.Sub(now))
+	err = fmt.Errorf("daily scrape quota of %d pages exceeded, %d pages left today", quota.PagesPerDay, quota.PagesRemaining)
+	if err := render.Render(w, r, ErrTooManyRequests(err)); err != nil {
+		h.logger.Printf("Error rendering response: %v", err)
+	}
+	return false
+}
+
+// releaseScrapeQuota gives back pages reserved by reserveScrapeQuota for a
+// scrape that did not start.
+func (h *Handler) releaseScrapeQuota(r *http.Request, pages int, now time.Time) {
+	user := currentUser(r)
+	if h.scrapeQuota == 0 || user.IsAdmin() {
+		return
+	}
+	if err := h.storage.ReleaseScrapePages(user.ID, quotaDay(now), pages); err != nil {
+		h.logger.Printf("Error releasing scrape quota: %v", err)
+	}
+}
+
+func (h *Handler) scrapeQuotaOf(user auth.User, now time.Time) (QuotaResponse, error) {
+	day := quotaDay(now)
+	quota := QuotaResponse{ResetAt: day.Add(24 * time.Hour)}
+	if h.scrapeQuota == 0 || user.IsAdmin() {
+		return quota, nil
+	}
+
+	used, err := h.storage.GetScrapedPages(user.ID, day)
+	if err != nil {
+		return QuotaResponse{}, err
+	}
+	quota.PagesPerDay = h.scrapeQuota
+	quota.PagesUsed = used
+	quota.PagesRemaining = max(h.scrapeQuota-used, 0)
+	return quota, nil
+}
+
+// quotaDay returns the start of the UTC day scrape quotas are counted for.
+func quotaDay(now time.Time) time.Time {
+	return now.UTC().Truncate(24 * time.Hour)
+}
+
+// rateLimitClient returns the key a request is rate limited under.
+func rateLimitClient(r *http.Request) string {
+	if key, ok := auth.APIKeyFromContext(r.Context()); ok {
+		return "key:" + key.ID
+	}
+	return "ip:" + clientIP(r)
+}
+
+// clientIP returns the address of the client of a request. RemoteAddr is
+// the client address set by middleware.RealIP, which has no port, or the
+// address of the connection.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func setRetryAfter(w http.ResponseWriter, wait time.Duration) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+}
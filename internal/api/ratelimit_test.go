@@ -0,0 +1,265 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ayagmar/gojobscraper/internal/auth"
+	"github.com/ayagmar/gojobscraper/internal/ratelimit"
+	"github.com/ayagmar/gojobscraper/internal/scraper"
+	"github.com/ayagmar/gojobscraper/internal/storage"
+)
+
+// memStore holds the users, API keys, scrape quotas and runs of a test in
+// memory. Calls to the other storage methods panic.
+type memStore struct {
+	storage.Storage
+
+	mu         sync.Mutex
+	users      map[string]auth.User
+	keys       map[string]auth.APIKey
+	pages      map[string]int
+	runs       []scraper.ScrapeRun
+	saveRunErr error
+}
+
+func newMemStore() *memStore {
+	return &memStore{users: make(map[string]auth.User), keys: make(map[string]auth.APIKey), pages: make(map[string]int)}
+}
+
+// addUser stores a user with a single API key and returns the key.
+func (s *memStore) addUser(t *testing.T, id string, role auth.Role) string {
+	t.Helper()
+	key, plaintext, err := auth.NewAPIKey(id, "test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[id] = auth.User{ID: id, Name: id, Role: role}
+	s.keys[key.Hash] = key
+	return plaintext
+}
+
+func (s *memStore) GetUser(id string) (auth.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[id]
+	if !ok {
+		return auth.User{}, storage.ErrNotFound
+	}
+	return user, nil
+}
+
+func (s *memStore) LookupAPIKey(hash string) (auth.APIKey, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.keys[hash]
+	return key, ok, nil
+}
+
+func (s *memStore) TouchAPIKey(id string, at time.Time) error {
+	return nil
+}
+
+func (s *memStore) ReserveScrapePages(userID string, day time.Time, pages, limit int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counter := userID + "/" + day.Format(time.DateOnly)
+	if s.pages[counter]+pages > limit {
+		return false, nil
+	}
+	s.pages[counter] += pages
+	return true, nil
+}
+
+func (s *memStore) ReleaseScrapePages(userID string, day time.Time, pages int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pages[userID+"/"+day.Format(time.DateOnly)] -= pages
+	return nil
+}
+
+func (s *memStore) GetScrapedPages(userID string, day time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pages[userID+"/"+day.Format(time.DateOnly)], nil
+}
+
+func (s *memStore) SaveRun(run scraper.ScrapeRun) error {
+	return s.saveRunErr
+}
+
+func newTestHandler(store storage.Storage, limiter, ipLimiter *ratelimit.Limiter, scrapeQuota int) *Handler {
+	return NewHandler(store, nil, scraper.Options{}, nil, nil, nil, nil, nil, limiter, ipLimiter, scrapeQuota, nil, log.New(io.Discard, "", 0))
+}
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+// get serves a GET request from remoteAddr with the given API key.
+func get(handler http.Handler, remoteAddr, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/jobs", nil)
+	req.RemoteAddr = remoteAddr
+	if key != "" {
+		req.Header.Set("X-API-Key", key)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// checkRejected checks a response rejected by a rate limit.
+func checkRejected(t *testing.T, rec *httptest.ResponseRecorder, limit int) {
+	t.Helper()
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status %d, want 429", rec.Code)
+	}
+	if retry, err := strconv.Atoi(rec.Header().Get("Retry-After")); err != nil || retry < 1 {
+		t.Errorf("Retry-After %q, want whole seconds", rec.Header().Get("Retry-After"))
+	}
+	if got := rec.Header().Get("X-RateLimit-Limit"); got != strconv.Itoa(limit) {
+		t.Errorf("X-RateLimit-Limit %q, want %d", got, limit)
+	}
+	if got := rec.Header().Get("X-RateLimit-Remaining"); got != "0" {
+		t.Errorf("X-RateLimit-Remaining %q, want 0", got)
+	}
+	if reset, err := strconv.ParseInt(rec.Header().Get("X-RateLimit-Reset"), 10, 64); err != nil || reset < time.Now().Unix() {
+		t.Errorf("X-RateLimit-Reset %q, want a future Unix time", rec.Header().Get("X-RateLimit-Reset"))
+	}
+}
+
+func TestRateLimitPerKey(t *testing.T) {
+	store := newMemStore()
+	ada := store.addUser(t, "ada", auth.RoleUser)
+	bob := store.addUser(t, "bob", auth.RoleUser)
+	h := newTestHandler(store, ratelimit.New(1, 2), ratelimit.New(1, 2), 0)
+	handler := h.Authenticate(h.RateLimit(okHandler))
+
+	// Keys behind the same address each get their own allowance.
+	for _, key := range []string{ada, bob} {
+		for i := 0; i < 2; i++ {
+			rec := get(handler, "203.0.113.7:1234", key)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("request %d: status %d, want 200", i, rec.Code)
+			}
+			if got := rec.Header().Get("X-RateLimit-Remaining"); got != strconv.Itoa(1-i) {
+				t.Errorf("request %d: X-RateLimit-Remaining %q, want %d", i, got, 1-i)
+			}
+		}
+	}
+
+	checkRejected(t, get(handler, "203.0.113.7:1234", ada), 2)
+	checkRejected(t, get(handler, "198.51.100.1:1234", bob), 2)
+}
+
+func TestRateLimitFailedAuth(t *testing.T) {
+	store := newMemStore()
+	ada := store.addUser(t, "ada", auth.RoleUser)
+	h := newTestHandler(store, ratelimit.New(1, 5), ratelimit.New(1, 2), 0)
+	handler := h.Authenticate(h.RateLimit(okHandler))
+
+	for _, key := range []string{"", "jsk_invalid"} {
+		if rec := get(handler, "203.0.113.7:1234", key); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("status %d, want 401", rec.Code)
+		}
+	}
+	checkRejected(t, get(handler, "203.0.113.7:1234", "jsk_invalid"), 2)
+
+	// Valid keys are not limited by the failures of their address.
+	if rec := get(handler, "203.0.113.7:1234", ada); rec.Code != http.StatusOK {
+		t.Errorf("valid key: status %d, want 200", rec.Code)
+	}
+	if rec := get(handler, "198.51.100.1:1234", "jsk_invalid"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("other address: status %d, want 401", rec.Code)
+	}
+}
+
+func TestRateLimitIP(t *testing.T) {
+	h := newTestHandler(newMemStore(), nil, ratelimit.New(1, 1), 0)
+	handler := h.RateLimitIP(okHandler)
+
+	if rec := get(handler, "203.0.113.7:1234", ""); rec.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200", rec.Code)
+	}
+	checkRejected(t, get(handler, "203.0.113.7:5678", ""), 1)
+
+	// Without limiters, requests pass through.
+	h = newTestHandler(newMemStore(), nil, nil, 0)
+	for i := 0; i < 3; i++ {
+		if rec := get(h.RateLimitIP(h.RateLimit(okHandler)), "203.0.113.7:1234", ""); rec.Code != http.StatusOK || rec.Header().Get("X-RateLimit-Limit") != "" {
+			t.Fatalf("unlimited request: status %d, headers %v", rec.Code, rec.Header())
+		}
+	}
+}
+
+// scrapeRequest returns a request of user to scrape the given pages.
+func scrapeRequest(user auth.User, pages int) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/scrape?jobTitle=go&country=fr&source=indeed&pages="+strconv.Itoa(pages), nil)
+	return req.WithContext(auth.WithUser(req.Context(), user))
+}
+
+func TestReserveScrapeQuota(t *testing.T) {
+	store := newMemStore()
+	h := newTestHandler(store, nil, nil, 5)
+	ada := auth.User{ID: "ada", Role: auth.RoleUser}
+	now := time.Now()
+
+	rec := httptest.NewRecorder()
+	if !h.reserveScrapeQuota(rec, scrapeRequest(ada, 3), 3, now) {
+		t.Fatalf("3 of 5 pages rejected: %s", rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	if h.reserveScrapeQuota(rec, scrapeRequest(ada, 3), 3, now) {
+		t.Fatal("3 more pages reserved over the quota")
+	}
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("status %d, Retry-After %q, want 429 with Retry-After", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	var body ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.ErrorText != "daily scrape quota of 5 pages exceeded, 2 pages left today" {
+		t.Errorf("error %q", body.ErrorText)
+	}
+
+	h.releaseScrapeQuota(scrapeRequest(ada, 3), 3, now)
+	if used, _ := store.GetScrapedPages("ada", quotaDay(now)); used != 0 {
+		t.Errorf("%d pages used after release, want 0", used)
+	}
+
+	// Admins have no quota.
+	admin := auth.User{ID: "root", Role: auth.RoleAdmin}
+	if !h.reserveScrapeQuota(httptest.NewRecorder(), scrapeRequest(admin, 50), 50, now) {
+		t.Error("admin limited by the quota")
+	}
+	if used, _ := store.GetScrapedPages("root", quotaDay(now)); used != 0 {
+		t.Errorf("%d pages counted for an admin, want 0", used)
+	}
+}
+
+func TestStartScrapingReleasesQuota(t *testing.T) {
+	store := newMemStore()
+	store.saveRunErr = errors.New("connection refused")
+	h := newTestHandler(store, nil, nil, 5)
+
+	rec := httptest.NewRecorder()
+	h.StartScraping(rec, scrapeRequest(auth.User{ID: "ada", Role: auth.RoleUser}, 4))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status %d, want 500", rec.Code)
+	}
+	if used, _ := store.GetScrapedPages("ada", quotaDay(time.Now())); used != 0 {
+		t.Errorf("%d pages kept for a scrape that did not start, want 0", used)
+	}
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ayagmar/gojobscraper/internal/scraper"
+	"github.com/ayagmar/gojobscraper/internal/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// GetRuns handles GET requests for retrieving scrape runs.
+// @Summary Get scrape runs
+// @Description Get the scrape runs of the current user, most recent first. Admins get the runs of every user.
+// @Tags scrapes
+// @Accept json
+// @Produce json
+// @Success 200 {array} scraper.ScrapeRun
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /scrapes [get]
+func (h *Handler) GetRuns(w http.ResponseWriter, r *http.Request) {
+	runs, err := h.storage.GetRuns()
+	if err != nil {
+		h.logger.Printf("Error retrieving scrape runs: %v", err)
+		err := render.Render(w, r, ErrInternalServer(err))
+		if err != nil {
+			return
+		}
+		return
+	}
+
+	user := currentUser(r)
+	owned := make([]scraper.ScrapeRun, 0, len(runs))
+	for _, run := range runs {
+		if canAccess(user, run.UserID) {
+			owned = append(owned, run)
+		}
+	}
+
+	render.JSON(w, r, owned)
+}
+
+// GetRun handles GET requests for retrieving a single scrape run.
+// @Summary Get scrape run
+// @Description Get the status and results of a scrape run. The runs of other users are not found, except by admins.
+// @Tags scrapes
+// @Accept json
+// @Produce json
+// @Param id path string true "Scrape run ID"
+// @Success 200 {object} scraper.ScrapeRun
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /scrapes/{id} [get]
+func (h *Handler) GetRun(w http.ResponseWriter, r *http.Request) {
+	run, err := h.ownedRun(r)
+	if err != nil {
+		renderer := ErrInternalServer(err)
+		if errors.Is(err, storage.ErrNotFound) {
+			renderer = ErrNotFound(errors.New("scrape run not found"))
+		} else {
+			h.logger.Printf("Error retrieving scrape run: %v", err)
+		}
+		if err := render.Render(w, r, renderer); err != nil {
+			h.logger.Printf("Error rendering response: %v", err)
+		}
+		return
+	}
+
+	render.JSON(w, r, run)
+}
+
+// ownedRun returns the scrape run named by the request path. The runs of
+// other users are reported as not found, except to admins.
+func (h *Handler) ownedRun(r *http.Request) (scraper.ScrapeRun, error) {
+	run, err := h.storage.GetRun(chi.URLParam(r, "id"))
+	if err != nil {
+		return scraper.ScrapeRun{}, err
+	}
+	if !canAccess(currentUser(r), run.UserID) {
+		return scraper.ScrapeRun{}, storage.ErrNotFound
+	}
+	return run, nil
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ayagmar/gojobscraper/internal/auth"
+	"github.com/ayagmar/gojobscraper/internal/scraper"
+	"github.com/ayagmar/gojobscraper/internal/storage"
+	"github.com/go-chi/chi/v5"
+)
+
+func (s *memStore) GetRun(id string) (scraper.ScrapeRun, error) {
+	for _, run := range s.runs {
+		if run.ID == id {
+			return run, nil
+		}
+	}
+	return scraper.ScrapeRun{}, storage.ErrNotFound
+}
+
+func (s *memStore) GetRuns() ([]scraper.ScrapeRun, error) {
+	return s.runs, nil
+}
+
+// runRequest returns a request of user for the run with the given ID.
+func runRequest(user auth.User, id string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/scrapes/"+id, nil)
+	routeCtx := chi.NewRouteContext()
+	routeCtx.URLParams.Add("id", id)
+	ctx := context.WithValue(auth.WithUser(req.Context(), user), chi.RouteCtxKey, routeCtx)
+	return req.WithContext(ctx)
+}
+
+func TestRunsOwnership(t *testing.T) {
+	store := newMemStore()
+	store.runs = []scraper.ScrapeRun{{ID: "ada-run", UserID: "ada"}, {ID: "bob-run", UserID: "bob"}, {ID: "legacy-run"}}
+	h := newTestHandler(store, nil, nil, 0)
+	ada := auth.User{ID: "ada", Role: auth.RoleUser}
+	admin := auth.User{ID: "root", Role: auth.RoleAdmin}
+
+	tests := []struct {
+		name string
+		user auth.User
+		want []string
+	}{
+		{"user", ada, []string{"ada-run"}},
+		{"admin", admin, []string{"ada-run", "bob-run", "legacy-run"}},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.GetRuns(rec, runRequest(tt.user, ""))
+		var runs []scraper.ScrapeRun
+		if err := json.NewDecoder(rec.Body).Decode(&runs); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var got []string
+		for _, run := range runs {
+			got = append(got, run.ID)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: runs %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	for _, id := range []string{"bob-run", "legacy-run"} {
+		rec := httptest.NewRecorder()
+		h.GetRun(rec, runRequest(ada, id))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("run %s of another user: status %d, want 404", id, rec.Code)
+		}
+		rec = httptest.NewRecorder()
+		h.GetRunEvents(rec, runRequest(ada, id))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("events of run %s of another user: status %d, want 404", id, rec.Code)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	h.GetRun(rec, runRequest(ada, "ada-run"))
+	if rec.Code != http.StatusOK {
+		t.Errorf("own run: status %d, want 200", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	h.GetRun(rec, runRequest(admin, "bob-run"))
+	if rec.Code != http.StatusOK {
+		t.Errorf("run of another user for an admin: status %d, want 200", rec.Code)
+	}
+}
@@ -0,0 +1,255 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ayagmar/gojobscraper/internal/filter"
+	"github.com/ayagmar/gojobscraper/internal/search"
+	"github.com/ayagmar/gojobscraper/internal/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultMatchesLimit = 100
+	maxMatchesLimit     = 1000
+)
+
+// SearchRequest creates a saved search
+// @Description Saved search
+type SearchRequest struct {
+	Name     string          `json:"name"`
+	Criteria filter.Criteria `json:"criteria"`
+}
+
+func (req *SearchRequest) Bind(r *http.Request) error {
+	if req.Name == "" {
+		return errors.New("missing name")
+	}
+	for _, source := range req.Criteria.Sources {
+		if !isValidScraperType(source) {
+			return errors.New("invalid source. Must be 'indeed' or 'linkedin'")
+		}
+	}
+	return req.Criteria.Validate()
+}
+
+// CreateSearch handles POST requests creating a saved search.
+// @Summary Create saved search
+// @Description Save a search. Every job saved from then on is evaluated against its criteria and recorded as a match when it satisfies them. Seniority is inferred from the job title and salaries are read from the summary and description. The feed token authenticates the Atom and RSS feeds of the search.
+// @Tags searches
+// @Accept json
+// @Produce json
+// @Param search body SearchRequest true "Saved search"
+// @Success 201 {object} search.SavedSearch
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /searches [post]
+func (h *Handler) CreateSearch(w http.ResponseWriter, r *http.Request) {
+	var req SearchRequest
+	if err := render.Bind(r, &req); err != nil {
+		if err := render.Render(w, r, ErrInvalidRequest(err)); err != nil {
+			h.logger.Printf("Error rendering response: %v", err)
+		}
+		return
+	}
+
+	feedToken, err := search.NewFeedToken()
+	if err != nil {
+		h.renderSearchError(w, r, err)
+		return
+	}
+
+	s := search.SavedSearch{
+		ID:        uuid.New().String(),
+		OwnerID:   currentUser(r).ID,
+		Name:      req.Name,
+		Criteria:  req.Criteria,
+		CreatedAt: time.Now(),
+		FeedToken: feedToken,
+	}
+	if err := h.storage.SaveSearch(s); err != nil {
+		h.renderSearchError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, s)
+}
+
+// GetSearches handles GET requests for retrieving saved searches.
+// @Summary Get saved searches
+// @Description Get the saved searches of the current user, or every saved search for admins
+// @Tags searches
+// @Produce json
+// @Success 200 {array} search.SavedSearch
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /searches [get]
+func (h *Handler) GetSearches(w http.ResponseWriter, r *http.Request) {
+	searches, err := h.storage.GetSearches()
+	if err != nil {
+		h.renderSearchError(w, r, err)
+		return
+	}
+
+	user := currentUser(r)
+	owned := make([]search.SavedSearch, 0, len(searches))
+	for _, s := range searches {
+		if canAccess(user, s.OwnerID) {
+			owned = append(owned, s)
+		}
+	}
+
+	render.JSON(w, r, owned)
+}
+
+// GetSearch handles GET requests for retrieving a single saved search.
+// @Summary Get saved search
+// @Description Get a saved search
+// @Tags searches
+// @Produce json
+// @Param id path string true "Saved search ID"
+// @Success 200 {object} search.SavedSearch
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /searches/{id} [get]
+func (h *Handler) GetSearch(w http.ResponseWriter, r *http.Request) {
+	s, err := h.ownedSearch(r)
+	if err != nil {
+		h.renderSearchError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, s)
+}
+
+// DeleteSearch handles DELETE requests removing a saved search.
+// @Summary Delete saved search
+// @Description Remove a saved search and its matches
+// @Tags searches
+// @Produce json
+// @Param id path string true "Saved search ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /searches/{id} [delete]
+func (h *Handler) DeleteSearch(w http.ResponseWriter, r *http.Request) {
+	s, err := h.ownedSearch(r)
+	if err != nil {
+		h.renderSearchError(w, r, err)
+		return
+	}
+
+	if err := h.storage.DeleteSearch(s.ID); err != nil {
+		h.renderSearchError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, SuccessResponse{Message: "Saved search deleted"})
+}
+
+// GetSearchMatches handles GET requests for the jobs matched by a saved search.
+// @Summary Get saved search matches
+// @Description Get the most recent jobs matched by a saved search, newest first
+// @Tags searches
+// @Produce json
+// @Param id path string true "Saved search ID"
+// @Param limit query int false "Maximum number of matches" default(100)
+// @Success 200 {array} search.Match
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /searches/{id}/matches [get]
+func (h *Handler) GetSearchMatches(w http.ResponseWriter, r *http.Request) {
+	limit := defaultMatchesLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxMatchesLimit {
+			if err := render.Render(w, r, ErrInvalidRequest(errors.New("invalid limit. Must be between 1 and 1000"))); err != nil {
+				h.logger.Printf("Error rendering response: %v", err)
+			}
+			return
+		}
+	}
+
+	s, err := h.ownedSearch(r)
+	if err != nil {
+		h.renderSearchError(w, r, err)
+		return
+	}
+
+	matches, err := h.storage.GetSearchMatches(s.ID, limit)
+	if err != nil {
+		h.renderSearchError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, matches)
+}
+
+// ResetFeedToken handles POST requests replacing the feed token of a saved search.
+// @Summary Reset saved search feed token
+// @Description Replace the token of the Atom and RSS feeds of a saved search, revoking the feed URLs handed out so far. Searches saved before feeds were available get their first token this way.
+// @Tags searches
+// @Produce json
+// @Param id path string true "Saved search ID"
+// @Success 200 {object} search.SavedSearch
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /searches/{id}/feed-token [post]
+func (h *Handler) ResetFeedToken(w http.ResponseWriter, r *http.Request) {
+	s, err := h.ownedSearch(r)
+	if err != nil {
+		h.renderSearchError(w, r, err)
+		return
+	}
+
+	s.FeedToken, err = search.NewFeedToken()
+	if err != nil {
+		h.renderSearchError(w, r, err)
+		return
+	}
+	if err := h.storage.SaveSearch(s); err != nil {
+		h.renderSearchError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, s)
+}
+
+// ownedSearch returns the saved search named by the request path. The
+// searches of other users are reported as not found, except to admins.
+func (h *Handler) ownedSearch(r *http.Request) (search.SavedSearch, error) {
+	s, err := h.storage.GetSearch(chi.URLParam(r, "id"))
+	if err != nil {
+		return search.SavedSearch{}, err
+	}
+	if !canAccess(currentUser(r), s.OwnerID) {
+		return search.SavedSearch{}, storage.ErrNotFound
+	}
+	return s, nil
+}
+
+func (h *Handler) renderSearchError(w http.ResponseWriter, r *http.Request, err error) {
+	renderer := ErrInternalServer(err)
+	if errors.Is(err, storage.ErrNotFound) {
+		renderer = ErrNotFound(errors.New("saved search not found"))
+	} else {
+		h.logger.Printf("Error handling saved search request: %v", err)
+	}
+
+	if err := render.Render(w, r, renderer); err != nil {
+		h.logger.Printf("Error rendering response: %v", err)
+	}
+}
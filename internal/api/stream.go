@@ -0,0 +1,221 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ayagmar/gojobscraper/internal/filter"
+	"github.com/ayagmar/gojobscraper/internal/scraper"
+	"github.com/go-chi/render"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// feedBuffer is how many postings a slow client may lag behind before it
+	// is disconnected.
+	feedBuffer = 256
+	// feedPingInterval is the interval of ping messages sent to idle clients
+	// so that proxies do not close the connection.
+	feedPingInterval = 30 * time.Second
+	// feedWriteTimeout bounds the delivery of a single message.
+	feedWriteTimeout = 10 * time.Second
+)
+
+// JobStreamMessage is a message of the job feed
+// @Description Message of the live job feed
+type JobStreamMessage struct {
+	// Type is "job" for a newly inserted posting and "ping" for keep-alives.
+	Type string              `json:"type"`
+	Job  *scraper.JobPosting `json:"job,omitempty"`
+}
+
+// jobFeed fans newly inserted postings out to the clients whose filter they
+// match.
+type jobFeed struct {
+	mu          sync.Mutex
+	subscribers map[chan scraper.JobPosting]filter.JobFilter
+}
+
+func newJobFeed() *jobFeed {
+	return &jobFeed{subscribers: make(map[chan scraper.JobPosting]filter.JobFilter)}
+}
+
+// publish sends jobs to the subscribers they match. Subscribers that cannot
+// keep up are disconnected rather than slowing down the scrape.
+func (f *jobFeed) publish(jobs []scraper.JobPosting) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for ch, jobFilter := range f.subscribers {
+		if !deliver(ch, jobFilter, jobs) {
+			delete(f.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// deliver sends the jobs matching jobFilter to ch without blocking. It
+// returns false if ch is full.
+func deliver(ch chan scraper.JobPosting, jobFilter filter.JobFilter, jobs []scraper.JobPosting) bool {
+	for _, job := range jobs {
+		if !jobFilter.Match(job) {
+			continue
+		}
+		select {
+		case ch <- job:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func (f *jobFeed) subscribe(jobFilter filter.JobFilter) chan scraper.JobPosting {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ch := make(chan scraper.JobPosting, feedBuffer)
+	f.subscribers[ch] = jobFilter
+	return ch
+}
+
+func (f *jobFeed) unsubscribe(ch chan scraper.JobPosting) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.subscribers[ch]; ok {
+		delete(f.subscribers, ch)
+		close(ch)
+	}
+}
+
+// StreamJobs handles WebSocket connections following newly discovered jobs.
+// @Summary Stream new jobs
+// @Description Open a WebSocket receiving every newly inserted job posting that matches the filter, as JSON messages of type "job". Updates of known postings are not sent. Messages of type "ping" are sent while the feed is idle. Connections from web pages of origins other than the API's own and the configured allowed origins are refused with 403.
+// @Tags jobScraper
+// @Produce json
+// @Param source query string false "Source of job listings (indeed or linkedin)" Enums(indeed, linkedin)
+// @Param keyword query string false "Whole words in the title, summary, description or company name"
+// @Param location query string false "Whole words of the location"
+// @Param api_key query string false "API key, for clients that cannot set headers"
+// @Success 101 {object} JobStreamMessage
+// @Failure 400 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /jobs/stream [get]
+func (h *Handler) StreamJobs(w http.ResponseWriter, r *http.Request) {
+	jobFilter, err := parseJobFilter(r)
+	if err != nil {
+		if err := render.Render(w, r, ErrInvalidRequest(err)); err != nil {
+			h.logger.Printf("Error rendering response: %v", err)
+		}
+		return
+	}
+
+	server := websocket.Server{
+		Handshake: func(_ *websocket.Config, r *http.Request) error {
+			return h.checkOrigin(r)
+		},
+		Handler: func(conn *websocket.Conn) {
+			h.serveJobStream(conn, jobFilter)
+		},
+	}
+	server.ServeHTTP(w, r)
+}
+
+func (h *Handler) serveJobStream(conn *websocket.Conn, jobFilter filter.JobFilter) {
+	defer conn.Close()
+
+	// The connection outlives the server read and write timeouts.
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		h.logger.Printf("Error clearing job stream deadline: %v", err)
+		return
+	}
+
+	jobs := h.feed.subscribe(jobFilter)
+	defer h.feed.unsubscribe(jobs)
+
+	// Clients do not send anything; reading detects when they go away.
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		var discard []byte
+		for websocket.Message.Receive(conn, &discard) == nil {
+		}
+	}()
+
+	ping := time.NewTicker(feedPingInterval)
+	defer ping.Stop()
+
+	for {
+		var message JobStreamMessage
+		select {
+		case <-closed:
+			return
+		case job, ok := <-jobs:
+			if !ok {
+				h.logger.Printf("Closing job stream of %s: client too slow", conn.Request().RemoteAddr)
+				return
+			}
+			message = JobStreamMessage{Type: "job", Job: &job}
+		case <-ping.C:
+			message = JobStreamMessage{Type: "ping"}
+		}
+
+		if err := conn.SetWriteDeadline(time.Now().Add(feedWriteTimeout)); err != nil {
+			return
+		}
+		if err := websocket.JSON.Send(conn, message); err != nil {
+			return
+		}
+	}
+}
+
+// errForbiddenOrigin is returned for WebSocket handshakes from pages of other
+// sites.
+var errForbiddenOrigin = errors.New("origin not allowed")
+
+// checkOrigin accepts WebSocket handshakes from pages of the API's own origin
+// and of the allowed origins. Browsers always send Origin with WebSocket
+// handshakes, so requests without one come from other clients and are
+// accepted: the API key authenticates them.
+func (h *Handler) checkOrigin(r *http.Request) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return errForbiddenOrigin
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return nil
+	}
+	for _, allowed := range h.allowedOrigins {
+		if strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return nil
+		}
+	}
+
+	h.logger.Printf("Refusing job stream from origin %s", origin)
+	return errForbiddenOrigin
+}
+
+// parseJobFilter reads a job filter from the source, keyword and location
+// query parameters.
+func parseJobFilter(r *http.Request) (filter.JobFilter, error) {
+	query := r.URL.Query()
+	jobFilter := filter.JobFilter{
+		Source:   scraper.ScraperType(query.Get("source")),
+		Keyword:  query.Get("keyword"),
+		Location: query.Get("location"),
+	}
+	if jobFilter.Source != "" && !isValidScraperType(jobFilter.Source) {
+		return filter.JobFilter{}, errors.New("invalid source. Must be 'indeed' or 'linkedin'")
+	}
+	return jobFilter, nil
+}
@@ -0,0 +1,313 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/mail"
+	"time"
+
+	"github.com/ayagmar/gojobscraper/internal/filter"
+	"github.com/ayagmar/gojobscraper/internal/notify"
+	"github.com/ayagmar/gojobscraper/internal/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+// SubscriberRequest subscribes an email address to digests
+// @Description Email digest subscription
+type SubscriberRequest struct {
+	Email    string           `json:"email"`
+	Name     string           `json:"name,omitempty"`
+	Filter   filter.JobFilter `json:"filter"`
+	Schedule notify.Schedule  `json:"schedule"`
+}
+
+func (req *SubscriberRequest) Bind(r *http.Request) error {
+	if _, err := mail.ParseAddress(req.Email); err != nil {
+		return errors.New("invalid email address")
+	}
+	if req.Filter.Source != "" && !isValidScraperType(req.Filter.Source) {
+		return errors.New("invalid filter source. Must be 'indeed' or 'linkedin'")
+	}
+	return req.Schedule.Validate()
+}
+
+// DigestSentResponse reports a digest sent on demand
+// @Description Result of sending a digest
+type DigestSentResponse struct {
+	Message string `json:"message"`
+	Jobs    int    `json:"jobs"`
+}
+
+// CreateSubscriber handles POST requests subscribing to email digests.
+// @Summary Create subscriber
+// @Description Subscribe an email address to digests of the jobs first seen since the previous digest that match the filter, grouped by company
+// @Tags subscribers
+// @Accept json
+// @Produce json
+// @Param subscriber body SubscriberRequest true "Subscriber"
+// @Success 201 {object} notify.Subscriber
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /subscribers [post]
+func (h *Handler) CreateSubscriber(w http.ResponseWriter, r *http.Request) {
+	var req SubscriberRequest
+	if err := render.Bind(r, &req); err != nil {
+		if err := render.Render(w, r, ErrInvalidRequest(err)); err != nil {
+			h.logger.Printf("Error rendering response: %v", err)
+		}
+		return
+	}
+
+	token, err := notify.NewUnsubscribeToken()
+	if err != nil {
+		h.renderSubscriberError(w, r, err)
+		return
+	}
+
+	now := time.Now()
+	subscriber := notify.Subscriber{
+		ID:               uuid.New().String(),
+		OwnerID:          currentUser(r).ID,
+		Email:            req.Email,
+		Name:             req.Name,
+		Filter:           req.Filter,
+		Schedule:         req.Schedule,
+		UnsubscribeToken: token,
+		NextDigestAt:     req.Schedule.Next(now),
+		CreatedAt:        now,
+	}
+	if err := h.storage.SaveSubscriber(subscriber); err != nil {
+		h.renderSubscriberError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, subscriber)
+}
+
+// GetSubscribers handles GET requests for retrieving digest subscribers.
+// @Summary Get subscribers
+// @Description Get the email digest subscribers of the current user, or every subscriber for admins
+// @Tags subscribers
+// @Produce json
+// @Success 200 {array} notify.Subscriber
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /subscribers [get]
+func (h *Handler) GetSubscribers(w http.ResponseWriter, r *http.Request) {
+	subscribers, err := h.storage.GetSubscribers()
+	if err != nil {
+		h.renderSubscriberError(w, r, err)
+		return
+	}
+
+	user := currentUser(r)
+	owned := make([]notify.Subscriber, 0, len(subscribers))
+	for _, subscriber := range subscribers {
+		if canAccess(user, subscriber.OwnerID) {
+			owned = append(owned, subscriber)
+		}
+	}
+
+	render.JSON(w, r, owned)
+}
+
+// GetSubscriber handles GET requests for retrieving a single subscriber.
+// @Summary Get subscriber
+// @Description Get an email digest subscriber
+// @Tags subscribers
+// @Produce json
+// @Param id path string true "Subscriber ID"
+// @Success 200 {object} notify.Subscriber
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /subscribers/{id} [get]
+func (h *Handler) GetSubscriber(w http.ResponseWriter, r *http.Request) {
+	subscriber, err := h.ownedSubscriber(r)
+	if err != nil {
+		h.renderSubscriberError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, subscriber)
+}
+
+// DeleteSubscriber handles DELETE requests removing a subscriber.
+// @Summary Delete subscriber
+// @Description Remove an email digest subscriber
+// @Tags subscribers
+// @Produce json
+// @Param id path string true "Subscriber ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /subscribers/{id} [delete]
+func (h *Handler) DeleteSubscriber(w http.ResponseWriter, r *http.Request) {
+	subscriber, err := h.ownedSubscriber(r)
+	if err != nil {
+		h.renderSubscriberError(w, r, err)
+		return
+	}
+
+	if err := h.storage.DeleteSubscriber(subscriber.ID); err != nil {
+		h.renderSubscriberError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, SuccessResponse{Message: "Subscriber deleted"})
+}
+
+// SendDigest handles POST requests sending a subscriber's digest immediately.
+// @Summary Send digest
+// @Description Send the subscriber's digest now instead of at its next scheduled time. The next digest is rescheduled from now.
+// @Tags subscribers
+// @Produce json
+// @Param id path string true "Subscriber ID"
+// @Success 200 {object} DigestSentResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 501 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /subscribers/{id}/digest [post]
+func (h *Handler) SendDigest(w http.ResponseWriter, r *http.Request) {
+	if h.digester == nil {
+		if err := render.Render(w, r, ErrNotImplemented(errors.New("email digests are not configured"))); err != nil {
+			h.logger.Printf("Error rendering response: %v", err)
+		}
+		return
+	}
+
+	subscriber, err := h.ownedSubscriber(r)
+	if err != nil {
+		h.renderSubscriberError(w, r, err)
+		return
+	}
+
+	jobs, err := h.digester.Send(subscriber, time.Now())
+	if err != nil {
+		h.renderSubscriberError(w, r, err)
+		return
+	}
+
+	message := "Digest sent"
+	if jobs == 0 {
+		message = "No new jobs, digest not sent"
+	}
+	render.JSON(w, r, DigestSentResponse{Message: message, Jobs: jobs})
+}
+
+// UnsubscribePage handles the unsubscribe links of digest emails.
+// @Summary Confirm unsubscribe
+// @Description Show a page asking to confirm the unsubscribe. Following the link does not unsubscribe, so that mail scanners fetching the links of emails do not unsubscribe their recipients.
+// @Tags subscribers
+// @Produce html
+// @Param token query string true "Unsubscribe token"
+// @Success 200 {string} string
+// @Failure 404 {object} ErrorResponse
+// @Router /subscribers/unsubscribe [get]
+func (h *Handler) UnsubscribePage(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		h.renderSubscriberError(w, r, storage.ErrNotFound)
+		return
+	}
+
+	h.renderUnsubscribePage(w, unsubscribePageData{Token: token})
+}
+
+// Unsubscribe handles the confirmation of unsubscribe pages and the one-click
+// unsubscribe requests of mail clients (RFC 8058).
+// @Summary Unsubscribe
+// @Description Stop the digests of the subscriber the token was issued to. Mail clients send List-Unsubscribe=One-Click as the form body, which is not required.
+// @Tags subscribers
+// @Accept x-www-form-urlencoded
+// @Produce html
+// @Param token query string true "Unsubscribe token"
+// @Success 200 {string} string
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /subscribers/unsubscribe [post]
+func (h *Handler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		h.renderSubscriberError(w, r, storage.ErrNotFound)
+		return
+	}
+
+	if err := h.storage.Unsubscribe(token); err != nil {
+		h.renderSubscriberError(w, r, err)
+		return
+	}
+
+	h.renderUnsubscribePage(w, unsubscribePageData{Unsubscribed: true})
+}
+
+type unsubscribePageData struct {
+	Token        string
+	Unsubscribed bool
+}
+
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Unsubscribe</title>
+</head>
+<body style="font-family: sans-serif; color: #222;">
+{{if .Unsubscribed}}<p>You have been unsubscribed. You will not receive further digests.</p>
+{{else}}<p>Stop receiving job digests at this address?</p>
+<form method="post" action="unsubscribe?token={{.Token}}">
+<button type="submit">Unsubscribe</button>
+</form>
+{{end}}</body>
+</html>
+`))
+
+func (h *Handler) renderUnsubscribePage(w http.ResponseWriter, data unsubscribePageData) {
+	var page bytes.Buffer
+	if err := unsubscribePage.Execute(&page, data); err != nil {
+		h.logger.Printf("Error rendering unsubscribe page: %v", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if _, err := page.WriteTo(w); err != nil {
+		h.logger.Printf("Error writing unsubscribe page: %v", err)
+	}
+}
+
+// ownedSubscriber returns the subscriber named by the request path. The
+// subscribers of other users are reported as not found, except to admins.
+func (h *Handler) ownedSubscriber(r *http.Request) (notify.Subscriber, error) {
+	subscriber, err := h.storage.GetSubscriber(chi.URLParam(r, "id"))
+	if err != nil {
+		return notify.Subscriber{}, err
+	}
+	if !canAccess(currentUser(r), subscriber.OwnerID) {
+		return notify.Subscriber{}, storage.ErrNotFound
+	}
+	return subscriber, nil
+}
+
+func (h *Handler) renderSubscriberError(w http.ResponseWriter, r *http.Request, err error) {
+	renderer := ErrInternalServer(err)
+	if errors.Is(err, storage.ErrNotFound) {
+		renderer = ErrNotFound(errors.New("subscriber not found"))
+	} else {
+		h.logger.Printf("Error handling subscriber request: %v", err)
+	}
+
+	if err := render.Render(w, r, renderer); err != nil {
+		h.logger.Printf("Error rendering response: %v", err)
+	}
+}
@@ -0,0 +1,410 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ayagmar/gojobscraper/internal/auth"
+	"github.com/ayagmar/gojobscraper/internal/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+// UserRequest creates an API user
+// @Description API user
+type UserRequest struct {
+	Name string `json:"name"`
+	// Role defaults to user.
+	Role auth.Role `json:"role,omitempty"`
+}
+
+func (req *UserRequest) Bind(r *http.Request) error {
+	if req.Name == "" {
+		return errors.New("missing name")
+	}
+	if req.Role == "" {
+		req.Role = auth.RoleUser
+	}
+	if !auth.ValidRole(req.Role) {
+		return errors.New("invalid role. Must be 'user' or 'admin'")
+	}
+	return nil
+}
+
+// APIKeyRequest creates an API key
+// @Description API key
+type APIKeyRequest struct {
+	Name string `json:"name"`
+	// ExpiresAt is when the key stops working. Omit it for a key that does
+	// not expire.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+func (req *APIKeyRequest) Bind(r *http.Request) error {
+	if req.Name == "" {
+		return errors.New("missing name")
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return errors.New("invalid expires_at. Must be in the future")
+	}
+	return nil
+}
+
+// APIKeyCreatedResponse carries a new API key
+// @Description New API key. The key itself is only returned once.
+type APIKeyCreatedResponse struct {
+	APIKey auth.APIKey `json:"api_key"`
+	Key    string      `json:"key"`
+}
+
+// UserCreatedResponse carries a new user and its first API key
+// @Description New API user. The key itself is only returned once.
+type UserCreatedResponse struct {
+	User   auth.User   `json:"user"`
+	APIKey auth.APIKey `json:"api_key"`
+	Key    string      `json:"key"`
+}
+
+// GetMe handles GET requests for the authenticated user.
+// @Summary Get current user
+// @Description Get the user owning the API key of the request
+// @Tags users
+// @Produce json
+// @Success 200 {object} auth.User
+// @Security ApiKeyAuth
+// @Router /me [get]
+func (h *Handler) GetMe(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, r, currentUser(r))
+}
+
+// CreateUser handles POST requests creating an API user.
+// @Summary Create user
+// @Description Create an API user along with its first API key. Requires the admin role.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user body UserRequest true "User"
+// @Success 201 {object} UserCreatedResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /users [post]
+func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	var req UserRequest
+	if err := render.Bind(r, &req); err != nil {
+		if err := render.Render(w, r, ErrInvalidRequest(err)); err != nil {
+			h.logger.Printf("Error rendering response: %v", err)
+		}
+		return
+	}
+
+	user := auth.User{
+		ID:        uuid.New().String(),
+		Name:      req.Name,
+		Role:      req.Role,
+		CreatedAt: time.Now(),
+	}
+	if err := h.storage.SaveUser(user); err != nil {
+		h.renderUserError(w, r, err)
+		return
+	}
+
+	key, plaintext, err := h.issueAPIKey(user.ID, "default", nil)
+	if err != nil {
+		h.renderUserError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, UserCreatedResponse{User: user, APIKey: key, Key: plaintext})
+}
+
+// GetUsers handles GET requests for retrieving API users.
+// @Summary Get users
+// @Description Get the API users. Requires the admin role.
+// @Tags users
+// @Produce json
+// @Success 200 {array} auth.User
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /users [get]
+func (h *Handler) GetUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := h.storage.GetUsers()
+	if err != nil {
+		h.renderUserError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, users)
+}
+
+// GetUser handles GET requests for retrieving a single API user.
+// @Summary Get user
+// @Description Get an API user. Requires the admin role.
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} auth.User
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /users/{id} [get]
+func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
+	user, err := h.storage.GetUser(chi.URLParam(r, "id"))
+	if err != nil {
+		h.renderUserError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, user)
+}
+
+// DeleteUser handles DELETE requests removing an API user.
+// @Summary Delete user
+// @Description Remove an API user, revoke its API keys and delete its subscribers and saved searches, along with their feed tokens. The applications of the user are kept. Requires the admin role.
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /users/{id} [delete]
+func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == currentUser(r).ID {
+		if err := render.Render(w, r, ErrInvalidRequest(errors.New("cannot delete your own user"))); err != nil {
+			h.logger.Printf("Error rendering response: %v", err)
+		}
+		return
+	}
+
+	if err := h.storage.DeleteUser(id); err != nil {
+		h.renderUserError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, SuccessResponse{Message: "User deleted"})
+}
+
+// GetAPIKeys handles GET requests for the API keys of a user.
+// @Summary Get API keys
+// @Description Get the API keys of a user, including revoked and expired ones. Users may list their own keys, admins the keys of every user.
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {array} auth.APIKey
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /users/{id}/keys [get]
+func (h *Handler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authorizeKeyOwner(w, r)
+	if !ok {
+		return
+	}
+
+	keys, err := h.storage.GetAPIKeys(userID)
+	if err != nil {
+		h.renderAPIKeyError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, keys)
+}
+
+// CreateAPIKey handles POST requests creating an API key.
+// @Summary Create API key
+// @Description Create an API key for a user. The key is only returned in this response. Users may create their own keys, admins keys for every user.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param key body APIKeyRequest true "API key"
+// @Success 201 {object} APIKeyCreatedResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /users/{id}/keys [post]
+func (h *Handler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authorizeKeyOwner(w, r)
+	if !ok {
+		return
+	}
+
+	var req APIKeyRequest
+	if err := render.Bind(r, &req); err != nil {
+		if err := render.Render(w, r, ErrInvalidRequest(err)); err != nil {
+			h.logger.Printf("Error rendering response: %v", err)
+		}
+		return
+	}
+
+	if _, err := h.storage.GetUser(userID); err != nil {
+		h.renderUserError(w, r, err)
+		return
+	}
+
+	key, plaintext, err := h.issueAPIKey(userID, req.Name, req.ExpiresAt)
+	if err != nil {
+		h.renderAPIKeyError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, APIKeyCreatedResponse{APIKey: key, Key: plaintext})
+}
+
+// RevokeAPIKey handles DELETE requests revoking an API key.
+// @Summary Revoke API key
+// @Description Revoke an API key. It stops working immediately and stays listed as revoked.
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID"
+// @Param keyID path string true "API key ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /users/{id}/keys/{keyID} [delete]
+func (h *Handler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	key, ok := h.userAPIKey(w, r)
+	if !ok {
+		return
+	}
+
+	if key.RevokedAt == nil {
+		now := time.Now()
+		key.RevokedAt = &now
+		if err := h.storage.SaveAPIKey(key); err != nil {
+			h.renderAPIKeyError(w, r, err)
+			return
+		}
+	}
+
+	render.JSON(w, r, SuccessResponse{Message: "API key revoked"})
+}
+
+// RotateAPIKey handles POST requests replacing an API key.
+// @Summary Rotate API key
+// @Description Create a new API key with the name and expiry of an existing one and revoke the existing key. The new key is only returned in this response.
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID"
+// @Param keyID path string true "API key ID"
+// @Success 201 {object} APIKeyCreatedResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /users/{id}/keys/{keyID}/rotate [post]
+func (h *Handler) RotateAPIKey(w http.ResponseWriter, r *http.Request) {
+	old, ok := h.userAPIKey(w, r)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	if !old.Active(now) {
+		if err := render.Render(w, r, ErrInvalidRequest(errors.New("API key is revoked or expired"))); err != nil {
+			h.logger.Printf("Error rendering response: %v", err)
+		}
+		return
+	}
+
+	key, plaintext, err := h.issueAPIKey(old.UserID, old.Name, old.ExpiresAt)
+	if err != nil {
+		h.renderAPIKeyError(w, r, err)
+		return
+	}
+
+	old.RevokedAt = &now
+	if err := h.storage.SaveAPIKey(old); err != nil {
+		h.renderAPIKeyError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, APIKeyCreatedResponse{APIKey: key, Key: plaintext})
+}
+
+func (h *Handler) issueAPIKey(userID, name string, expiresAt *time.Time) (auth.APIKey, string, error) {
+	key, plaintext, err := auth.NewAPIKey(userID, name, expiresAt)
+	if err != nil {
+		return auth.APIKey{}, "", err
+	}
+	if err := h.storage.SaveAPIKey(key); err != nil {
+		return auth.APIKey{}, "", err
+	}
+	return key, plaintext, nil
+}
+
+// authorizeKeyOwner returns the user ID of the request path when the
+// current user may manage its keys, and renders a 403 response otherwise.
+func (h *Handler) authorizeKeyOwner(w http.ResponseWriter, r *http.Request) (string, bool) {
+	userID := chi.URLParam(r, "id")
+	if user := currentUser(r); user.ID != userID && !user.IsAdmin() {
+		if err := render.Render(w, r, ErrForbidden(errors.New("cannot manage the API keys of another user"))); err != nil {
+			h.logger.Printf("Error rendering response: %v", err)
+		}
+		return "", false
+	}
+	return userID, true
+}
+
+// userAPIKey returns the API key named by the request path, rendering an
+// error response when it does not exist, belongs to another user than the
+// path names, or the current user may not manage it.
+func (h *Handler) userAPIKey(w http.ResponseWriter, r *http.Request) (auth.APIKey, bool) {
+	userID, ok := h.authorizeKeyOwner(w, r)
+	if !ok {
+		return auth.APIKey{}, false
+	}
+
+	key, err := h.storage.GetAPIKey(chi.URLParam(r, "keyID"))
+	if err == nil && key.UserID != userID {
+		err = storage.ErrNotFound
+	}
+	if err != nil {
+		h.renderAPIKeyError(w, r, err)
+		return auth.APIKey{}, false
+	}
+	return key, true
+}
+
+func (h *Handler) renderUserError(w http.ResponseWriter, r *http.Request, err error) {
+	renderer := ErrInternalServer(err)
+	if errors.Is(err, storage.ErrNotFound) {
+		renderer = ErrNotFound(errors.New("user not found"))
+	} else {
+		h.logger.Printf("Error handling user request: %v", err)
+	}
+
+	if err := render.Render(w, r, renderer); err != nil {
+		h.logger.Printf("Error rendering response: %v", err)
+	}
+}
+
+func (h *Handler) renderAPIKeyError(w http.ResponseWriter, r *http.Request, err error) {
+	renderer := ErrInternalServer(err)
+	if errors.Is(err, storage.ErrNotFound) {
+		renderer = ErrNotFound(errors.New("API key not found"))
+	} else {
+		h.logger.Printf("Error handling API key request: %v", err)
+	}
+
+	if err := render.Render(w, r, renderer); err != nil {
+		h.logger.Printf("Error rendering response: %v", err)
+	}
+}
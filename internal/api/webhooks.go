@@ -0,0 +1,254 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ayagmar/gojobscraper/internal/filter"
+	"github.com/ayagmar/gojobscraper/internal/storage"
+	"github.com/ayagmar/gojobscraper/internal/webhook"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+// WebhookRequest registers a webhook
+// @Description Webhook registration
+type WebhookRequest struct {
+	URL string `json:"url"`
+	// Secret signs the deliveries. A random secret is generated when empty.
+	Secret string              `json:"secret,omitempty"`
+	Events []webhook.EventType `json:"events"`
+	// Filter selects the jobs sent in jobs.new deliveries.
+	Filter filter.JobFilter `json:"filter"`
+}
+
+func (req *WebhookRequest) Bind(r *http.Request) error {
+	target, err := url.Parse(req.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return errors.New("invalid url. Must be an absolute http or https URL")
+	}
+	if len(req.Events) == 0 {
+		return errors.New("missing events")
+	}
+	for _, event := range req.Events {
+		if !webhook.ValidEventType(event) {
+			return fmt.Errorf("invalid event %q. Must be one of run.finished, run.failed or jobs.new", event)
+		}
+	}
+	if req.Filter.Source != "" && !isValidScraperType(req.Filter.Source) {
+		return errors.New("invalid filter source. Must be 'indeed' or 'linkedin'")
+	}
+	return nil
+}
+
+// WebhookCreatedResponse is the webhook created along with its secret
+// @Description Created webhook and the secret its deliveries are signed with
+type WebhookCreatedResponse struct {
+	webhook.Webhook
+	Secret string `json:"secret"`
+}
+
+// CreateWebhook handles POST requests registering a webhook.
+// @Summary Create webhook
+// @Description Register an endpoint receiving signed JSON POSTs for run.finished, run.failed and jobs.new events. Each request carries X-Webhook-Event, X-Webhook-Delivery, X-Webhook-Timestamp and X-Webhook-Signature headers; the signature is "sha256=" followed by the hex HMAC-SHA256 of the timestamp, a dot and the body, keyed by the secret. The secret is only returned by this endpoint. Requires the admin role.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param webhook body WebhookRequest true "Webhook"
+// @Success 201 {object} WebhookCreatedResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /webhooks [post]
+func (h *Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req WebhookRequest
+	if err := render.Bind(r, &req); err != nil {
+		if err := render.Render(w, r, ErrInvalidRequest(err)); err != nil {
+			h.logger.Printf("Error rendering response: %v", err)
+		}
+		return
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		var err error
+		if secret, err = newWebhookSecret(); err != nil {
+			h.renderWebhookError(w, r, err)
+			return
+		}
+	}
+
+	hook := webhook.Webhook{
+		ID:        uuid.New().String(),
+		URL:       req.URL,
+		Secret:    secret,
+		Events:    req.Events,
+		Filter:    req.Filter,
+		CreatedAt: time.Now(),
+	}
+	if err := h.storage.SaveWebhook(hook); err != nil {
+		h.renderWebhookError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, WebhookCreatedResponse{Webhook: hook, Secret: secret})
+}
+
+// GetWebhooks handles GET requests for retrieving webhooks.
+// @Summary Get webhooks
+// @Description Get the registered webhooks. Requires the admin role.
+// @Tags webhooks
+// @Produce json
+// @Success 200 {array} webhook.Webhook
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /webhooks [get]
+func (h *Handler) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	hooks, err := h.storage.GetWebhooks()
+	if err != nil {
+		h.renderWebhookError(w, r, err)
+		return
+	}
+	if hooks == nil {
+		hooks = []webhook.Webhook{}
+	}
+
+	render.JSON(w, r, hooks)
+}
+
+// GetWebhook handles GET requests for retrieving a single webhook.
+// @Summary Get webhook
+// @Description Get a registered webhook. Requires the admin role.
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} webhook.Webhook
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /webhooks/{id} [get]
+func (h *Handler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	hook, err := h.storage.GetWebhook(chi.URLParam(r, "id"))
+	if err != nil {
+		h.renderWebhookError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, hook)
+}
+
+// DeleteWebhook handles DELETE requests removing a webhook.
+// @Summary Delete webhook
+// @Description Remove a webhook. Its pending deliveries are dropped; its delivery log is kept. Requires the admin role.
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /webhooks/{id} [delete]
+func (h *Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	if err := h.storage.DeleteWebhook(chi.URLParam(r, "id")); err != nil {
+		h.renderWebhookError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, SuccessResponse{Message: "Webhook deleted"})
+}
+
+// GetWebhookDeliveries handles GET requests for the delivery log of a webhook.
+// @Summary Get webhook deliveries
+// @Description Get the 100 most recent deliveries of a webhook with their attempts, newest first. Requires the admin role.
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Success 200 {array} webhook.Delivery
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /webhooks/{id}/deliveries [get]
+func (h *Handler) GetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := h.storage.GetWebhook(id); err != nil {
+		h.renderWebhookError(w, r, err)
+		return
+	}
+
+	deliveries, err := h.storage.GetDeliveries(id)
+	if err != nil {
+		h.renderWebhookError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, deliveries)
+}
+
+// RedeliverWebhook handles POST requests sending a past delivery again.
+// @Summary Redeliver webhook delivery
+// @Description Send the payload of a past delivery again, as a new delivery with its own attempts. Requires the admin role.
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param deliveryID path string true "Delivery ID"
+// @Success 202 {object} webhook.Delivery
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /webhooks/{id}/deliveries/{deliveryID}/redeliver [post]
+func (h *Handler) RedeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	deliveryID := chi.URLParam(r, "deliveryID")
+
+	original, err := h.storage.GetDelivery(deliveryID)
+	if err == nil && original.WebhookID != id {
+		err = storage.ErrNotFound
+	}
+	if err != nil {
+		h.renderWebhookError(w, r, err)
+		return
+	}
+
+	delivery, err := h.webhooks.Redeliver(deliveryID)
+	if err != nil {
+		h.renderWebhookError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusAccepted)
+	render.JSON(w, r, delivery)
+}
+
+func (h *Handler) renderWebhookError(w http.ResponseWriter, r *http.Request, err error) {
+	renderer := ErrInternalServer(err)
+	if errors.Is(err, storage.ErrNotFound) {
+		renderer = ErrNotFound(errors.New("webhook or delivery not found"))
+	} else {
+		h.logger.Printf("Error handling webhook request: %v", err)
+	}
+
+	if err := render.Render(w, r, renderer); err != nil {
+		h.logger.Printf("Error rendering response: %v", err)
+	}
+}
+
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return hex.EncodeToString(secret), nil
+}
@@ -0,0 +1,168 @@
+// Package auth identifies API users by their API keys. Keys are only stored
+// as SHA-256 hashes; the plaintext is shown once, when a key is created.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// keyPrefix starts every API key so leaked keys are easy to recognize.
+const keyPrefix = "jsk_"
+
+// touchInterval is how stale the last use of a key may get before it is
+// recorded again, so authenticating does not write on every request.
+const touchInterval = time.Minute
+
+// ErrInvalidKey is returned for unknown, revoked and expired API keys.
+var ErrInvalidKey = errors.New("invalid, revoked or expired API key")
+
+// Role decides what a user may do
+// @Description User role
+type Role string
+
+const (
+	// RoleUser manages its own searches, subscriptions and applications.
+	RoleUser Role = "user"
+	// RoleAdmin also manages users and shared settings and may perform
+	// destructive operations such as clearing jobs.
+	RoleAdmin Role = "admin"
+)
+
+// ValidRole reports whether r is a known role.
+func ValidRole(r Role) bool {
+	return r == RoleUser || r == RoleAdmin
+}
+
+// User is an account of the API
+// @Description API user
+type User struct {
+	ID        string    `json:"id" bson:"id"`
+	Name      string    `json:"name" bson:"name"`
+	Role      Role      `json:"role" bson:"role"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+// IsAdmin reports whether the user has the admin role.
+func (u User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
+// APIKey authenticates the requests of a user
+// @Description API key, without its secret
+type APIKey struct {
+	ID     string `json:"id" bson:"id"`
+	UserID string `json:"user_id" bson:"user_id"`
+	Name   string `json:"name" bson:"name"`
+	// Prefix is the start of the key, to tell keys apart.
+	Prefix     string     `json:"prefix" bson:"prefix"`
+	Hash       string     `json:"-" bson:"hash"`
+	CreatedAt  time.Time  `json:"created_at" bson:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" bson:"last_used_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" bson:"expires_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" bson:"revoked_at,omitempty"`
+}
+
+// Active reports whether the key can authenticate at now.
+func (k APIKey) Active(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+// NewAPIKey generates a key for a user. It returns the key record to store
+// and the plaintext key to hand to the user.
+func NewAPIKey(userID, name string, expiresAt *time.Time) (APIKey, string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return APIKey{}, "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	plaintext := keyPrefix + hex.EncodeToString(secret)
+
+	return APIKey{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Name:      name,
+		Prefix:    plaintext[:len(keyPrefix)+8],
+		Hash:      HashKey(plaintext),
+		CreatedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	}, plaintext, nil
+}
+
+// HashKey returns the hash API keys are stored and looked up by. Keys are
+// random, so a fast hash is as safe as a slow one.
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Store provides the users and API keys requests are authenticated with.
+type Store interface {
+	GetUser(id string) (User, error)
+	// LookupAPIKey returns the key with the given hash, and whether it
+	// exists.
+	LookupAPIKey(hash string) (APIKey, bool, error)
+	// TouchAPIKey records that a key was used at the given time.
+	TouchAPIKey(id string, at time.Time) error
+}
+
+// Authenticate returns the user owning key. It returns ErrInvalidKey when
+// the key is unknown or no longer active.
+func Authenticate(store Store, key string, now time.Time) (User, APIKey, error) {
+	apiKey, ok, err := store.LookupAPIKey(HashKey(key))
+	if err != nil {
+		return User{}, APIKey{}, err
+	}
+	if !ok || !apiKey.Active(now) {
+		return User{}, APIKey{}, ErrInvalidKey
+	}
+
+	user, err := store.GetUser(apiKey.UserID)
+	if err != nil {
+		return User{}, APIKey{}, fmt.Errorf("failed to load user of API key %s: %w", apiKey.ID, err)
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > touchInterval {
+		// Failing to record the use must not fail the request.
+		if err := store.TouchAPIKey(apiKey.ID, now); err == nil {
+			apiKey.LastUsedAt = &now
+		}
+	}
+	return user, apiKey, nil
+}
+
+type contextKey struct{}
+
+type apiKeyContextKey struct{}
+
+// WithUser returns a copy of ctx carrying the authenticated user.
+func WithUser(ctx context.Context, user User) context.Context {
+	return context.WithValue(ctx, contextKey{}, user)
+}
+
+// UserFromContext returns the authenticated user carried by ctx.
+func UserFromContext(ctx context.Context) (User, bool) {
+	user, ok := ctx.Value(contextKey{}).(User)
+	return user, ok
+}
+
+// WithAPIKey returns a copy of ctx carrying the API key a request was
+// authenticated with.
+func WithAPIKey(ctx context.Context, key APIKey) context.Context {
+	return context.WithValue(ctx, apiKeyContextKey{}, key)
+}
+
+// APIKeyFromContext returns the API key carried by ctx.
+func APIKeyFromContext(ctx context.Context) (APIKey, bool) {
+	key, ok := ctx.Value(apiKeyContextKey{}).(APIKey)
+	return key, ok
+}
@@ -0,0 +1,253 @@
+// Package blocklist keeps unwanted postings, such as those of recruiting
+// agencies or spammy reposts, out of storage or out of sight.
+package blocklist
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ayagmar/gojobscraper/internal/scraper"
+)
+
+// Kind is what a blocklist rule matches
+// @Description Blocklist rule kind
+type Kind string
+
+const (
+	// KindCompany matches the company name, ignoring case and surrounding
+	// spaces.
+	KindCompany Kind = "company"
+	// KindCompanyRegex matches the company name against a regular
+	// expression.
+	KindCompanyRegex Kind = "company_regex"
+	// KindTitleKeyword matches titles containing the keyword, ignoring case.
+	KindTitleKeyword Kind = "title_keyword"
+	// KindDomain matches company URLs on the domain or its subdomains.
+	KindDomain Kind = "domain"
+)
+
+// Action is what happens to a blocked posting
+// @Description Blocklist rule action
+type Action string
+
+const (
+	// ActionDrop discards the posting before it is stored.
+	ActionDrop Action = "drop"
+	// ActionHide stores the posting flagged as hidden, with the reason.
+	ActionHide Action = "hide"
+)
+
+// Rule blocks the postings matching its pattern
+// @Description Blocklist rule
+type Rule struct {
+	ID      string `json:"id" bson:"id"`
+	Kind    Kind   `json:"kind" bson:"kind"`
+	Pattern string `json:"pattern" bson:"pattern"`
+	Action  Action `json:"action" bson:"action"`
+	// Reason is recorded on the postings the rule hides.
+	Reason    string    `json:"reason,omitempty" bson:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+// Validate reports whether the rule can be applied.
+func (r Rule) Validate() error {
+	if strings.TrimSpace(r.Pattern) == "" {
+		return errors.New("missing pattern")
+	}
+	switch r.Kind {
+	case KindCompany, KindTitleKeyword, KindDomain:
+	case KindCompanyRegex:
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+	default:
+		return fmt.Errorf("invalid kind %q. Must be company, company_regex, title_keyword or domain", r.Kind)
+	}
+	if r.Action != ActionDrop && r.Action != ActionHide {
+		return fmt.Errorf("invalid action %q. Must be drop or hide", r.Action)
+	}
+	return nil
+}
+
+// reason describes why the rule blocked a posting.
+func (r Rule) reason() string {
+	if r.Reason != "" {
+		return r.Reason
+	}
+	return fmt.Sprintf("blocked by %s %q", strings.ReplaceAll(string(r.Kind), "_", " "), r.Pattern)
+}
+
+// Store provides the blocklist rules.
+type Store interface {
+	GetBlockRules() ([]Rule, error)
+}
+
+// compiledRule is a rule with its pattern prepared for matching.
+type compiledRule struct {
+	Rule
+	pattern string
+	regex   *regexp.Regexp
+}
+
+func compile(rule Rule) (compiledRule, error) {
+	compiled := compiledRule{Rule: rule, pattern: strings.ToLower(strings.TrimSpace(rule.Pattern))}
+	if rule.Kind == KindCompanyRegex {
+		regex, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return compiledRule{}, err
+		}
+		compiled.regex = regex
+	}
+	if rule.Kind == KindDomain {
+		compiled.pattern = strings.TrimPrefix(compiled.pattern, "www.")
+	}
+	return compiled, nil
+}
+
+func (r compiledRule) match(job scraper.JobPosting) bool {
+	company := job.CompanyDetails.Company
+	switch r.Kind {
+	case KindCompany:
+		return company != "" && strings.EqualFold(strings.TrimSpace(company), r.pattern)
+	case KindCompanyRegex:
+		return company != "" && r.regex.MatchString(company)
+	case KindTitleKeyword:
+		return strings.Contains(strings.ToLower(job.Title), r.pattern)
+	case KindDomain:
+		host := companyHost(job.CompanyDetails.CompanyURL)
+		return host != "" && (host == r.pattern || strings.HasSuffix(host, "."+r.pattern))
+	}
+	return false
+}
+
+// companyHost returns the lowercased host of a company URL, which may lack a
+// scheme.
+func companyHost(companyURL string) string {
+	if companyURL == "" {
+		return ""
+	}
+	if !strings.Contains(companyURL, "://") {
+		companyURL = "http://" + companyURL
+	}
+	parsed, err := url.Parse(companyURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+}
+
+// Blocklist applies the stored rules to scraped postings. Rules are loaded
+// once and kept until Reload is called.
+type Blocklist struct {
+	store  Store
+	logger *log.Logger
+
+	mu     sync.RWMutex
+	rules  []compiledRule
+	loaded bool
+	// generation counts the calls to Reload, so that rules read from the
+	// store before a Reload are not kept as loaded after it.
+	generation uint64
+}
+
+// New creates a blocklist applying the rules of store.
+func New(store Store, logger *log.Logger) *Blocklist {
+	return &Blocklist{store: store, logger: logger}
+}
+
+// Reload drops the loaded rules so the next Apply reads them again. It is
+// called whenever the rules change.
+func (b *Blocklist) Reload() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rules = nil
+	b.loaded = false
+	b.generation++
+}
+
+// Apply removes the postings blocked by a drop rule and flags the ones
+// blocked by a hide rule as hidden. Drop rules take precedence. It returns
+// the postings to store and how many were dropped.
+func (b *Blocklist) Apply(jobs []scraper.JobPosting) ([]scraper.JobPosting, int) {
+	rules, err := b.load()
+	if err != nil {
+		// Blocklists filter noise; failing to load them must not lose jobs.
+		b.logger.Printf("Error loading blocklist, storing jobs unfiltered: %v", err)
+		return jobs, 0
+	}
+	if len(rules) == 0 {
+		return jobs, 0
+	}
+
+	kept := make([]scraper.JobPosting, 0, len(jobs))
+	dropped := 0
+	for _, job := range jobs {
+		var hide *compiledRule
+		drop := false
+		for i := range rules {
+			if !rules[i].match(job) {
+				continue
+			}
+			if rules[i].Action == ActionDrop {
+				drop = true
+				break
+			}
+			if hide == nil {
+				hide = &rules[i]
+			}
+		}
+
+		switch {
+		case drop:
+			dropped++
+		case hide != nil:
+			job.Hidden = true
+			job.HiddenReason = hide.reason()
+			kept = append(kept, job)
+		default:
+			kept = append(kept, job)
+		}
+	}
+	return kept, dropped
+}
+
+// load returns the loaded rules, reading them from the store if needed. Rules
+// read while Reload is called are used once but not kept.
+func (b *Blocklist) load() ([]compiledRule, error) {
+	b.mu.RLock()
+	if b.loaded {
+		defer b.mu.RUnlock()
+		return b.rules, nil
+	}
+	generation := b.generation
+	b.mu.RUnlock()
+
+	rules, err := b.store.GetBlockRules()
+	if err != nil {
+		return nil, err
+	}
+
+	compiled := make([]compiledRule, 0, len(rules))
+	for _, rule := range rules {
+		c, err := compile(rule)
+		if err != nil {
+			b.logger.Printf("Skipping invalid blocklist rule %s: %v", rule.ID, err)
+			continue
+		}
+		compiled = append(compiled, c)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.generation == generation {
+		b.rules = compiled
+		b.loaded = true
+	}
+	return compiled, nil
+}
@@ -0,0 +1,207 @@
+package blocklist
+
+import (
+	"errors"
+	"io"
+	"log"
+	"sync"
+	"testing"
+
+	"github.com/ayagmar/gojobscraper/internal/scraper"
+)
+
+// memStore serves rules from memory. While block is set, GetBlockRules
+// signals fetching and waits for block to be closed.
+type memStore struct {
+	mu       sync.Mutex
+	rules    []Rule
+	err      error
+	reads    int
+	block    chan struct{}
+	fetching chan struct{}
+}
+
+func (s *memStore) GetBlockRules() ([]Rule, error) {
+	s.mu.Lock()
+	s.reads++
+	rules, err, block := append([]Rule(nil), s.rules...), s.err, s.block
+	s.mu.Unlock()
+
+	if block != nil {
+		s.fetching <- struct{}{}
+		<-block
+	}
+	return rules, err
+}
+
+func (s *memStore) setRules(rules ...Rule) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rules = rules
+}
+
+func newTestBlocklist(store Store) *Blocklist {
+	return New(store, log.New(io.Discard, "", 0))
+}
+
+func posting(id, company, title, companyURL string) scraper.JobPosting {
+	job := scraper.JobPosting{ID: id, Title: title}
+	job.CompanyDetails.Company = company
+	job.CompanyDetails.CompanyURL = companyURL
+	return job
+}
+
+func TestCompanyHost(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://www.Acme.com/careers", "acme.com"},
+		{"http://jobs.acme.com", "jobs.acme.com"},
+		{"acme.com/about", "acme.com"},
+		{"www.acme.co.uk", "acme.co.uk"},
+		{"https://acme.com:8443/", "acme.com"},
+		{"", ""},
+		{"http://%zz", ""},
+	}
+	for _, tt := range tests {
+		if got := companyHost(tt.url); got != tt.want {
+			t.Errorf("companyHost(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestRuleMatch(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+		job  scraper.JobPosting
+		want bool
+	}{
+		{"company", Rule{Kind: KindCompany, Pattern: " Acme Staffing "}, posting("1", "acme staffing", "", ""), true},
+		{"company is exact", Rule{Kind: KindCompany, Pattern: "Acme"}, posting("1", "Acme Staffing", "", ""), false},
+		{"company regex", Rule{Kind: KindCompanyRegex, Pattern: `(?i)recruit(ing|ment)`}, posting("1", "Top Recruitment Ltd", "", ""), true},
+		{"company regex without company", Rule{Kind: KindCompanyRegex, Pattern: `.*`}, posting("1", "", "", ""), false},
+		{"title keyword", Rule{Kind: KindTitleKeyword, Pattern: "Commission Only"}, posting("1", "", "Sales Rep - COMMISSION ONLY", ""), true},
+		{"domain", Rule{Kind: KindDomain, Pattern: "www.spam.example"}, posting("1", "", "", "https://spam.example/jobs"), true},
+		{"subdomain", Rule{Kind: KindDomain, Pattern: "spam.example"}, posting("1", "", "", "careers.spam.example"), true},
+		{"domain suffix only", Rule{Kind: KindDomain, Pattern: "spam.example"}, posting("1", "", "", "https://notspam.example"), false},
+		{"domain without URL", Rule{Kind: KindDomain, Pattern: "spam.example"}, posting("1", "", "", ""), false},
+	}
+	for _, tt := range tests {
+		compiled, err := compile(tt.rule)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := compiled.match(tt.job); got != tt.want {
+			t.Errorf("%s: match = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	store := &memStore{rules: []Rule{
+		{ID: "hide", Kind: KindTitleKeyword, Pattern: "unpaid", Action: ActionHide},
+		{ID: "hide-reason", Kind: KindCompany, Pattern: "Globex", Action: ActionHide, Reason: "agency"},
+		{ID: "drop", Kind: KindCompany, Pattern: "Acme Staffing", Action: ActionDrop},
+		{ID: "invalid", Kind: KindCompanyRegex, Pattern: "(", Action: ActionDrop},
+	}}
+	b := newTestBlocklist(store)
+
+	jobs := []scraper.JobPosting{
+		posting("kept", "Initech", "Engineer", ""),
+		posting("dropped", "Acme Staffing", "Engineer", ""),
+		posting("hidden", "Initech", "Unpaid Internship", ""),
+		posting("drop wins", "Acme Staffing", "Unpaid Internship", ""),
+		posting("first hide rule", "Globex", "Unpaid Internship", ""),
+		posting("reason", "Globex", "Engineer", ""),
+	}
+	kept, dropped := b.Apply(jobs)
+	if dropped != 2 || len(kept) != 4 {
+		t.Fatalf("kept %d, dropped %d, want 4 and 2", len(kept), dropped)
+	}
+
+	want := []struct {
+		id     string
+		hidden bool
+		reason string
+	}{
+		{"kept", false, ""},
+		{"hidden", true, `blocked by title keyword "unpaid"`},
+		{"first hide rule", true, `blocked by title keyword "unpaid"`},
+		{"reason", true, "agency"},
+	}
+	for i, w := range want {
+		job := kept[i]
+		if job.ID != w.id || job.Hidden != w.hidden || job.HiddenReason != w.reason {
+			t.Errorf("posting %d: %s hidden %v (%q), want %s hidden %v (%q)", i, job.ID, job.Hidden, job.HiddenReason, w.id, w.hidden, w.reason)
+		}
+	}
+
+	// Rules are read once until Reload.
+	b.Apply(jobs)
+	if store.reads != 1 {
+		t.Errorf("rules read %d times, want once", store.reads)
+	}
+	store.setRules()
+	b.Reload()
+	if kept, dropped := b.Apply(jobs); len(kept) != len(jobs) || dropped != 0 {
+		t.Errorf("after Reload: kept %d, dropped %d, want every posting kept", len(kept), dropped)
+	}
+}
+
+func TestApplyStoreError(t *testing.T) {
+	store := &memStore{err: errors.New("connection refused")}
+	b := newTestBlocklist(store)
+
+	jobs := []scraper.JobPosting{posting("1", "Acme Staffing", "Engineer", "")}
+	if kept, dropped := b.Apply(jobs); len(kept) != 1 || dropped != 0 {
+		t.Errorf("kept %d, dropped %d, want the postings kept unfiltered", len(kept), dropped)
+	}
+
+	// Failed loads are retried.
+	store.mu.Lock()
+	store.err = nil
+	store.rules = []Rule{{Kind: KindCompany, Pattern: "Acme Staffing", Action: ActionDrop}}
+	store.mu.Unlock()
+	if _, dropped := b.Apply(jobs); dropped != 1 {
+		t.Errorf("dropped %d, want the rules loaded once the store recovers", dropped)
+	}
+}
+
+// TestReloadDuringLoad checks that rules read before a Reload are not kept
+// after it.
+func TestReloadDuringLoad(t *testing.T) {
+	store := &memStore{
+		rules:    []Rule{{Kind: KindCompany, Pattern: "Acme Staffing", Action: ActionDrop}},
+		block:    make(chan struct{}),
+		fetching: make(chan struct{}),
+	}
+	b := newTestBlocklist(store)
+	jobs := []scraper.JobPosting{posting("1", "Acme Staffing", "Engineer", "")}
+
+	done := make(chan int)
+	go func() {
+		_, dropped := b.Apply(jobs)
+		done <- dropped
+	}()
+
+	// The rule is deleted while the old rules are being read.
+	<-store.fetching
+	store.mu.Lock()
+	block := store.block
+	store.rules = nil
+	store.block = nil
+	store.mu.Unlock()
+	b.Reload()
+	close(block)
+
+	// The load in progress still applies the rules it read.
+	if dropped := <-done; dropped != 1 {
+		t.Errorf("dropped %d by the load in progress, want 1", dropped)
+	}
+
+	if _, dropped := b.Apply(jobs); dropped != 0 {
+		t.Error("stale rules kept after Reload")
+	}
+}
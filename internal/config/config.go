@@ -12,7 +12,21 @@ type Config struct {
 		ReadTimeout  time.Duration `mapstructure:"read_timeout"`
 		WriteTimeout time.Duration `mapstructure:"write_timeout"`
 		IdleTimeout  time.Duration `mapstructure:"idle_timeout"`
+		// AllowedOrigins are the origins, besides the API's own, whose pages
+		// may open the job stream WebSocket.
+		AllowedOrigins []string `mapstructure:"allowed_origins"`
 	} `mapstructure:"server"`
+	RateLimit struct {
+		RequestsPerMinute float64 `mapstructure:"requests_per_minute"`
+		Burst             int     `mapstructure:"burst"`
+		// IPRequestsPerMinute and IPBurst limit the requests made without a
+		// valid API key per IP address. Zero disables that limit.
+		IPRequestsPerMinute float64 `mapstructure:"ip_requests_per_minute"`
+		IPBurst             int     `mapstructure:"ip_burst"`
+		// ScrapePagesPerDay is the number of pages a user may scrape per UTC
+		// day. Zero disables the quota.
+		ScrapePagesPerDay int `mapstructure:"scrape_pages_per_day"`
+	} `mapstructure:"rate_limit"`
 	Database struct {
 		URL         string `mapstructure:"url"`
 		Name        string `mapstructure:"name"`
@@ -21,13 +35,89 @@ type Config struct {
 	} `mapstructure:"database"`
 	Scraper struct {
 		DefaultPages int `mapstructure:"default_pages"`
+		CompanyCache struct {
+			TTL        time.Duration `mapstructure:"ttl"`
+			Persistent bool          `mapstructure:"persistent"`
+		} `mapstructure:"company_cache"`
+		Politeness struct {
+			Default PolitenessConfig            `mapstructure:"default"`
+			Sources map[string]PolitenessConfig `mapstructure:"sources"`
+		} `mapstructure:"politeness"`
+		BlockBackoff struct {
+			Initial time.Duration `mapstructure:"initial"`
+			Max     time.Duration `mapstructure:"max"`
+		} `mapstructure:"block_backoff"`
+		Retry struct {
+			MaxAttempts          int           `mapstructure:"max_attempts"`
+			InitialBackoff       time.Duration `mapstructure:"initial_backoff"`
+			MaxBackoff           time.Duration `mapstructure:"max_backoff"`
+			RetryableStatusCodes []int         `mapstructure:"retryable_status_codes"`
+			Timeout              time.Duration `mapstructure:"timeout"`
+		} `mapstructure:"retry"`
+		PartialResults    bool `mapstructure:"partial_results"`
+		DetailConcurrency int  `mapstructure:"detail_concurrency"`
+		SaveBatch         struct {
+			Size          int           `mapstructure:"size"`
+			FlushInterval time.Duration `mapstructure:"flush_interval"`
+		} `mapstructure:"save_batch"`
+		Proxies struct {
+			URLs                []string      `mapstructure:"urls"`
+			File                string        `mapstructure:"file"`
+			Strategy            string        `mapstructure:"strategy"`
+			BanDuration         time.Duration `mapstructure:"ban_duration"`
+			MaxFailures         int           `mapstructure:"max_failures"`
+			HealthCheckURL      string        `mapstructure:"health_check_url"`
+			HealthCheckInterval time.Duration `mapstructure:"health_check_interval"`
+		} `mapstructure:"proxies"`
+		Robots struct {
+			DefaultPolicy string            `mapstructure:"default_policy"`
+			Sources       map[string]string `mapstructure:"sources"`
+			CacheTTL      time.Duration     `mapstructure:"cache_ttl"`
+		} `mapstructure:"robots"`
 	} `mapstructure:"scraper"`
+	Webhooks struct {
+		MaxAttempts    int           `mapstructure:"max_attempts"`
+		InitialBackoff time.Duration `mapstructure:"initial_backoff"`
+		MaxBackoff     time.Duration `mapstructure:"max_backoff"`
+		Timeout        time.Duration `mapstructure:"timeout"`
+		Workers        int           `mapstructure:"workers"`
+	} `mapstructure:"webhooks"`
+	Notifications struct {
+		// BaseURL is the public URL of the API used in unsubscribe links.
+		BaseURL       string        `mapstructure:"base_url"`
+		CheckInterval time.Duration `mapstructure:"check_interval"`
+		SMTP          struct {
+			Host     string `mapstructure:"host"`
+			Port     int    `mapstructure:"port"`
+			Username string `mapstructure:"username"`
+			Password string `mapstructure:"password"`
+			From     string `mapstructure:"from"`
+		} `mapstructure:"smtp"`
+		Chat struct {
+			BatchWindow        time.Duration `mapstructure:"batch_window"`
+			MaxMessagesPerHour int           `mapstructure:"max_messages_per_hour"`
+			MaxJobsPerMessage  int           `mapstructure:"max_jobs_per_message"`
+			MaxPending         int           `mapstructure:"max_pending"`
+			Timeout            time.Duration `mapstructure:"timeout"`
+		} `mapstructure:"chat"`
+	} `mapstructure:"notifications"`
+	Dedup struct {
+		TitleThreshold       float64 `mapstructure:"title_threshold"`
+		DescriptionThreshold float64 `mapstructure:"description_threshold"`
+	} `mapstructure:"dedup"`
 	Log struct {
 		Level  string `mapstructure:"level"`
 		Format string `mapstructure:"format"`
 	} `mapstructure:"log"`
 }
 
+// PolitenessConfig limits the request rate to a crawled domain.
+type PolitenessConfig struct {
+	RequestsPerSecond float64       `mapstructure:"requests_per_second"`
+	Burst             int           `mapstructure:"burst"`
+	Jitter            time.Duration `mapstructure:"jitter"`
+}
+
 func Load() (*Config, error) {
 	viper.SetConfigName("config")
 	viper.SetConfigType("yaml")
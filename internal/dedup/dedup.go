@@ -0,0 +1,254 @@
+package dedup
+
+import (
+	"sort"
+
+	"github.com/ayagmar/gojobscraper/internal/scraper"
+)
+
+// Options tunes how aggressively postings are considered duplicates.
+type Options struct {
+	// TitleThreshold is the minimum token Jaccard similarity between titles.
+	TitleThreshold float64
+	// DescriptionThreshold is the minimum estimated Jaccard similarity
+	// between description shingle sets.
+	DescriptionThreshold float64
+	// ShingleSize is the number of words per description shingle.
+	ShingleSize int
+	// NumHashes is the number of MinHash functions per signature.
+	NumHashes int
+}
+
+// DefaultOptions returns the options used when none are configured.
+func DefaultOptions() Options {
+	return Options{
+		TitleThreshold:       0.8,
+		DescriptionThreshold: 0.7,
+		ShingleSize:          3,
+		NumHashes:            128,
+	}
+}
+
+// Detector clusters job postings that describe the same role, whether it was
+// posted on several boards or reposted on one board under a new ID.
+type Detector struct {
+	opts   Options
+	hasher *minHasher
+}
+
+// NewDetector creates a Detector, filling unset options with defaults.
+func NewDetector(opts Options) *Detector {
+	defaults := DefaultOptions()
+	if opts.TitleThreshold <= 0 {
+		opts.TitleThreshold = defaults.TitleThreshold
+	}
+	if opts.DescriptionThreshold <= 0 {
+		opts.DescriptionThreshold = defaults.DescriptionThreshold
+	}
+	if opts.ShingleSize <= 0 {
+		opts.ShingleSize = defaults.ShingleSize
+	}
+	if opts.NumHashes <= 0 {
+		opts.NumHashes = defaults.NumHashes
+	}
+
+	return &Detector{opts: opts, hasher: newMinHasher(opts.ShingleSize, opts.NumHashes)}
+}
+
+// fingerprint holds the normalized fields of a posting used for comparison.
+type fingerprint struct {
+	company     string
+	title       map[string]struct{}
+	titleKey    string
+	location    string
+	description signature
+}
+
+func (d *Detector) fingerprint(job scraper.JobPosting) fingerprint {
+	titleKey := NormalizeTitle(job.Title)
+	return fingerprint{
+		company:     NormalizeCompany(job.CompanyDetails.Company),
+		title:       tokenSet(titleKey),
+		titleKey:    titleKey,
+		location:    NormalizeLocation(job.Location),
+		description: d.hasher.Sign(job.Description),
+	}
+}
+
+// isDuplicate reports whether two fingerprints describe the same role.
+// Postings without a company name are never matched, since title and
+// location alone are too weak a signal.
+func (d *Detector) isDuplicate(a, b fingerprint) bool {
+	if a.company == "" || a.company != b.company {
+		return false
+	}
+	if !locationsCompatible(a.location, b.location) {
+		return false
+	}
+
+	titleSimilarity := jaccard(a.title, b.title)
+	if titleSimilarity < d.opts.TitleThreshold {
+		return false
+	}
+
+	// Without both descriptions, only an identical title is convincing.
+	if a.description == nil || b.description == nil {
+		return a.titleKey == b.titleKey
+	}
+
+	return a.description.similarity(b.description) >= d.opts.DescriptionThreshold
+}
+
+// Cluster groups jobs into sets of duplicates. Each cluster is ordered with
+// its canonical posting first; clusters keep the order of their canonical
+// postings in jobs.
+func (d *Detector) Cluster(jobs []scraper.JobPosting) [][]scraper.JobPosting {
+	prints := make([]fingerprint, len(jobs))
+	for i, job := range jobs {
+		prints[i] = d.fingerprint(job)
+	}
+
+	sets := newDisjointSet(len(jobs))
+	for _, pair := range candidatePairs(prints) {
+		if d.isDuplicate(prints[pair[0]], prints[pair[1]]) {
+			sets.union(pair[0], pair[1])
+		}
+	}
+
+	members := make(map[int][]int)
+	for i := range jobs {
+		root := sets.find(i)
+		members[root] = append(members[root], i)
+	}
+
+	clusters := make([][]scraper.JobPosting, 0, len(members))
+	for _, indexes := range members {
+		cluster := make([]scraper.JobPosting, len(indexes))
+		for k, i := range indexes {
+			cluster[k] = jobs[i]
+		}
+		sort.SliceStable(cluster, func(x, y int) bool {
+			return isMoreCanonical(cluster[x], cluster[y])
+		})
+		clusters = append(clusters, cluster)
+	}
+
+	position := make(map[string]int, len(jobs))
+	for i, job := range jobs {
+		position[job.ID] = i
+	}
+	sort.SliceStable(clusters, func(x, y int) bool {
+		return position[clusters[x][0].ID] < position[clusters[y][0].ID]
+	})
+
+	return clusters
+}
+
+// bucket groups postings of a company that may be duplicates: either the
+// postings sharing a normalized title, or the postings whose description
+// signatures agree on one LSH band.
+type bucket struct {
+	company string
+	title   string
+	band    int
+	hash    uint64
+}
+
+// candidatePairs returns the pairs of postings worth comparing, so that
+// clustering does not compare every two postings of a company. Pairs with
+// identical titles are always candidates, since they are duplicates even
+// without descriptions. Otherwise postings are candidates when their
+// descriptions agree on all bandRows values of a band, which catches pairs
+// above the description threshold with high probability.
+func candidatePairs(prints []fingerprint) [][2]int {
+	buckets := make(map[bucket][]int)
+	for i, p := range prints {
+		if p.company == "" {
+			continue
+		}
+		titleBucket := bucket{company: p.company, title: p.titleKey, band: -1}
+		buckets[titleBucket] = append(buckets[titleBucket], i)
+		for band := 0; band*bandRows < len(p.description); band++ {
+			b := bucket{company: p.company, band: band, hash: p.description.bandHash(band)}
+			buckets[b] = append(buckets[b], i)
+		}
+	}
+
+	seen := make(map[[2]int]bool)
+	var pairs [][2]int
+	for _, indexes := range buckets {
+		for x := 0; x < len(indexes); x++ {
+			for y := x + 1; y < len(indexes); y++ {
+				pair := [2]int{indexes[x], indexes[y]}
+				if !seen[pair] {
+					seen[pair] = true
+					pairs = append(pairs, pair)
+				}
+			}
+		}
+	}
+	// Map iteration is random; comparing in a fixed order keeps clusters
+	// stable across requests.
+	sort.Slice(pairs, func(x, y int) bool {
+		if pairs[x][0] != pairs[y][0] {
+			return pairs[x][0] < pairs[y][0]
+		}
+		return pairs[x][1] < pairs[y][1]
+	})
+	return pairs
+}
+
+// Collapse returns one canonical posting per cluster, with the other postings
+// of the cluster linked through its Duplicates field.
+func (d *Detector) Collapse(jobs []scraper.JobPosting) []scraper.JobPosting {
+	clusters := d.Cluster(jobs)
+	canonical := make([]scraper.JobPosting, 0, len(clusters))
+	for _, cluster := range clusters {
+		job := cluster[0]
+		job.Duplicates = nil
+		for _, duplicate := range cluster[1:] {
+			job.Duplicates = append(job.Duplicates, scraper.DuplicateRef{
+				ID:            duplicate.ID,
+				PlatformJobId: duplicate.PlatformJobId,
+				Source:        duplicate.Source,
+				URL:           duplicate.URL,
+			})
+		}
+		canonical = append(canonical, job)
+	}
+	return canonical
+}
+
+// isMoreCanonical prefers the earliest posting, then the most detailed one.
+func isMoreCanonical(a, b scraper.JobPosting) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	return len(a.Description) > len(b.Description)
+}
+
+type disjointSet struct {
+	parent []int
+}
+
+func newDisjointSet(n int) *disjointSet {
+	parent := make([]int, n)
+	for i := range parent {
+		parent[i] = i
+	}
+	return &disjointSet{parent: parent}
+}
+
+func (s *disjointSet) find(i int) int {
+	for s.parent[i] != i {
+		s.parent[i] = s.parent[s.parent[i]]
+		i = s.parent[i]
+	}
+	return i
+}
+
+func (s *disjointSet) union(i, j int) {
+	if ri, rj := s.find(i), s.find(j); ri != rj {
+		s.parent[rj] = ri
+	}
+}
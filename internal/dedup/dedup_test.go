@@ -0,0 +1,190 @@
+package dedup
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ayagmar/gojobscraper/internal/scraper"
+)
+
+const description = `We are looking for a backend engineer to design, build and operate the
+services behind our payments platform. You will work with Go, PostgreSQL and
+Kafka, own features from design to production, take part in the on-call
+rotation and mentor other engineers. Experience with distributed systems,
+observability and cloud infrastructure is a plus.`
+
+func job(id, company, title, location, desc string) scraper.JobPosting {
+	j := scraper.JobPosting{ID: id, Title: title, Location: location, Description: desc}
+	j.CompanyDetails.Company = company
+	return j
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"company suffix", NormalizeCompany("Acme, Inc."), "acme"},
+		{"company legal form", NormalizeCompany("Müller Software GmbH"), "müller software"},
+		{"company only suffixes", NormalizeCompany("Co. Ltd"), ""},
+		{"title qualifier", NormalizeTitle("Senior Go Engineer (m/f/d)"), "senior go engineer"},
+		{"title brackets", NormalizeTitle("Backend Developer [Remote] - Payments"), "backend developer payments"},
+		{"location punctuation", NormalizeLocation("Berlin, Germany"), "berlin germany"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestLocationsCompatible(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"berlin", "berlin germany", true},
+		{"berlin germany", "berlin", true},
+		{"", "paris", true},
+		{"berlin", "munich", false},
+		{"berlin germany", "munich germany", false},
+	}
+	for _, tt := range tests {
+		if got := locationsCompatible(tt.a, tt.b); got != tt.want {
+			t.Errorf("locationsCompatible(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSignatureSimilarity(t *testing.T) {
+	hasher := newMinHasher(3, 128)
+	base := hasher.Sign(description)
+
+	if got := base.similarity(hasher.Sign(description)); got != 1 {
+		t.Errorf("identical descriptions: similarity %v, want 1", got)
+	}
+	if got := base.similarity(hasher.Sign(strings.ToUpper(description))); got != 1 {
+		t.Errorf("descriptions differing in case: similarity %v, want 1", got)
+	}
+
+	edited := strings.Replace(description, "mentor other engineers", "mentor junior engineers", 1)
+	if got := base.similarity(hasher.Sign(edited)); got < 0.7 {
+		t.Errorf("slightly edited description: similarity %v, want at least 0.7", got)
+	}
+
+	other := hasher.Sign("Join our kitchen team as a line cook preparing breakfast and lunch for guests of the hotel restaurant.")
+	if got := base.similarity(other); got > 0.1 {
+		t.Errorf("unrelated descriptions: similarity %v, want at most 0.1", got)
+	}
+
+	if hasher.Sign("  ...  ") != nil {
+		t.Error("signature of text without words is not nil")
+	}
+}
+
+func TestIsDuplicate(t *testing.T) {
+	d := NewDetector(Options{})
+	base := job("1", "Acme Inc", "Senior Backend Engineer", "Berlin", description)
+
+	tests := []struct {
+		name  string
+		other scraper.JobPosting
+		want  bool
+	}{
+		{"repost on another board", job("2", "ACME", "Senior Backend Engineer (m/f/d)", "Berlin, Germany", description), true},
+		{"edited description", job("2", "Acme", "Senior Backend Engineer", "Berlin", strings.Replace(description, "Kafka", "NATS", 1)), true},
+		{"other company", job("2", "Globex", "Senior Backend Engineer", "Berlin", description), false},
+		{"other location", job("2", "Acme", "Senior Backend Engineer", "Munich", description), false},
+		{"title below threshold", job("2", "Acme", "Junior Frontend Engineer", "Berlin", description), false},
+		{"other description", job("2", "Acme", "Senior Backend Engineer", "Berlin", "Build the mobile apps of our booking platform with Swift and Kotlin, together with designers and product managers."), false},
+		{"missing description, same title", job("2", "Acme", "Senior Backend Engineer", "Berlin", ""), true},
+		{"missing description, similar title", job("2", "Acme", "Senior Backend Engineer II", "Berlin", ""), false},
+	}
+	for _, tt := range tests {
+		if got := d.isDuplicate(d.fingerprint(base), d.fingerprint(tt.other)); got != tt.want {
+			t.Errorf("%s: isDuplicate = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	noCompany := job("3", "", "Senior Backend Engineer", "Berlin", description)
+	if d.isDuplicate(d.fingerprint(noCompany), d.fingerprint(noCompany)) {
+		t.Error("postings without a company are duplicates")
+	}
+}
+
+func TestCollapse(t *testing.T) {
+	now := time.Now()
+	first := job("1", "Acme", "Backend Engineer", "Berlin", description)
+	first.CreatedAt = now.Add(-time.Hour)
+	repost := job("2", "Acme Inc.", "Backend Engineer", "Berlin", description)
+	repost.CreatedAt = now
+	repost.Source = scraper.LinkedIn
+	repost.URL = "https://linkedin.example/2"
+	other := job("3", "Acme", "Office Manager", "Berlin", "Keep our Berlin office running smoothly, from supplies and visitors to events.")
+	other.CreatedAt = now
+
+	collapsed := NewDetector(Options{}).Collapse([]scraper.JobPosting{repost, first, other})
+	if len(collapsed) != 2 {
+		t.Fatalf("got %d postings, want 2", len(collapsed))
+	}
+	if collapsed[0].ID != "1" {
+		t.Errorf("canonical posting is %s, want the earliest posting 1", collapsed[0].ID)
+	}
+	if len(collapsed[0].Duplicates) != 1 || collapsed[0].Duplicates[0].ID != "2" || collapsed[0].Duplicates[0].URL != repost.URL {
+		t.Errorf("duplicates of the canonical posting: %+v", collapsed[0].Duplicates)
+	}
+	if collapsed[1].ID != "3" || len(collapsed[1].Duplicates) != 0 {
+		t.Errorf("unrelated posting: %+v", collapsed[1])
+	}
+}
+
+// TestCandidatePairs checks that LSH banding finds the duplicates that
+// comparing every pair of postings finds.
+func TestCandidatePairs(t *testing.T) {
+	d := NewDetector(Options{})
+	rng := rand.New(rand.NewSource(1))
+	words := strings.Fields(description)
+
+	var jobs []scraper.JobPosting
+	for i := 0; i < 200; i++ {
+		desc := make([]string, len(words))
+		copy(desc, words)
+		// Postings replace up to 20 words of the same description, so that
+		// some are duplicates and some are not.
+		for k := 0; k < rng.Intn(20); k++ {
+			desc[rng.Intn(len(desc))] = fmt.Sprintf("word%d", rng.Intn(1000))
+		}
+		title := []string{"Backend Engineer", "Senior Backend Engineer", "Platform Engineer"}[rng.Intn(3)]
+		company := []string{"Acme", "Globex"}[rng.Intn(2)]
+		jobs = append(jobs, job(fmt.Sprint(i), company, title, "Berlin", strings.Join(desc, " ")))
+	}
+
+	prints := make([]fingerprint, len(jobs))
+	for i, j := range jobs {
+		prints[i] = d.fingerprint(j)
+	}
+	candidates := make(map[[2]int]bool)
+	for _, pair := range candidatePairs(prints) {
+		candidates[pair] = true
+	}
+
+	compared := 0
+	for i := range prints {
+		for j := i + 1; j < len(prints); j++ {
+			if prints[i].company != prints[j].company {
+				continue
+			}
+			compared++
+			if d.isDuplicate(prints[i], prints[j]) && !candidates[[2]int{i, j}] {
+				t.Errorf("duplicates %d and %d are not candidates", i, j)
+			}
+		}
+	}
+	if len(candidates) >= compared {
+		t.Errorf("banding compares %d pairs, as many as the %d pairs of each company", len(candidates), compared)
+	}
+}
@@ -0,0 +1,118 @@
+package dedup
+
+import (
+	"hash/fnv"
+	"math"
+	"strings"
+)
+
+// bandRows is the number of signature values per LSH band. With 128 hashes,
+// descriptions at the default 0.7 similarity share a band with a probability
+// above 99.9%, while descriptions at 0.3 share one about 23% of the time.
+const bandRows = 4
+
+// signature is a MinHash sketch of a document's shingle set.
+type signature []uint64
+
+// minHasher computes MinHash signatures over word shingles.
+type minHasher struct {
+	shingleSize int
+	seeds       []uint64
+}
+
+func newMinHasher(shingleSize, numHashes int) *minHasher {
+	seeds := make([]uint64, numHashes)
+	state := uint64(0x9e3779b97f4a7c15)
+	for i := range seeds {
+		state = splitmix64(state)
+		seeds[i] = state
+	}
+	return &minHasher{shingleSize: shingleSize, seeds: seeds}
+}
+
+// Sign returns the signature of text, or nil if text has no shingles.
+func (m *minHasher) Sign(text string) signature {
+	shingles := m.shingles(text)
+	if len(shingles) == 0 {
+		return nil
+	}
+
+	sig := make(signature, len(m.seeds))
+	for i := range sig {
+		sig[i] = math.MaxUint64
+	}
+
+	for _, h := range shingles {
+		for i, seed := range m.seeds {
+			if v := splitmix64(h ^ seed); v < sig[i] {
+				sig[i] = v
+			}
+		}
+	}
+
+	return sig
+}
+
+// shingles returns the hashes of the distinct word n-grams in text. Texts
+// shorter than one shingle are hashed as a single shingle.
+func (m *minHasher) shingles(text string) []uint64 {
+	words := tokenize(text)
+	if len(words) == 0 {
+		return nil
+	}
+
+	size := m.shingleSize
+	if len(words) < size {
+		size = len(words)
+	}
+
+	seen := make(map[uint64]struct{})
+	hashes := make([]uint64, 0, len(words))
+	for i := 0; i+size <= len(words); i++ {
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(words[i:i+size], " ")))
+		sum := h.Sum64()
+		if _, ok := seen[sum]; ok {
+			continue
+		}
+		seen[sum] = struct{}{}
+		hashes = append(hashes, sum)
+	}
+
+	return hashes
+}
+
+// similarity estimates the Jaccard similarity of the documents behind two
+// signatures produced by the same minHasher.
+func (s signature) similarity(other signature) float64 {
+	if len(s) == 0 || len(s) != len(other) {
+		return 0
+	}
+	equal := 0
+	for i := range s {
+		if s[i] == other[i] {
+			equal++
+		}
+	}
+	return float64(equal) / float64(len(s))
+}
+
+// bandHash hashes the values of a band of the signature.
+func (s signature) bandHash(band int) uint64 {
+	end := (band + 1) * bandRows
+	if end > len(s) {
+		end = len(s)
+	}
+	h := uint64(band)
+	for _, v := range s[band*bandRows : end] {
+		h = splitmix64(h ^ v)
+	}
+	return h
+}
+
+func splitmix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
@@ -0,0 +1,90 @@
+package dedup
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var parenthesized = regexp.MustCompile(`\([^)]*\)|\[[^\]]*\]`)
+
+// companySuffixes are legal-form tokens that do not help tell employers apart.
+var companySuffixes = map[string]bool{
+	"inc": true, "llc": true, "ltd": true, "limited": true, "gmbh": true,
+	"corp": true, "corporation": true, "co": true, "company": true, "plc": true,
+	"sa": true, "sas": true, "sarl": true, "ag": true, "bv": true, "srl": true,
+}
+
+// tokenize lowercases s and splits it on anything that is not a letter or digit.
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// NormalizeCompany reduces a company name to a comparable key, dropping
+// punctuation and legal-form suffixes such as "Inc." or "GmbH".
+func NormalizeCompany(name string) string {
+	tokens := tokenize(name)
+	kept := tokens[:0]
+	for _, t := range tokens {
+		if !companySuffixes[t] {
+			kept = append(kept, t)
+		}
+	}
+	return strings.Join(kept, " ")
+}
+
+// NormalizeTitle reduces a job title to a comparable key, dropping
+// parenthesized qualifiers such as "(m/f/d)" or "(Remote)".
+func NormalizeTitle(title string) string {
+	return strings.Join(tokenize(parenthesized.ReplaceAllString(title, " ")), " ")
+}
+
+// NormalizeLocation reduces a location to a comparable key.
+func NormalizeLocation(location string) string {
+	return strings.Join(tokenize(location), " ")
+}
+
+// tokenSet returns the distinct whitespace-separated tokens of a normalized key.
+func tokenSet(key string) map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, t := range strings.Fields(key) {
+		set[t] = struct{}{}
+	}
+	return set
+}
+
+// jaccard returns the Jaccard similarity of two token sets.
+func jaccard(a, b map[string]struct{}) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	intersection := 0
+	for t := range a {
+		if _, ok := b[t]; ok {
+			intersection++
+		}
+	}
+	return float64(intersection) / float64(len(a)+len(b)-intersection)
+}
+
+// locationsCompatible reports whether two normalized locations may refer to
+// the same place, e.g. "berlin" and "berlin germany". Missing locations are
+// treated as compatible with anything.
+func locationsCompatible(a, b string) bool {
+	if a == "" || b == "" || a == b {
+		return true
+	}
+	setA, setB := tokenSet(a), tokenSet(b)
+	return isSubset(setA, setB) || isSubset(setB, setA)
+}
+
+func isSubset(a, b map[string]struct{}) bool {
+	for t := range a {
+		if _, ok := b[t]; !ok {
+			return false
+		}
+	}
+	return true
+}
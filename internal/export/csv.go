@@ -0,0 +1,43 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+
+	"github.com/ayagmar/gojobscraper/internal/scraper"
+)
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	cw := &csvWriter{w: csv.NewWriter(w)}
+	if err := cw.w.Write(columns); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+func (cw *csvWriter) Write(job scraper.JobPosting) error {
+	cells := row(job)
+	for i, cell := range cells {
+		cells[i] = escapeFormula(cell)
+	}
+	return cw.w.Write(cells)
+}
+
+func (cw *csvWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// escapeFormula prefixes cells that spreadsheet applications would evaluate
+// as formulas with a quote, since scraped text is not trusted.
+func escapeFormula(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
@@ -0,0 +1,111 @@
+// Package export writes job postings as CSV, NDJSON or XLSX files. Postings
+// are written one at a time, so exports of any size can be streamed.
+package export
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/ayagmar/gojobscraper/internal/scraper"
+)
+
+// Format is the file format of an export.
+type Format string
+
+const (
+	CSV    Format = "csv"
+	NDJSON Format = "ndjson"
+	XLSX   Format = "xlsx"
+)
+
+// ValidFormat reports whether f is a known format.
+func ValidFormat(f Format) bool {
+	return f == CSV || f == NDJSON || f == XLSX
+}
+
+// ContentType returns the media type of files of format f.
+func (f Format) ContentType() string {
+	switch f {
+	case CSV:
+		return "text/csv; charset=utf-8"
+	case NDJSON:
+		return "application/x-ndjson"
+	case XLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "application/octet-stream"
+}
+
+// Writer writes the postings of an export.
+type Writer interface {
+	Write(job scraper.JobPosting) error
+	// Close completes the file. It does not close the underlying writer.
+	Close() error
+}
+
+// NewWriter returns a writer producing a file of format f on w.
+func NewWriter(f Format, w io.Writer) (Writer, error) {
+	switch f {
+	case CSV:
+		return newCSVWriter(w)
+	case NDJSON:
+		return newNDJSONWriter(w), nil
+	case XLSX:
+		return newXLSXWriter(w)
+	}
+	return nil, fmt.Errorf("unknown export format %q", f)
+}
+
+// columns are the columns of tabular exports, the company details of a
+// posting flattened into company_* columns.
+var columns = []string{
+	"id",
+	"platform_job_id",
+	"source",
+	"title",
+	"location",
+	"summary",
+	"description",
+	"url",
+	"created_at",
+	"incomplete",
+	"hidden",
+	"hidden_reason",
+	"company_id",
+	"company_name",
+	"company_url",
+	"company_platform_url",
+	"company_industry",
+	"company_size",
+	"company_rating",
+	"company_headquarters",
+}
+
+// row returns the cells of job in the order of columns.
+func row(job scraper.JobPosting) []string {
+	company := job.CompanyDetails
+	return []string{
+		job.ID,
+		job.PlatformJobId,
+		string(job.Source),
+		job.Title,
+		job.Location,
+		job.Summary,
+		job.Description,
+		job.URL,
+		job.CreatedAt.UTC().Format(time.RFC3339),
+		strconv.FormatBool(job.Incomplete),
+		strconv.FormatBool(job.Hidden),
+		job.HiddenReason,
+		job.CompanyID,
+		company.Company,
+		company.CompanyURL,
+		company.PlatformCompanyURL,
+		company.CompanyIndustry,
+		company.CompanySize,
+		company.CompanyRating,
+		company.Headquarters,
+	}
+}
@@ -0,0 +1,26 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/ayagmar/gojobscraper/internal/scraper"
+)
+
+// ndjsonWriter writes every posting as a JSON object on its own line, with
+// the same fields as the API.
+type ndjsonWriter struct {
+	enc *json.Encoder
+}
+
+func newNDJSONWriter(w io.Writer) *ndjsonWriter {
+	return &ndjsonWriter{enc: json.NewEncoder(w)}
+}
+
+func (nw *ndjsonWriter) Write(job scraper.JobPosting) error {
+	return nw.enc.Encode(job)
+}
+
+func (nw *ndjsonWriter) Close() error {
+	return nil
+}
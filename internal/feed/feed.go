@@ -0,0 +1,222 @@
+// Package feed renders the matches of saved searches as Atom and RSS feeds.
+package feed
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ayagmar/gojobscraper/internal/scraper"
+	"github.com/ayagmar/gojobscraper/internal/search"
+)
+
+// generator names the feeds' author and generator.
+const generator = "Job Scraper"
+
+// Feed lists the most recent postings matched by a saved search.
+type Feed struct {
+	ID    string
+	Title string
+	// SelfURL is the URL the feed is served at.
+	SelfURL string
+	// Updated is when the most recent posting was matched, or when the
+	// search was saved if it has no match yet.
+	Updated time.Time
+	Entries []Entry
+}
+
+// Entry is a posting of a feed.
+type Entry struct {
+	ID      string
+	Title   string
+	Link    string
+	Author  string
+	Summary string
+	Updated time.Time
+}
+
+// New builds the feed of a saved search from its matches, newest first.
+// Matches whose posting is no longer stored are left out.
+func New(s search.SavedSearch, matches []search.Match, selfURL string) Feed {
+	f := Feed{
+		ID:      "urn:uuid:" + s.ID,
+		Title:   fmt.Sprintf("%s: new jobs", s.Name),
+		SelfURL: selfURL,
+		Updated: s.CreatedAt,
+	}
+	for _, match := range matches {
+		if match.Job == nil {
+			continue
+		}
+		if match.MatchedAt.After(f.Updated) {
+			f.Updated = match.MatchedAt
+		}
+		f.Entries = append(f.Entries, newEntry(*match.Job, match.MatchedAt))
+	}
+	return f
+}
+
+func newEntry(job scraper.JobPosting, matchedAt time.Time) Entry {
+	company := job.CompanyDetails.Company
+	title := job.Title
+	if company != "" {
+		title = fmt.Sprintf("%s at %s", job.Title, company)
+	}
+
+	var header []string
+	for _, part := range []string{company, job.Location} {
+		if part != "" {
+			header = append(header, part)
+		}
+	}
+	summary := strings.Join(header, " · ")
+	if job.Summary != "" {
+		if summary != "" {
+			summary += "\n\n"
+		}
+		summary += job.Summary
+	}
+
+	return Entry{
+		ID:      "urn:uuid:" + job.ID,
+		Title:   title,
+		Link:    job.URL,
+		Author:  company,
+		Summary: summary,
+		Updated: matchedAt,
+	}
+}
+
+type atomFeed struct {
+	XMLName   xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Updated   string      `xml:"updated"`
+	Link      atomLink    `xml:"link"`
+	Author    atomAuthor  `xml:"author"`
+	Generator string      `xml:"generator"`
+	Entries   []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Link    *atomLink   `xml:"link,omitempty"`
+	Author  *atomAuthor `xml:"author,omitempty"`
+	Summary atomText    `xml:"summary"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Text string `xml:",chardata"`
+}
+
+// Atom renders the feed as an Atom 1.0 document.
+func (f Feed) Atom() ([]byte, error) {
+	doc := atomFeed{
+		ID:        f.ID,
+		Title:     f.Title,
+		Updated:   f.Updated.UTC().Format(time.RFC3339),
+		Link:      atomLink{Rel: "self", Type: "application/atom+xml", Href: f.SelfURL},
+		Author:    atomAuthor{Name: generator},
+		Generator: generator,
+	}
+	for _, e := range f.Entries {
+		entry := atomEntry{
+			ID:      e.ID,
+			Title:   e.Title,
+			Updated: e.Updated.UTC().Format(time.RFC3339),
+			Summary: atomText{Type: "text", Text: e.Summary},
+		}
+		if e.Link != "" {
+			entry.Link = &atomLink{Rel: "alternate", Type: "text/html", Href: e.Link}
+		}
+		if e.Author != "" {
+			entry.Author = &atomAuthor{Name: e.Author}
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+	return marshal(doc)
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	AtomLink      rssLink   `xml:"atom:link"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Generator     string    `xml:"generator"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssLink struct {
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+	Href string `xml:"href,attr"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link,omitempty"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+	Description string  `xml:"description"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// RSS renders the feed as an RSS 2.0 document.
+func (f Feed) RSS() ([]byte, error) {
+	doc := rssFeed{
+		Version: "2.0",
+		AtomNS:  "http://www.w3.org/2005/Atom",
+		Channel: rssChannel{
+			Title:         f.Title,
+			Link:          f.SelfURL,
+			Description:   f.Title,
+			AtomLink:      rssLink{Rel: "self", Type: "application/rss+xml", Href: f.SelfURL},
+			LastBuildDate: f.Updated.UTC().Format(time.RFC1123Z),
+			Generator:     generator,
+		},
+	}
+	for _, e := range f.Entries {
+		doc.Channel.Items = append(doc.Channel.Items, rssItem{
+			Title:       e.Title,
+			Link:        e.Link,
+			GUID:        rssGUID{Value: e.ID},
+			PubDate:     e.Updated.UTC().Format(time.RFC1123Z),
+			Description: e.Summary,
+		})
+	}
+	return marshal(doc)
+}
+
+func marshal(doc any) ([]byte, error) {
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to render feed: %w", err)
+	}
+	return append([]byte(xml.Header), body...), nil
+}
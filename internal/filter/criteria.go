@@ -0,0 +1,121 @@
+package filter
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ayagmar/gojobscraper/internal/scraper"
+)
+
+// Criteria are the conditions of a saved search. Empty fields match every
+// posting; a list matches when any of its entries does, except Keywords
+// which must all match.
+// @Description Saved search criteria
+type Criteria struct {
+	// Keywords must all appear as whole words in the title, summary,
+	// description or company name, ignoring case.
+	Keywords []string `json:"keywords,omitempty" bson:"keywords,omitempty"`
+	// ExcludeKeywords must not appear as whole words in the title, summary,
+	// description or company name.
+	ExcludeKeywords []string              `json:"exclude_keywords,omitempty" bson:"exclude_keywords,omitempty"`
+	Sources         []scraper.ScraperType `json:"sources,omitempty" bson:"sources,omitempty"`
+	// Locations are matched as whole words against the location, ignoring
+	// case.
+	Locations []string `json:"locations,omitempty" bson:"locations,omitempty"`
+	// Companies are matched as whole words against the company name,
+	// ignoring case.
+	Companies []string `json:"companies,omitempty" bson:"companies,omitempty"`
+	// MinSalary is the yearly salary floor, in the currency of the posting.
+	// Postings are matched on the top of their salary range.
+	MinSalary float64 `json:"min_salary,omitempty" bson:"min_salary,omitempty"`
+	// SalaryRequired excludes postings without a salary when MinSalary is
+	// set. Otherwise they are kept.
+	SalaryRequired bool `json:"salary_required,omitempty" bson:"salary_required,omitempty"`
+	// Seniority is inferred from the job title.
+	Seniority []Seniority `json:"seniority,omitempty" bson:"seniority,omitempty"`
+}
+
+// Validate reports whether the criteria can be used.
+func (c Criteria) Validate() error {
+	if c.MinSalary < 0 {
+		return errors.New("invalid min_salary. Must not be negative")
+	}
+	for _, seniority := range c.Seniority {
+		if !ValidSeniority(seniority) {
+			return fmt.Errorf("invalid seniority %q. Must be one of intern, junior, mid, senior or lead", seniority)
+		}
+	}
+	return nil
+}
+
+// Match reports whether job satisfies every criterion of c.
+func (c Criteria) Match(job scraper.JobPosting) bool {
+	if len(c.Sources) > 0 && !containsSource(c.Sources, job.Source) {
+		return false
+	}
+	if len(c.Locations) > 0 && !anyContainsWords(job.Location, c.Locations) {
+		return false
+	}
+	if len(c.Companies) > 0 && !anyContainsWords(job.CompanyDetails.Company, c.Companies) {
+		return false
+	}
+	for _, keyword := range c.Keywords {
+		if !mentions(job, keyword) {
+			return false
+		}
+	}
+	for _, keyword := range c.ExcludeKeywords {
+		if mentions(job, keyword) {
+			return false
+		}
+	}
+	if len(c.Seniority) > 0 && !containsSeniority(c.Seniority, InferSeniority(job.Title)) {
+		return false
+	}
+	if c.MinSalary > 0 {
+		salary, ok := ParseSalary(job.Summary + "\n" + job.Description)
+		if !ok {
+			return !c.SalaryRequired
+		}
+		if salary.Max < c.MinSalary {
+			return false
+		}
+	}
+	return true
+}
+
+// mentions reports whether keyword appears as whole words in the text fields
+// of job.
+func mentions(job scraper.JobPosting, keyword string) bool {
+	return containsWords(job.Title, keyword) ||
+		containsWords(job.Summary, keyword) ||
+		containsWords(job.Description, keyword) ||
+		containsWords(job.CompanyDetails.Company, keyword)
+}
+
+func anyContainsWords(s string, phrases []string) bool {
+	for _, phrase := range phrases {
+		if containsWords(s, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsSource(sources []scraper.ScraperType, source scraper.ScraperType) bool {
+	for _, s := range sources {
+		if s == source {
+			return true
+		}
+	}
+	return false
+}
+
+func containsSeniority(levels []Seniority, seniority Seniority) bool {
+	for _, level := range levels {
+		if level == seniority {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/ayagmar/gojobscraper/internal/scraper"
+)
+
+func TestCriteriaMatch(t *testing.T) {
+	job := scraper.JobPosting{
+		Title:       "Senior Backend Engineer",
+		Location:    "Remote, Germany",
+		Description: "Go, Kafka and Kubernetes. Salary: €70,000 - €90,000 a year.",
+		Source:      scraper.Indeed,
+	}
+	job.CompanyDetails.Company = "Acme GmbH"
+
+	noSalary := job
+	noSalary.Description = "Go, Kafka and Kubernetes."
+
+	tests := []struct {
+		name     string
+		criteria Criteria
+		job      scraper.JobPosting
+		want     bool
+	}{
+		{"empty criteria", Criteria{}, job, true},
+		{"all keywords", Criteria{Keywords: []string{"go", "kafka"}}, job, true},
+		{"missing keyword", Criteria{Keywords: []string{"go", "rust"}}, job, false},
+		{"keyword inside a word", Criteria{Keywords: []string{"kube"}}, job, false},
+		{"excluded keyword", Criteria{ExcludeKeywords: []string{"kubernetes"}}, job, false},
+		{"excluded keyword inside a word", Criteria{ExcludeKeywords: []string{"end"}}, job, true},
+		{"any source", Criteria{Sources: []scraper.ScraperType{scraper.LinkedIn, scraper.Indeed}}, job, true},
+		{"other source", Criteria{Sources: []scraper.ScraperType{scraper.LinkedIn}}, job, false},
+		{"any location", Criteria{Locations: []string{"berlin", "remote"}}, job, true},
+		{"other location", Criteria{Locations: []string{"berlin"}}, job, false},
+		{"company", Criteria{Companies: []string{"acme"}}, job, true},
+		{"company inside a word", Criteria{Companies: []string{"acm"}}, job, false},
+		{"seniority", Criteria{Seniority: []Seniority{SenioritySenior, SeniorityLead}}, job, true},
+		{"other seniority", Criteria{Seniority: []Seniority{SeniorityJunior}}, job, false},
+		{"salary above the floor", Criteria{MinSalary: 80000}, job, true},
+		{"salary below the floor", Criteria{MinSalary: 95000}, job, false},
+		{"no salary kept", Criteria{MinSalary: 80000}, noSalary, true},
+		{"no salary required", Criteria{MinSalary: 80000, SalaryRequired: true}, noSalary, false},
+	}
+	for _, tt := range tests {
+		if got := tt.criteria.Match(tt.job); got != tt.want {
+			t.Errorf("%s: Match = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCriteriaValidate(t *testing.T) {
+	if err := (Criteria{MinSalary: 50000, Seniority: []Seniority{SeniorityMid}}).Validate(); err != nil {
+		t.Errorf("valid criteria: %v", err)
+	}
+	if err := (Criteria{MinSalary: -1}).Validate(); err == nil {
+		t.Error("negative min_salary accepted")
+	}
+	if err := (Criteria{Seniority: []Seniority{"expert"}}).Validate(); err == nil {
+		t.Error("unknown seniority accepted")
+	}
+}
@@ -0,0 +1,85 @@
+// Package filter matches job postings against user supplied criteria.
+package filter
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ayagmar/gojobscraper/internal/scraper"
+)
+
+// JobFilter selects job postings. Empty fields match every posting.
+type JobFilter struct {
+	// Source restricts postings to a single source.
+	Source scraper.ScraperType `json:"source,omitempty" bson:"source,omitempty"`
+	// Keyword must appear as whole words in the title, summary, description
+	// or company name, ignoring case.
+	Keyword string `json:"keyword,omitempty" bson:"keyword,omitempty"`
+	// Location must appear as whole words in the location, ignoring case.
+	Location string `json:"location,omitempty" bson:"location,omitempty"`
+}
+
+// Match reports whether job satisfies every criterion of f.
+func (f JobFilter) Match(job scraper.JobPosting) bool {
+	if f.Source != "" && job.Source != f.Source {
+		return false
+	}
+	if f.Location != "" && !containsWords(job.Location, f.Location) {
+		return false
+	}
+	if f.Keyword != "" && !mentions(job, f.Keyword) {
+		return false
+	}
+	return true
+}
+
+// containsWords reports whether phrase appears in s as whole words, ignoring
+// case: "go" is found in "Go developer" but not in "Google". Edges of phrase
+// that are not letters or digits, such as the end of "c++", match anywhere.
+func containsWords(s, phrase string) bool {
+	s, phrase = strings.ToLower(s), strings.ToLower(phrase)
+	if phrase == "" {
+		return true
+	}
+	first, _ := utf8.DecodeRuneInString(phrase)
+	last, _ := utf8.DecodeLastRuneInString(phrase)
+
+	for offset := 0; ; {
+		i := strings.Index(s[offset:], phrase)
+		if i < 0 {
+			return false
+		}
+		start, end := offset+i, offset+i+len(phrase)
+
+		before, _ := utf8.DecodeLastRuneInString(s[:start])
+		after, _ := utf8.DecodeRuneInString(s[end:])
+		if (start == 0 || !isWordRune(first) || !isWordRune(before)) &&
+			(end == len(s) || !isWordRune(last) || !isWordRune(after)) {
+			return true
+		}
+
+		_, size := utf8.DecodeRuneInString(s[start:])
+		offset = start + size
+	}
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// WordsPattern returns a regular expression, for Go and MongoDB, matching the
+// strings in which phrase appears as whole words as containsWords does. It
+// is meant to be used case-insensitively.
+func WordsPattern(phrase string) string {
+	const word = `\p{L}\p{N}`
+	pattern := regexp.QuoteMeta(phrase)
+	if first, _ := utf8.DecodeRuneInString(phrase); isWordRune(first) {
+		pattern = `(?:^|[^` + word + `])` + pattern
+	}
+	if last, _ := utf8.DecodeLastRuneInString(phrase); isWordRune(last) {
+		pattern += `(?:$|[^` + word + `])`
+	}
+	return pattern
+}
@@ -0,0 +1,82 @@
+package filter
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/ayagmar/gojobscraper/internal/scraper"
+)
+
+func TestContainsWords(t *testing.T) {
+	tests := []struct {
+		s, phrase string
+		want      bool
+	}{
+		{"Go Developer", "go", true},
+		{"Senior Golang Engineer", "go", false},
+		{"Google", "go", false},
+		{"Backend (Go/Python)", "go", true},
+		{"Java Engineer", "java", true},
+		{"JavaScript Engineer", "java", false},
+		{"Berlin, Germany", "berlin", true},
+		{"Berlingen", "berlin", false},
+		{"New York, NY", "new york", true},
+		{"New Yorkshire", "new york", false},
+		{"Go go gopher", "go", true},
+		{"gopher, go", "go", true},
+		{"C++ Developer", "c++", true},
+		{"Senior C++", "C++", true},
+		{"ASP.NET Developer", ".net", true},
+		{"Zürich", "zürich", true},
+		{"ZÜRICH", "zürich", true},
+		{"Zürichsee", "zürich", false},
+		{"Engineer II", "ii", true},
+		{"AI2 Researcher", "ai", false},
+		{"anything", "", true},
+	}
+	for _, tt := range tests {
+		if got := containsWords(tt.s, tt.phrase); got != tt.want {
+			t.Errorf("containsWords(%q, %q) = %v, want %v", tt.s, tt.phrase, got, tt.want)
+		}
+
+		// The query pattern of the store matches the same strings.
+		pattern := regexp.MustCompile("(?i)" + WordsPattern(tt.phrase))
+		if got := pattern.MatchString(tt.s); got != tt.want {
+			t.Errorf("WordsPattern(%q) matches %q: %v, want %v", tt.phrase, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestJobFilterMatch(t *testing.T) {
+	job := scraper.JobPosting{
+		Title:       "Senior Go Engineer",
+		Location:    "Berlin, Germany",
+		Summary:     "Payments platform",
+		Description: "Build services with Go and PostgreSQL.",
+		Source:      scraper.LinkedIn,
+	}
+	job.CompanyDetails.Company = "Acme"
+
+	tests := []struct {
+		name   string
+		filter JobFilter
+		want   bool
+	}{
+		{"empty filter", JobFilter{}, true},
+		{"source", JobFilter{Source: scraper.LinkedIn}, true},
+		{"other source", JobFilter{Source: scraper.Indeed}, false},
+		{"keyword in title", JobFilter{Keyword: "GO"}, true},
+		{"keyword in description", JobFilter{Keyword: "postgresql"}, true},
+		{"keyword in company", JobFilter{Keyword: "acme"}, true},
+		{"keyword inside a word", JobFilter{Keyword: "engine"}, false},
+		{"location", JobFilter{Location: "germany"}, true},
+		{"location inside a word", JobFilter{Location: "germ"}, false},
+		{"every criterion", JobFilter{Source: scraper.LinkedIn, Keyword: "payments", Location: "berlin"}, true},
+		{"one criterion failing", JobFilter{Source: scraper.LinkedIn, Keyword: "payments", Location: "munich"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.filter.Match(job); got != tt.want {
+			t.Errorf("%s: Match = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
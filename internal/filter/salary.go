@@ -0,0 +1,85 @@
+package filter
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Salary is a pay range found in a posting, converted to a yearly amount in
+// the posting's currency.
+type Salary struct {
+	Min      float64
+	Max      float64
+	Currency string
+}
+
+// Yearly hours, days, weeks and months used to annualize pay.
+var annualFactors = map[string]float64{
+	"hour": 2080, "hr": 2080, "hourly": 2080,
+	"day": 260, "daily": 260,
+	"week": 52, "weekly": 52,
+	"month": 12, "mo": 12, "monthly": 12,
+	"year": 1, "yr": 1, "annum": 1, "yearly": 1, "annually": 1,
+}
+
+var currencyCodes = map[string]string{"$": "USD", "€": "EUR", "£": "GBP"}
+
+// salaryPattern matches an amount or a range of amounts preceded by a currency
+// symbol or code, optionally followed by the period it is paid for, such as
+// "$120,000 - $150,000 a year", "€60k" or "USD 45 per hour".
+var salaryPattern = regexp.MustCompile(`(?i)(?:([$€£])|\b(usd|eur|gbp|cad|aud|chf)\s?)(\d[\d,.]*\d|\d)(k)?` +
+	`(?:\s*(?:-|–|to)\s*(?:[$€£]|(?:usd|eur|gbp|cad|aud|chf)\s?)?(\d[\d,.]*\d|\d)(k)?)?` +
+	`(?:\s*(?:(?:a|an|per|/)\s*(year|yr|annum|hour|hr|day|week|month|mo)\b|\b(yearly|annually|hourly|daily|weekly|monthly)\b))?`)
+
+var thousandsDots = regexp.MustCompile(`^\d{1,3}(\.\d{3})+$`)
+
+// ParseSalary returns the first salary mentioned in text. Amounts without a
+// period are yearly, unless they are below 1000, which only hourly pay is.
+func ParseSalary(text string) (Salary, bool) {
+	m := salaryPattern.FindStringSubmatch(text)
+	if m == nil {
+		return Salary{}, false
+	}
+
+	min, ok := parseAmount(m[3], m[4])
+	if !ok {
+		return Salary{}, false
+	}
+	max := min
+	if m[5] != "" {
+		if max, ok = parseAmount(m[5], m[6]); !ok || max < min {
+			max = min
+		}
+	}
+
+	period := strings.ToLower(m[7] + m[8])
+	factor, ok := annualFactors[period]
+	if !ok {
+		factor = 1
+		if max < 1000 {
+			factor = annualFactors["hour"]
+		}
+	}
+
+	currency := currencyCodes[m[1]]
+	if currency == "" {
+		currency = strings.ToUpper(m[2])
+	}
+
+	return Salary{Min: min * factor, Max: max * factor, Currency: currency}, true
+}
+
+func parseAmount(digits, thousands string) (float64, bool) {
+	if thousandsDots.MatchString(digits) {
+		digits = strings.ReplaceAll(digits, ".", "")
+	}
+	amount, err := strconv.ParseFloat(strings.ReplaceAll(digits, ",", ""), 64)
+	if err != nil {
+		return 0, false
+	}
+	if thousands != "" {
+		amount *= 1000
+	}
+	return amount, true
+}
@@ -0,0 +1,32 @@
+package filter
+
+import "testing"
+
+func TestParseSalary(t *testing.T) {
+	tests := []struct {
+		text string
+		want Salary
+		ok   bool
+	}{
+		{"Pay: $120,000 - $150,000 a year", Salary{120000, 150000, "USD"}, true},
+		{"€60k", Salary{60000, 60000, "EUR"}, true},
+		{"€60k-80k per year", Salary{60000, 80000, "EUR"}, true},
+		{"USD 45 per hour", Salary{45 * 2080, 45 * 2080, "USD"}, true},
+		{"$25 - $30 an hour", Salary{25 * 2080, 30 * 2080, "USD"}, true},
+		{"£3,500 a month", Salary{42000, 42000, "GBP"}, true},
+		{"£400 daily", Salary{400 * 260, 400 * 260, "GBP"}, true},
+		{"CHF 1.200 weekly", Salary{1200 * 52, 1200 * 52, "CHF"}, true},
+		{"€55.000 - €65.000", Salary{55000, 65000, "EUR"}, true},
+		{"$40", Salary{40 * 2080, 40 * 2080, "USD"}, true},
+		{"$90,000 to $80,000", Salary{90000, 90000, "USD"}, true},
+		{"First: $100k, then $200k", Salary{100000, 100000, "USD"}, true},
+		{"Competitive salary", Salary{}, false},
+		{"5 years of experience", Salary{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseSalary(tt.text)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("ParseSalary(%q) = %+v, %v, want %+v, %v", tt.text, got, ok, tt.want, tt.ok)
+		}
+	}
+}
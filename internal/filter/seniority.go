@@ -0,0 +1,58 @@
+package filter
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Seniority is the experience level of a role, inferred from its title
+// @Description Seniority of a role
+type Seniority string
+
+const (
+	SeniorityIntern Seniority = "intern"
+	SeniorityJunior Seniority = "junior"
+	SeniorityMid    Seniority = "mid"
+	SenioritySenior Seniority = "senior"
+	SeniorityLead   Seniority = "lead"
+)
+
+// ValidSeniority reports whether s is a known seniority.
+func ValidSeniority(s Seniority) bool {
+	switch s {
+	case SeniorityIntern, SeniorityJunior, SeniorityMid, SenioritySenior, SeniorityLead:
+		return true
+	}
+	return false
+}
+
+// seniorityWords are the title words of each seniority, most specific first.
+// Titles without any of them are mid level.
+var seniorityWords = []struct {
+	seniority Seniority
+	words     []string
+}{
+	{SeniorityIntern, []string{"intern", "internship", "trainee", "apprentice", "stagiaire", "werkstudent"}},
+	{SeniorityLead, []string{"lead", "staff", "principal", "head", "director", "vp"}},
+	{SenioritySenior, []string{"senior", "sr", "snr"}},
+	{SeniorityJunior, []string{"junior", "jr", "entry", "graduate", "grad"}},
+}
+
+// InferSeniority returns the seniority a job title denotes.
+func InferSeniority(title string) Seniority {
+	words := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		words[word] = true
+	}
+
+	for _, level := range seniorityWords {
+		for _, word := range level.words {
+			if words[word] {
+				return level.seniority
+			}
+		}
+	}
+	return SeniorityMid
+}
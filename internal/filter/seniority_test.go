@@ -0,0 +1,40 @@
+package filter
+
+import "testing"
+
+func TestInferSeniority(t *testing.T) {
+	tests := []struct {
+		title string
+		want  Seniority
+	}{
+		{"Software Engineer", SeniorityMid},
+		{"Senior Software Engineer", SenioritySenior},
+		{"Sr. Backend Developer", SenioritySenior},
+		{"Junior Frontend Developer", SeniorityJunior},
+		{"Graduate Data Analyst", SeniorityJunior},
+		{"Staff Engineer", SeniorityLead},
+		{"Lead Engineer (Senior)", SeniorityLead},
+		{"Software Engineering Intern", SeniorityIntern},
+		{"Werkstudent Softwareentwicklung", SeniorityIntern},
+		{"Senior-Principal Architect", SeniorityLead},
+		{"Leadership Coach", SeniorityMid},
+		{"Internal Tools Engineer", SeniorityMid},
+		{"Seniority Analyst", SeniorityMid},
+	}
+	for _, tt := range tests {
+		if got := InferSeniority(tt.title); got != tt.want {
+			t.Errorf("InferSeniority(%q) = %s, want %s", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestValidSeniority(t *testing.T) {
+	for _, s := range []Seniority{SeniorityIntern, SeniorityJunior, SeniorityMid, SenioritySenior, SeniorityLead} {
+		if !ValidSeniority(s) {
+			t.Errorf("%s not valid", s)
+		}
+	}
+	if ValidSeniority("expert") {
+		t.Error("unknown seniority valid")
+	}
+}
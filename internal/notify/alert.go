@@ -0,0 +1,286 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ayagmar/gojobscraper/internal/filter"
+	"github.com/ayagmar/gojobscraper/internal/scraper"
+)
+
+// AlertRule posts the new jobs matching its filter to a chat webhook
+// @Description Chat alert rule
+type AlertRule struct {
+	ID   string `json:"id" bson:"id"`
+	Name string `json:"name" bson:"name"`
+	// URL is the incoming webhook URL messages are posted to.
+	URL    string           `json:"url" bson:"url"`
+	Format ChatFormat       `json:"format" bson:"format"`
+	Filter filter.JobFilter `json:"filter" bson:"filter"`
+	// BatchWindowSeconds is how long matching jobs are collected before
+	// they are posted in a single message. Zero uses the configured default.
+	BatchWindowSeconds int `json:"batch_window_seconds,omitempty" bson:"batch_window_seconds,omitempty"`
+	// MaxMessagesPerHour caps the messages posted for the rule. Jobs
+	// matched while the limit is reached are posted once it allows. Zero
+	// uses the configured default.
+	MaxMessagesPerHour int       `json:"max_messages_per_hour,omitempty" bson:"max_messages_per_hour,omitempty"`
+	CreatedAt          time.Time `json:"created_at" bson:"created_at"`
+}
+
+// AlertStore provides the alert rules.
+type AlertStore interface {
+	GetAlertRules() ([]AlertRule, error)
+}
+
+// AlertConfig configures the defaults and limits of chat alerts.
+type AlertConfig struct {
+	BatchWindow        time.Duration
+	MaxMessagesPerHour int
+	// MaxJobsPerMessage is the number of jobs listed in a message. Further
+	// jobs are only counted.
+	MaxJobsPerMessage int
+	// MaxPending bounds the jobs held per rule while it is rate limited.
+	MaxPending int
+	Timeout    time.Duration
+}
+
+// alertState is what an Alerter keeps about a rule between messages.
+type alertState struct {
+	rule    AlertRule
+	pending []scraper.JobPosting
+	// dropped counts the jobs matched beyond MaxPending.
+	dropped int
+	timer   *time.Timer
+	// sent holds the times of the messages posted in the last hour.
+	sent []time.Time
+}
+
+// Alerter batches the new jobs matching alert rules and posts them to chat
+// webhooks, within each rule's rate limit.
+type Alerter struct {
+	store  AlertStore
+	config AlertConfig
+	client *http.Client
+	logger *log.Logger
+
+	mu     sync.Mutex
+	states map[string]*alertState
+}
+
+// NewAlerter creates an alerter.
+func NewAlerter(store AlertStore, config AlertConfig, logger *log.Logger) *Alerter {
+	if config.BatchWindow <= 0 {
+		config.BatchWindow = time.Minute
+	}
+	if config.MaxMessagesPerHour <= 0 {
+		config.MaxMessagesPerHour = 10
+	}
+	if config.MaxJobsPerMessage <= 0 {
+		config.MaxJobsPerMessage = 10
+	}
+	if config.MaxPending <= 0 {
+		config.MaxPending = 1000
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+
+	return &Alerter{
+		store:  store,
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+		logger: logger,
+		states: make(map[string]*alertState),
+	}
+}
+
+// JobsSaved queues the newly inserted jobs for the rules they match. Each
+// rule posts them once its batch window has passed.
+func (a *Alerter) JobsSaved(jobs []scraper.JobPosting) {
+	if len(jobs) == 0 {
+		return
+	}
+
+	rules, err := a.store.GetAlertRules()
+	if err != nil {
+		a.logger.Printf("Error loading alert rules: %v", err)
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, rule := range rules {
+		var matching []scraper.JobPosting
+		for _, job := range jobs {
+			if rule.Filter.Match(job) {
+				matching = append(matching, job)
+			}
+		}
+		if len(matching) == 0 {
+			continue
+		}
+
+		state, ok := a.states[rule.ID]
+		if !ok {
+			state = &alertState{}
+			a.states[rule.ID] = state
+		}
+		state.rule = rule
+
+		room := a.config.MaxPending - len(state.pending)
+		if room < len(matching) {
+			if room < 0 {
+				room = 0
+			}
+			state.dropped += len(matching) - room
+			matching = matching[:room]
+		}
+		state.pending = append(state.pending, matching...)
+
+		if state.timer == nil {
+			id := rule.ID
+			state.timer = time.AfterFunc(a.batchWindow(rule), func() { a.flush(id) })
+		}
+	}
+}
+
+// Test posts a sample message for rule and returns the receiver's error.
+func (a *Alerter) Test(rule AlertRule) error {
+	sample := scraper.JobPosting{
+		Title:    "Example job posting",
+		Location: "Remote",
+		URL:      "https://example.com/jobs/1",
+		Source:   scraper.ScraperType("example"),
+	}
+	sample.CompanyDetails.Company = "Example Inc."
+	return a.post(rule, ChatMessage{Rule: rule, Jobs: []scraper.JobPosting{sample}, Total: 1})
+}
+
+// flush posts the pending jobs of a rule, or reschedules itself when the
+// rule's rate limit is reached. The rule is looked up again first, so that
+// the jobs of deleted rules are dropped and edits are applied.
+func (a *Alerter) flush(id string) {
+	rule, found, err := a.currentRule(id)
+	if err != nil {
+		a.logger.Printf("Error reloading alert rule %s: %v", id, err)
+	}
+
+	a.mu.Lock()
+	state, ok := a.states[id]
+	if !ok {
+		a.mu.Unlock()
+		return
+	}
+	state.timer = nil
+
+	if err == nil {
+		if !found {
+			delete(a.states, id)
+			a.mu.Unlock()
+			return
+		}
+		state.rule = rule
+	}
+
+	now := time.Now()
+	recent := state.sent[:0]
+	for _, at := range state.sent {
+		if now.Sub(at) < time.Hour {
+			recent = append(recent, at)
+		}
+	}
+	state.sent = recent
+
+	if len(state.pending) == 0 {
+		if len(state.sent) == 0 {
+			delete(a.states, id)
+		}
+		a.mu.Unlock()
+		return
+	}
+	if len(state.sent) >= a.maxMessagesPerHour(state.rule) {
+		wait := state.sent[0].Add(time.Hour).Sub(now)
+		state.timer = time.AfterFunc(wait, func() { a.flush(id) })
+		a.mu.Unlock()
+		return
+	}
+
+	rule = state.rule
+	msg := ChatMessage{Rule: rule, Jobs: state.pending, Total: len(state.pending) + state.dropped}
+	if len(msg.Jobs) > a.config.MaxJobsPerMessage {
+		msg.Jobs = msg.Jobs[:a.config.MaxJobsPerMessage]
+	}
+	state.pending = nil
+	state.dropped = 0
+	state.sent = append(state.sent, now)
+	a.mu.Unlock()
+
+	if err := a.post(rule, msg); err != nil {
+		a.logger.Printf("Error posting alert for rule %s: %v", rule.ID, err)
+	}
+}
+
+// currentRule returns the stored rule with the given ID. found is false if
+// the rule was deleted.
+func (a *Alerter) currentRule(id string) (rule AlertRule, found bool, err error) {
+	rules, err := a.store.GetAlertRules()
+	if err != nil {
+		return AlertRule{}, false, err
+	}
+	for _, rule := range rules {
+		if rule.ID == id {
+			return rule, true, nil
+		}
+	}
+	return AlertRule{}, false, nil
+}
+
+func (a *Alerter) post(rule AlertRule, msg ChatMessage) error {
+	payload, err := msg.Payload(rule.Format)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rule.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gojobscraper-alerts")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post alert: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("chat webhook responded with HTTP %d: %s", resp.StatusCode, body)
+	}
+	return nil
+}
+
+func (a *Alerter) batchWindow(rule AlertRule) time.Duration {
+	if rule.BatchWindowSeconds > 0 {
+		return time.Duration(rule.BatchWindowSeconds) * time.Second
+	}
+	return a.config.BatchWindow
+}
+
+func (a *Alerter) maxMessagesPerHour(rule AlertRule) int {
+	if rule.MaxMessagesPerHour > 0 {
+		return rule.MaxMessagesPerHour
+	}
+	return a.config.MaxMessagesPerHour
+}
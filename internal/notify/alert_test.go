@@ -0,0 +1,146 @@
+package notify
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ayagmar/gojobscraper/internal/scraper"
+)
+
+// alertStore serves the rules of an alerter and lets tests delete them.
+type alertStore struct {
+	mu    sync.Mutex
+	rules []AlertRule
+}
+
+func (s *alertStore) GetAlertRules() ([]AlertRule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]AlertRule(nil), s.rules...), nil
+}
+
+func (s *alertStore) deleteRules() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rules = nil
+}
+
+// chatReceiver records the generic payloads posted to it.
+type chatReceiver struct {
+	*httptest.Server
+	posts chan genericPayload
+}
+
+type genericPayload struct {
+	Text  string       `json:"text"`
+	Total int          `json:"total"`
+	Jobs  []genericJob `json:"jobs"`
+}
+
+func newChatReceiver(t *testing.T) *chatReceiver {
+	rec := &chatReceiver{posts: make(chan genericPayload, 10)}
+	rec.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload genericPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("invalid payload: %v", err)
+		}
+		rec.posts <- payload
+	}))
+	t.Cleanup(rec.Close)
+	return rec
+}
+
+// expectPost returns the next payload posted within a second.
+func (rec *chatReceiver) expectPost(t *testing.T) genericPayload {
+	t.Helper()
+	select {
+	case payload := <-rec.posts:
+		return payload
+	case <-time.After(time.Second):
+		t.Fatal("no alert posted")
+		return genericPayload{}
+	}
+}
+
+// expectNoPost fails if a payload is posted within wait.
+func (rec *chatReceiver) expectNoPost(t *testing.T, wait time.Duration) {
+	t.Helper()
+	select {
+	case payload := <-rec.posts:
+		t.Fatalf("unexpected alert %q", payload.Text)
+	case <-time.After(wait):
+	}
+}
+
+func newTestAlerter(t *testing.T, config AlertConfig) (*Alerter, *alertStore, *chatReceiver) {
+	rec := newChatReceiver(t)
+	store := &alertStore{rules: []AlertRule{{ID: "r", Name: "Engineers", URL: rec.URL, Format: FormatGeneric}}}
+	if config.BatchWindow == 0 {
+		config.BatchWindow = 20 * time.Millisecond
+	}
+	return NewAlerter(store, config, log.New(io.Discard, "", 0)), store, rec
+}
+
+func TestAlerterBatchesJobs(t *testing.T) {
+	a, _, rec := newTestAlerter(t, AlertConfig{MaxJobsPerMessage: 2})
+
+	a.JobsSaved([]scraper.JobPosting{posting("1", "Acme", "Engineer"), posting("2", "Acme", "Engineer")})
+	a.JobsSaved([]scraper.JobPosting{posting("3", "Globex", "Engineer")})
+
+	payload := rec.expectPost(t)
+	if payload.Total != 3 || len(payload.Jobs) != 2 || payload.Jobs[0].ID != "1" {
+		t.Errorf("posted %d of %d jobs, want the first 2 of 3", len(payload.Jobs), payload.Total)
+	}
+	rec.expectNoPost(t, 50*time.Millisecond)
+}
+
+func TestAlerterRateLimit(t *testing.T) {
+	a, _, rec := newTestAlerter(t, AlertConfig{MaxMessagesPerHour: 2, MaxPending: 2})
+
+	for i := 0; i < 2; i++ {
+		a.JobsSaved([]scraper.JobPosting{posting("1", "Acme", "Engineer")})
+		rec.expectPost(t)
+	}
+
+	// The third batch waits for the oldest message to leave the hour, and
+	// counts the jobs beyond MaxPending.
+	a.JobsSaved([]scraper.JobPosting{posting("2", "Acme", "Engineer"), posting("3", "Acme", "Engineer"), posting("4", "Acme", "Engineer")})
+	rec.expectNoPost(t, 100*time.Millisecond)
+
+	a.mu.Lock()
+	state := a.states["r"]
+	if state.timer == nil || len(state.pending) != 2 || state.dropped != 1 {
+		t.Errorf("rate limited state: timer %v, %d pending, %d dropped", state.timer != nil, len(state.pending), state.dropped)
+	}
+	// Move the sent messages back an hour, as if it had passed.
+	for i := range state.sent {
+		state.sent[i] = state.sent[i].Add(-time.Hour)
+	}
+	state.timer.Stop()
+	a.mu.Unlock()
+
+	a.flush("r")
+	if payload := rec.expectPost(t); payload.Total != 3 || len(payload.Jobs) != 2 {
+		t.Errorf("posted %d of %d jobs, want 2 of 3", len(payload.Jobs), payload.Total)
+	}
+}
+
+func TestAlerterDropsDeletedRules(t *testing.T) {
+	a, store, rec := newTestAlerter(t, AlertConfig{BatchWindow: 50 * time.Millisecond})
+
+	a.JobsSaved([]scraper.JobPosting{posting("1", "Acme", "Engineer")})
+	store.deleteRules()
+	rec.expectNoPost(t, 150*time.Millisecond)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.states["r"]; ok {
+		t.Error("state of the deleted rule kept")
+	}
+}
@@ -0,0 +1,213 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ayagmar/gojobscraper/internal/scraper"
+)
+
+// ChatFormat is the payload format of a chat incoming webhook
+// @Description Chat webhook payload format
+type ChatFormat string
+
+const (
+	// FormatSlack posts Block Kit messages to Slack incoming webhooks.
+	FormatSlack ChatFormat = "slack"
+	// FormatDiscord posts embeds to Discord webhooks.
+	FormatDiscord ChatFormat = "discord"
+	// FormatGeneric posts a JSON object with a markdown text field, accepted
+	// by Teams and Mattermost incoming webhooks, along with the jobs.
+	FormatGeneric ChatFormat = "generic"
+)
+
+// ValidChatFormat reports whether f is a known chat format.
+func ValidChatFormat(f ChatFormat) bool {
+	return f == FormatSlack || f == FormatDiscord || f == FormatGeneric
+}
+
+const (
+	// discordMaxEmbeds is the number of embeds Discord accepts in one
+	// message.
+	discordMaxEmbeds = 10
+	// slackMaxHeader is the length Slack accepts for the text of header
+	// blocks.
+	slackMaxHeader = 150
+)
+
+// ChatMessage is an alert about the new jobs matching a rule. Jobs are the
+// jobs listed in the message; Total also counts the ones left out.
+type ChatMessage struct {
+	Rule  AlertRule
+	Jobs  []scraper.JobPosting
+	Total int
+}
+
+// Headline summarizes the message in one line.
+func (m ChatMessage) Headline() string {
+	jobs := "jobs"
+	if m.Total == 1 {
+		jobs = "job"
+	}
+	return fmt.Sprintf("%d new %s for %s", m.Total, jobs, m.Rule.Name)
+}
+
+func (m ChatMessage) omitted() int {
+	return m.Total - len(m.Jobs)
+}
+
+// Payload encodes the message in the given format.
+func (m ChatMessage) Payload(format ChatFormat) ([]byte, error) {
+	var payload interface{}
+	switch format {
+	case FormatSlack:
+		payload = m.slack()
+	case FormatDiscord:
+		payload = m.discord()
+	case FormatGeneric:
+		payload = m.generic()
+	default:
+		return nil, fmt.Errorf("unknown chat format %q", format)
+	}
+	return json.Marshal(payload)
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Elements []slackText `json:"elements,omitempty"`
+}
+
+func (m ChatMessage) slack() interface{} {
+	// Slack rejects the whole message when a header is too long, which long
+	// rule names would make it.
+	header := truncate(m.Headline(), slackMaxHeader)
+	blocks := []slackBlock{{Type: "header", Text: &slackText{Type: "plain_text", Text: header}}}
+	for _, job := range m.Jobs {
+		text := fmt.Sprintf("*<%s|%s>*", job.URL, slackEscape(job.Title))
+		if line := jobLine(job); line != "" {
+			text += "\n" + slackEscape(line)
+		}
+		blocks = append(blocks, slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: text}})
+	}
+	if omitted := m.omitted(); omitted > 0 {
+		blocks = append(blocks, slackBlock{
+			Type:     "context",
+			Elements: []slackText{{Type: "mrkdwn", Text: fmt.Sprintf("…and %d more", omitted)}},
+		})
+	}
+
+	return struct {
+		Text   string       `json:"text"`
+		Blocks []slackBlock `json:"blocks"`
+	}{Text: m.Headline(), Blocks: blocks}
+}
+
+// slackEscape escapes the characters Slack reserves for links and mentions.
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+type discordEmbed struct {
+	Title       string `json:"title"`
+	URL         string `json:"url,omitempty"`
+	Description string `json:"description,omitempty"`
+	Footer      *struct {
+		Text string `json:"text"`
+	} `json:"footer,omitempty"`
+}
+
+func (m ChatMessage) discord() interface{} {
+	jobs := m.Jobs
+	if len(jobs) > discordMaxEmbeds {
+		jobs = jobs[:discordMaxEmbeds]
+	}
+
+	embeds := make([]discordEmbed, 0, len(jobs))
+	for _, job := range jobs {
+		embed := discordEmbed{Title: truncate(job.Title, 256), URL: job.URL, Description: jobLine(job)}
+		if job.Source != "" {
+			embed.Footer = &struct {
+				Text string `json:"text"`
+			}{Text: string(job.Source)}
+		}
+		embeds = append(embeds, embed)
+	}
+
+	content := "**" + m.Headline() + "**"
+	if omitted := m.Total - len(jobs); omitted > 0 {
+		content += fmt.Sprintf("\n…and %d more", omitted)
+	}
+
+	return struct {
+		Content string         `json:"content"`
+		Embeds  []discordEmbed `json:"embeds"`
+	}{Content: content, Embeds: embeds}
+}
+
+type genericJob struct {
+	ID       string              `json:"id"`
+	Title    string              `json:"title"`
+	Company  string              `json:"company,omitempty"`
+	Location string              `json:"location,omitempty"`
+	URL      string              `json:"url"`
+	Source   scraper.ScraperType `json:"source"`
+}
+
+func (m ChatMessage) generic() interface{} {
+	var text strings.Builder
+	text.WriteString("**" + m.Headline() + "**\n\n")
+	jobs := make([]genericJob, 0, len(m.Jobs))
+	for _, job := range m.Jobs {
+		fmt.Fprintf(&text, "- [%s](%s)", job.Title, job.URL)
+		if line := jobLine(job); line != "" {
+			text.WriteString(" · " + line)
+		}
+		text.WriteString("\n")
+
+		jobs = append(jobs, genericJob{
+			ID:       job.ID,
+			Title:    job.Title,
+			Company:  job.CompanyDetails.Company,
+			Location: job.Location,
+			URL:      job.URL,
+			Source:   job.Source,
+		})
+	}
+	if omitted := m.omitted(); omitted > 0 {
+		fmt.Fprintf(&text, "\n…and %d more\n", omitted)
+	}
+
+	return struct {
+		Text  string       `json:"text"`
+		Rule  string       `json:"rule"`
+		Total int          `json:"total"`
+		Jobs  []genericJob `json:"jobs"`
+	}{Text: text.String(), Rule: m.Rule.Name, Total: m.Total, Jobs: jobs}
+}
+
+// jobLine returns the company and location of job.
+func jobLine(job scraper.JobPosting) string {
+	parts := make([]string, 0, 2)
+	if job.CompanyDetails.Company != "" {
+		parts = append(parts, job.CompanyDetails.Company)
+	}
+	if job.Location != "" {
+		parts = append(parts, job.Location)
+	}
+	return strings.Join(parts, " · ")
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
@@ -0,0 +1,135 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/ayagmar/gojobscraper/internal/scraper"
+)
+
+func chatMessage(jobs, total int) ChatMessage {
+	msg := ChatMessage{Rule: AlertRule{ID: "r", Name: "Go jobs in Berlin"}, Total: total}
+	for i := 0; i < jobs; i++ {
+		job := posting(fmt.Sprint(i), "Acme & Sons", fmt.Sprintf("Engineer <%d>", i))
+		job.Location = "Berlin"
+		msg.Jobs = append(msg.Jobs, job)
+	}
+	return msg
+}
+
+func TestHeadline(t *testing.T) {
+	if got := chatMessage(1, 1).Headline(); got != "1 new job for Go jobs in Berlin" {
+		t.Errorf("headline %q", got)
+	}
+	if got := chatMessage(2, 12).Headline(); got != "12 new jobs for Go jobs in Berlin" {
+		t.Errorf("headline %q", got)
+	}
+}
+
+func TestSlackPayload(t *testing.T) {
+	payload, err := chatMessage(2, 5).Payload(FormatSlack)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got struct {
+		Text   string       `json:"text"`
+		Blocks []slackBlock `json:"blocks"`
+	}
+	if err := json.Unmarshal(payload, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Text != "5 new jobs for Go jobs in Berlin" || len(got.Blocks) != 4 {
+		t.Fatalf("payload %s", payload)
+	}
+	if header := got.Blocks[0]; header.Type != "header" || header.Text.Type != "plain_text" || header.Text.Text != got.Text {
+		t.Errorf("header block %+v", header)
+	}
+	if section := got.Blocks[1].Text.Text; section != "*<https://example.com/jobs/0|Engineer &lt;0&gt;>*\nAcme &amp; Sons · Berlin" {
+		t.Errorf("job section %q", section)
+	}
+	if context := got.Blocks[3]; context.Type != "context" || context.Elements[0].Text != "…and 3 more" {
+		t.Errorf("context block %+v", context)
+	}
+}
+
+func TestSlackHeaderTruncated(t *testing.T) {
+	msg := chatMessage(1, 1)
+	msg.Rule.Name = strings.Repeat("é", 200)
+
+	payload, err := msg.Payload(FormatSlack)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Blocks []slackBlock `json:"blocks"`
+	}
+	if err := json.Unmarshal(payload, &got); err != nil {
+		t.Fatal(err)
+	}
+	header := got.Blocks[0].Text.Text
+	if n := utf8.RuneCountInString(header); n != slackMaxHeader {
+		t.Errorf("header of %d characters, want %d", n, slackMaxHeader)
+	}
+	if !strings.HasPrefix(header, "1 new job for é") || !strings.HasSuffix(header, "…") {
+		t.Errorf("header %q", header)
+	}
+}
+
+func TestDiscordPayload(t *testing.T) {
+	payload, err := chatMessage(12, 15).Payload(FormatDiscord)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got struct {
+		Content string         `json:"content"`
+		Embeds  []discordEmbed `json:"embeds"`
+	}
+	if err := json.Unmarshal(payload, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Content != "**15 new jobs for Go jobs in Berlin**\n…and 5 more" {
+		t.Errorf("content %q", got.Content)
+	}
+	if len(got.Embeds) != discordMaxEmbeds {
+		t.Fatalf("%d embeds, want %d", len(got.Embeds), discordMaxEmbeds)
+	}
+	embed := got.Embeds[0]
+	if embed.Title != "Engineer <0>" || embed.URL != "https://example.com/jobs/0" || embed.Description != "Acme & Sons · Berlin" || embed.Footer == nil || embed.Footer.Text != "indeed" {
+		t.Errorf("embed %+v", embed)
+	}
+}
+
+func TestGenericPayload(t *testing.T) {
+	payload, err := chatMessage(1, 2).Payload(FormatGeneric)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got struct {
+		Text  string       `json:"text"`
+		Rule  string       `json:"rule"`
+		Total int          `json:"total"`
+		Jobs  []genericJob `json:"jobs"`
+	}
+	if err := json.Unmarshal(payload, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := "**2 new jobs for Go jobs in Berlin**\n\n- [Engineer <0>](https://example.com/jobs/0) · Acme & Sons · Berlin\n\n…and 1 more\n"
+	if got.Text != want {
+		t.Errorf("text %q, want %q", got.Text, want)
+	}
+	if got.Rule != "Go jobs in Berlin" || got.Total != 2 || len(got.Jobs) != 1 || got.Jobs[0].Company != "Acme & Sons" || got.Jobs[0].Source != scraper.Indeed {
+		t.Errorf("payload %s", payload)
+	}
+}
+
+func TestUnknownChatFormat(t *testing.T) {
+	if _, err := chatMessage(1, 1).Payload("teams"); err == nil {
+		t.Error("unknown format encoded")
+	}
+}
@@ -0,0 +1,262 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"net/url"
+	"sort"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/ayagmar/gojobscraper/internal/filter"
+	"github.com/ayagmar/gojobscraper/internal/scraper"
+)
+
+const (
+	// maxDigestJobs is the number of jobs listed in a single digest. Further
+	// jobs are only counted.
+	maxDigestJobs = 200
+	// digestRetryDelay is the delay before retrying a digest that failed to
+	// send. It doubles with every failure in a row.
+	digestRetryDelay = 5 * time.Minute
+)
+
+// SubscriberStore persists digest subscribers and provides the jobs their
+// digests are built from.
+type SubscriberStore interface {
+	// GetDueSubscribers returns the subscribed subscribers whose next digest
+	// is due at now.
+	GetDueSubscribers(now time.Time) ([]Subscriber, error)
+	SaveSubscriber(subscriber Subscriber) error
+	// GetJobsSince returns the first limit visible jobs matching jobFilter
+	// first seen after since, oldest first, and the number of those jobs.
+	GetJobsSince(since time.Time, jobFilter filter.JobFilter, limit int) ([]scraper.JobPosting, int, error)
+}
+
+// Digest lists the new jobs of a subscriber grouped by company.
+type Digest struct {
+	Subscriber     Subscriber
+	Since          time.Time
+	Companies      []CompanyJobs
+	Total          int
+	Omitted        int
+	UnsubscribeURL string
+}
+
+// CompanyJobs are the jobs of a digest posted by one company.
+type CompanyJobs struct {
+	Company string
+	Jobs    []scraper.JobPosting
+}
+
+// BuildDigest selects the jobs matching the subscriber's filter and groups
+// them by company, companies sorted by name.
+func BuildDigest(subscriber Subscriber, jobs []scraper.JobPosting, since time.Time, unsubscribeURL string) Digest {
+	digest := Digest{Subscriber: subscriber, Since: since, UnsubscribeURL: unsubscribeURL}
+
+	byCompany := make(map[string][]scraper.JobPosting)
+	for _, job := range jobs {
+		if !subscriber.Filter.Match(job) {
+			continue
+		}
+		digest.Total++
+		if digest.Total > maxDigestJobs {
+			digest.Omitted++
+			continue
+		}
+		company := job.CompanyDetails.Company
+		if company == "" {
+			company = "Unknown company"
+		}
+		byCompany[company] = append(byCompany[company], job)
+	}
+
+	for company, companyJobs := range byCompany {
+		digest.Companies = append(digest.Companies, CompanyJobs{Company: company, Jobs: companyJobs})
+	}
+	sort.Slice(digest.Companies, func(i, j int) bool {
+		return digest.Companies[i].Company < digest.Companies[j].Company
+	})
+
+	return digest
+}
+
+// Subject returns the subject line of the digest email.
+func (d Digest) Subject() string {
+	if d.Total == 1 {
+		return "1 new job matching your search"
+	}
+	return fmt.Sprintf("%d new jobs matching your search", d.Total)
+}
+
+// Message renders the digest as an email to its subscriber.
+func (d Digest) Message() (Message, error) {
+	var text, html bytes.Buffer
+	if err := digestText.Execute(&text, d); err != nil {
+		return Message{}, fmt.Errorf("failed to render text digest: %w", err)
+	}
+	if err := digestHTML.Execute(&html, d); err != nil {
+		return Message{}, fmt.Errorf("failed to render HTML digest: %w", err)
+	}
+
+	return Message{
+		To:      d.Subscriber.Email,
+		Subject: d.Subject(),
+		Text:    text.String(),
+		HTML:    html.String(),
+		// Mail clients unsubscribe in one click by POSTing to the link
+		// (RFC 8058).
+		Headers: map[string]string{
+			"List-Unsubscribe":      "<" + d.UnsubscribeURL + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	}, nil
+}
+
+var digestText = texttemplate.Must(texttemplate.New("digest").Parse(`Hello{{with .Subscriber.Name}} {{.}}{{end}},
+
+{{.Total}} new job{{if ne .Total 1}}s{{end}} matching your search since {{.Since.Format "Jan 2, 2006 15:04 MST"}}.
+{{range .Companies}}
+{{.Company}}
+{{range .Jobs}}  - {{.Title}}{{with .Location}} ({{.}}){{end}}
+    {{.URL}}
+{{end}}{{end}}{{if .Omitted}}
+...and {{.Omitted}} more.
+{{end}}
+Unsubscribe: {{.UnsubscribeURL}}
+`))
+
+var digestHTML = htmltemplate.Must(htmltemplate.New("digest").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif; color: #222;">
+<p>Hello{{with .Subscriber.Name}} {{.}}{{end}},</p>
+<p>{{.Total}} new job{{if ne .Total 1}}s{{end}} matching your search since {{.Since.Format "Jan 2, 2006 15:04 MST"}}.</p>
+{{range .Companies}}
+<h3>{{.Company}}</h3>
+<ul>
+{{range .Jobs}}<li><a href="{{.URL}}">{{.Title}}</a>{{with .Location}} &middot; {{.}}{{end}}</li>
+{{end}}</ul>
+{{end}}{{if .Omitted}}<p>&hellip;and {{.Omitted}} more.</p>
+{{end}}<p style="font-size: small; color: #888;"><a href="{{.UnsubscribeURL}}">Unsubscribe</a></p>
+</body>
+</html>
+`))
+
+// Digester sends the digests of subscribers when they are due.
+type Digester struct {
+	store   SubscriberStore
+	mailer  Mailer
+	baseURL string
+	logger  *log.Logger
+}
+
+// NewDigester creates a digester. baseURL is the public URL of the API, used
+// to build unsubscribe links.
+func NewDigester(store SubscriberStore, mailer Mailer, baseURL string, logger *log.Logger) *Digester {
+	return &Digester{store: store, mailer: mailer, baseURL: baseURL, logger: logger}
+}
+
+// Run sends due digests every interval until ctx is done.
+func (d *Digester) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			d.SendDue(now)
+		}
+	}
+}
+
+// SendDue sends the digests due at now.
+func (d *Digester) SendDue(now time.Time) {
+	subscribers, err := d.store.GetDueSubscribers(now)
+	if err != nil {
+		d.logger.Printf("Error loading due digest subscribers: %v", err)
+		return
+	}
+
+	for _, subscriber := range subscribers {
+		if _, err := d.Send(subscriber, now); err != nil {
+			d.logger.Printf("Error sending digest to subscriber %s: %v", subscriber.ID, err)
+			d.retryLater(subscriber, now)
+		}
+	}
+}
+
+// retryLater schedules the retry of a digest that failed to send at now. The
+// delay doubles with every failure in a row. A digest whose retry would come
+// after the next scheduled digest is skipped: its jobs are sent with that
+// digest instead.
+func (d *Digester) retryLater(subscriber Subscriber, now time.Time) {
+	subscriber.FailedDigests++
+	next := subscriber.Schedule.Next(now)
+
+	delay := digestRetryDelay
+	for i := 1; i < subscriber.FailedDigests && now.Add(delay).Before(next); i++ {
+		delay *= 2
+	}
+	if retry := now.Add(delay); retry.Before(next) {
+		next = retry
+	}
+
+	subscriber.NextDigestAt = next
+	if err := d.store.SaveSubscriber(subscriber); err != nil {
+		d.logger.Printf("Error scheduling digest retry of subscriber %s: %v", subscriber.ID, err)
+	}
+}
+
+// Send emails the subscriber the jobs first seen since its last digest and
+// schedules its next digest. No email is sent when there are no new jobs. It
+// returns the number of new jobs.
+func (d *Digester) Send(subscriber Subscriber, now time.Time) (int, error) {
+	since := subscriber.CreatedAt
+	if subscriber.LastDigestAt != nil {
+		since = *subscriber.LastDigestAt
+	}
+
+	jobs, total, err := d.store.GetJobsSince(since, subscriber.Filter, maxDigestJobs)
+	if err != nil {
+		return 0, err
+	}
+
+	digest := BuildDigest(subscriber, jobs, since, d.UnsubscribeURL(subscriber))
+	// The jobs past the limit were only counted.
+	if more := total - len(jobs); more > 0 {
+		digest.Total += more
+		digest.Omitted += more
+	}
+	if digest.Total > 0 {
+		msg, err := digest.Message()
+		if err != nil {
+			return 0, err
+		}
+		if err := d.mailer.Send(msg); err != nil {
+			return 0, err
+		}
+		d.logger.Printf("Sent digest of %d jobs to subscriber %s", digest.Total, subscriber.ID)
+	}
+
+	subscriber.LastDigestAt = &now
+	subscriber.NextDigestAt = subscriber.Schedule.Next(now)
+	subscriber.FailedDigests = 0
+	if err := d.store.SaveSubscriber(subscriber); err != nil {
+		return digest.Total, err
+	}
+	return digest.Total, nil
+}
+
+// UnsubscribeURL returns the link that unsubscribes subscriber.
+func (d *Digester) UnsubscribeURL(subscriber Subscriber) string {
+	return d.baseURL + "/api/v1/subscribers/unsubscribe?token=" + url.QueryEscape(subscriber.UnsubscribeToken)
+}
@@ -0,0 +1,247 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ayagmar/gojobscraper/internal/filter"
+	"github.com/ayagmar/gojobscraper/internal/scraper"
+)
+
+func posting(id, company, title string) scraper.JobPosting {
+	job := scraper.JobPosting{ID: id, Title: title, URL: "https://example.com/jobs/" + id, Source: scraper.Indeed}
+	job.CompanyDetails.Company = company
+	return job
+}
+
+func TestBuildDigest(t *testing.T) {
+	subscriber := Subscriber{Email: "ada@example.com", Filter: filter.JobFilter{Keyword: "engineer"}}
+	jobs := []scraper.JobPosting{
+		posting("1", "Globex", "Backend Engineer"),
+		posting("2", "Acme", "Platform Engineer"),
+		posting("3", "Acme", "Office Manager"),
+		posting("4", "", "Data Engineer"),
+		posting("5", "Acme", "Frontend Engineer"),
+	}
+
+	digest := BuildDigest(subscriber, jobs, time.Time{}, "https://example.com/unsubscribe")
+	if digest.Total != 4 || digest.Omitted != 0 {
+		t.Errorf("total %d, omitted %d, want 4 and 0", digest.Total, digest.Omitted)
+	}
+
+	var got []string
+	for _, company := range digest.Companies {
+		var ids []string
+		for _, job := range company.Jobs {
+			ids = append(ids, job.ID)
+		}
+		got = append(got, company.Company+":"+strings.Join(ids, ","))
+	}
+	want := []string{"Acme:2,5", "Globex:1", "Unknown company:4"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("companies %v, want %v", got, want)
+	}
+
+	msg, err := digest.Message()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Headers["List-Unsubscribe"] != "<https://example.com/unsubscribe>" || msg.Headers["List-Unsubscribe-Post"] != "List-Unsubscribe=One-Click" {
+		t.Errorf("unsubscribe headers %v", msg.Headers)
+	}
+}
+
+func TestBuildDigestCap(t *testing.T) {
+	var jobs []scraper.JobPosting
+	for i := 0; i < maxDigestJobs+25; i++ {
+		jobs = append(jobs, posting(fmt.Sprint(i), []string{"Acme", "Globex"}[i%2], "Engineer"))
+	}
+
+	digest := BuildDigest(Subscriber{}, jobs, time.Time{}, "")
+	if digest.Total != maxDigestJobs+25 || digest.Omitted != 25 {
+		t.Errorf("total %d, omitted %d, want %d and 25", digest.Total, digest.Omitted, maxDigestJobs+25)
+	}
+	listed := 0
+	for _, company := range digest.Companies {
+		listed += len(company.Jobs)
+	}
+	if listed != maxDigestJobs {
+		t.Errorf("%d jobs listed, want %d", listed, maxDigestJobs)
+	}
+
+	msg, err := digest.Message()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(msg.Text, "...and 25 more.") {
+		t.Error("text digest does not mention the omitted jobs")
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+
+	// Wednesday, March 6, 2024.
+	wednesday := time.Date(2024, time.March, 6, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		schedule Schedule
+		from     time.Time
+		want     time.Time
+	}{
+		{"hourly", Schedule{Frequency: Hourly}, wednesday, time.Date(2024, time.March, 6, 11, 0, 0, 0, time.UTC)},
+		{"hourly on the hour", Schedule{Frequency: Hourly}, time.Date(2024, time.March, 6, 11, 0, 0, 0, time.UTC), time.Date(2024, time.March, 6, 12, 0, 0, 0, time.UTC)},
+		{"daily later today", Schedule{Frequency: Daily, Hour: 18}, wednesday, time.Date(2024, time.March, 6, 18, 0, 0, 0, time.UTC)},
+		{"daily tomorrow", Schedule{Frequency: Daily, Hour: 8}, wednesday, time.Date(2024, time.March, 7, 8, 0, 0, 0, time.UTC)},
+		{"daily at the hour", Schedule{Frequency: Daily, Hour: 10}, time.Date(2024, time.March, 6, 10, 0, 0, 0, time.UTC), time.Date(2024, time.March, 7, 10, 0, 0, 0, time.UTC)},
+		{"daily across months", Schedule{Frequency: Daily, Hour: 8}, time.Date(2024, time.February, 29, 9, 0, 0, 0, time.UTC), time.Date(2024, time.March, 1, 8, 0, 0, 0, time.UTC)},
+		{"weekly later this week", Schedule{Frequency: Weekly, Hour: 9, Weekday: time.Friday}, wednesday, time.Date(2024, time.March, 8, 9, 0, 0, 0, time.UTC)},
+		{"weekly next week", Schedule{Frequency: Weekly, Hour: 9, Weekday: time.Monday}, wednesday, time.Date(2024, time.March, 11, 9, 0, 0, 0, time.UTC)},
+		{"weekly same day, passed hour", Schedule{Frequency: Weekly, Hour: 9, Weekday: time.Wednesday}, wednesday, time.Date(2024, time.March, 13, 9, 0, 0, 0, time.UTC)},
+		{"daily in a time zone", Schedule{Frequency: Daily, Hour: 8, Timezone: "Europe/Paris"}, wednesday, time.Date(2024, time.March, 7, 8, 0, 0, 0, paris)},
+		{"date in the time zone", Schedule{Frequency: Daily, Hour: 20, Timezone: "America/New_York"}, time.Date(2024, time.March, 7, 0, 30, 0, 0, time.UTC), time.Date(2024, time.March, 6, 20, 0, 0, 0, newYork)},
+		{"weekday in the time zone", Schedule{Frequency: Weekly, Hour: 1, Weekday: time.Thursday, Timezone: "Europe/Paris"}, time.Date(2024, time.March, 6, 23, 30, 0, 0, time.UTC), time.Date(2024, time.March, 7, 1, 0, 0, 0, paris)},
+		{"daylight saving change", Schedule{Frequency: Daily, Hour: 8, Timezone: "Europe/Paris"}, time.Date(2024, time.March, 30, 12, 0, 0, 0, time.UTC), time.Date(2024, time.March, 31, 6, 0, 0, 0, time.UTC)},
+		{"unknown time zone", Schedule{Frequency: Daily, Hour: 8, Timezone: "Mars/Olympus"}, wednesday, time.Date(2024, time.March, 7, 8, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got := tt.schedule.Next(tt.from)
+		if !got.Equal(tt.want) {
+			t.Errorf("%s: Next(%s) = %s, want %s", tt.name, tt.from, got, tt.want)
+		}
+		if tt.schedule.Frequency == Weekly && got.Weekday() != tt.schedule.Weekday {
+			t.Errorf("%s: Next(%s) on %s, want %s", tt.name, tt.from, got.Weekday(), tt.schedule.Weekday)
+		}
+	}
+}
+
+// digestStore serves the jobs of a digest and records the saved subscribers.
+type digestStore struct {
+	jobs  []scraper.JobPosting
+	saved []Subscriber
+}
+
+func (s *digestStore) GetDueSubscribers(now time.Time) ([]Subscriber, error) {
+	var due []Subscriber
+	for _, subscriber := range s.saved {
+		if !subscriber.NextDigestAt.After(now) {
+			due = append(due, subscriber)
+		}
+	}
+	return due, nil
+}
+
+func (s *digestStore) SaveSubscriber(subscriber Subscriber) error {
+	s.saved = []Subscriber{subscriber}
+	return nil
+}
+
+func (s *digestStore) GetJobsSince(since time.Time, jobFilter filter.JobFilter, limit int) ([]scraper.JobPosting, int, error) {
+	var jobs []scraper.JobPosting
+	for _, job := range s.jobs {
+		if jobFilter.Match(job) {
+			jobs = append(jobs, job)
+		}
+	}
+	total := len(jobs)
+	if len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+	return jobs, total, nil
+}
+
+type fakeMailer struct {
+	err  error
+	sent []Message
+}
+
+func (m *fakeMailer) Send(msg Message) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func TestDigesterSend(t *testing.T) {
+	store := &digestStore{}
+	for i := 0; i < maxDigestJobs+10; i++ {
+		store.jobs = append(store.jobs, posting(fmt.Sprint(i), "Acme", "Engineer"))
+	}
+	mailer := &fakeMailer{}
+	d := NewDigester(store, mailer, "https://jobs.example.com", log.New(io.Discard, "", 0))
+
+	now := time.Date(2024, time.March, 6, 8, 0, 0, 0, time.UTC)
+	subscriber := Subscriber{ID: "s", Email: "ada@example.com", Schedule: Schedule{Frequency: Daily, Hour: 8}, FailedDigests: 2, UnsubscribeToken: "t"}
+	total, err := d.Send(subscriber, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != maxDigestJobs+10 || len(mailer.sent) != 1 {
+		t.Fatalf("sent %d emails of %d jobs, want one of %d", len(mailer.sent), total, maxDigestJobs+10)
+	}
+	if !strings.Contains(mailer.sent[0].Text, "...and 10 more.") {
+		t.Error("digest does not mention the jobs past the limit")
+	}
+
+	saved := store.saved[0]
+	if saved.LastDigestAt == nil || !saved.LastDigestAt.Equal(now) || !saved.NextDigestAt.Equal(now.AddDate(0, 0, 1)) || saved.FailedDigests != 0 {
+		t.Errorf("saved subscriber %+v", saved)
+	}
+}
+
+func TestDigesterRetriesFailedSends(t *testing.T) {
+	store := &digestStore{jobs: []scraper.JobPosting{posting("1", "Acme", "Engineer")}}
+	mailer := &fakeMailer{err: errors.New("connection refused")}
+	d := NewDigester(store, mailer, "https://jobs.example.com", log.New(io.Discard, "", 0))
+
+	now := time.Date(2024, time.March, 6, 8, 0, 0, 0, time.UTC)
+	store.saved = []Subscriber{{ID: "s", Schedule: Schedule{Frequency: Daily, Hour: 8}, NextDigestAt: now}}
+
+	// Retries double from digestRetryDelay until they would come after the
+	// next scheduled digest.
+	for _, wait := range []time.Duration{5 * time.Minute, 10 * time.Minute, 20 * time.Minute} {
+		d.SendDue(now)
+		next := store.saved[0].NextDigestAt
+		if got := next.Sub(now); got != wait {
+			t.Fatalf("retry after %s, want %s", got, wait)
+		}
+		now = next
+	}
+	nextDigest := time.Date(2024, time.March, 7, 8, 0, 0, 0, time.UTC)
+	for i := 0; i < 10 && now.Before(nextDigest); i++ {
+		d.SendDue(now)
+		now = store.saved[0].NextDigestAt
+	}
+	if !now.Equal(nextDigest) {
+		t.Fatalf("digest retried at %s, want it skipped to the next scheduled digest at %s", now, nextDigest)
+	}
+	// After as many failures, further failed digests are skipped at once.
+	d.SendDue(now)
+	if next := store.saved[0].NextDigestAt; !next.Equal(nextDigest.AddDate(0, 0, 1)) {
+		t.Errorf("digest retried at %s, want the following scheduled digest", next)
+	}
+	now = store.saved[0].NextDigestAt
+	if store.saved[0].LastDigestAt != nil {
+		t.Error("failed digest recorded as sent")
+	}
+
+	mailer.err = nil
+	d.SendDue(now)
+	if len(mailer.sent) != 1 || store.saved[0].FailedDigests != 0 {
+		t.Errorf("sent %d emails, %d failed digests, want the digest sent", len(mailer.sent), store.saved[0].FailedDigests)
+	}
+}
@@ -0,0 +1,149 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Message is an email with a plain text and an HTML body.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+	// Headers are added to the standard headers of the message.
+	Headers map[string]string
+}
+
+// Mailer sends email messages.
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// SMTPConfig configures the SMTP server digests are sent through.
+type SMTPConfig struct {
+	Host string
+	Port int
+	// Username and Password enable PLAIN authentication when set. Go only
+	// sends them over TLS or to localhost.
+	Username string
+	Password string
+	From     string
+}
+
+// SMTPMailer sends messages through an SMTP server, upgrading to TLS when
+// the server offers STARTTLS.
+type SMTPMailer struct {
+	config SMTPConfig
+}
+
+// NewSMTPMailer creates a mailer for the given server.
+func NewSMTPMailer(config SMTPConfig) (*SMTPMailer, error) {
+	if config.Host == "" {
+		return nil, fmt.Errorf("missing SMTP host")
+	}
+	if config.Port == 0 {
+		config.Port = 25
+	}
+	if _, err := mail.ParseAddress(config.From); err != nil {
+		return nil, fmt.Errorf("invalid SMTP from address: %w", err)
+	}
+	return &SMTPMailer{config: config}, nil
+}
+
+func (m *SMTPMailer) Send(msg Message) error {
+	from, _ := mail.ParseAddress(m.config.From)
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient: %w", err)
+	}
+
+	body, err := buildMessage(m.config.From, msg)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if m.config.Username != "" {
+		auth = smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)
+	}
+
+	addr := net.JoinHostPort(m.config.Host, strconv.Itoa(m.config.Port))
+	if err := smtp.SendMail(addr, auth, from.Address, []string{to.Address}, body); err != nil {
+		return fmt.Errorf("failed to send email to %s: %w", to.Address, err)
+	}
+	return nil
+}
+
+// buildMessage encodes msg as a multipart/alternative MIME message.
+func buildMessage(from string, msg Message) ([]byte, error) {
+	boundary, err := randomBoundary()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	headers := map[string]string{
+		"From":         from,
+		"To":           msg.To,
+		"Subject":      mime.QEncoding.Encode("utf-8", msg.Subject),
+		"Date":         time.Now().Format(time.RFC1123Z),
+		"MIME-Version": "1.0",
+		"Content-Type": fmt.Sprintf("multipart/alternative; boundary=%q", boundary),
+	}
+	for key, value := range msg.Headers {
+		headers[key] = value
+	}
+	for _, key := range []string{"From", "To", "Subject", "Date", "MIME-Version", "Content-Type"} {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, headers[key])
+		delete(headers, key)
+	}
+	extra := make([]string, 0, len(headers))
+	for key := range headers {
+		extra = append(extra, key)
+	}
+	sort.Strings(extra)
+	for _, key := range extra {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, headers[key])
+	}
+	buf.WriteString("\r\n")
+
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain", msg.Text},
+		{"text/html", msg.HTML},
+	} {
+		fmt.Fprintf(&buf, "--%s\r\n", boundary)
+		fmt.Fprintf(&buf, "Content-Type: %s; charset=utf-8\r\n", part.contentType)
+		buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+		writer := quotedprintable.NewWriter(&buf)
+		if _, err := writer.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+		buf.WriteString("\r\n")
+	}
+	fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+
+	return buf.Bytes(), nil
+}
+
+func randomBoundary() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate MIME boundary: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
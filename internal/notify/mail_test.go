@@ -0,0 +1,198 @@
+package notify
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+func TestBuildMessage(t *testing.T) {
+	msg := Message{
+		To:      "ada@example.com",
+		Subject: "3 new jobs – Zürich",
+		Text:    "Hello Ada,\n\nBackend Engineer at Acme: https://example.com/jobs/1?ref=digest&utm=mail\n",
+		HTML:    `<p>Hello Ada,</p><p><a href="https://example.com/jobs/1">Backend Engineer</a></p>`,
+		Headers: map[string]string{"List-Unsubscribe": "<https://example.com/unsubscribe>"},
+	}
+
+	raw, err := buildMessage("Job Scraper <jobs@example.com>", msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("invalid message: %v", err)
+	}
+
+	header := parsed.Header
+	if header.Get("From") != "Job Scraper <jobs@example.com>" || header.Get("To") != msg.To || header.Get("MIME-Version") != "1.0" {
+		t.Errorf("headers %v", header)
+	}
+	if subject, err := new(mime.WordDecoder).DecodeHeader(header.Get("Subject")); err != nil || subject != msg.Subject {
+		t.Errorf("subject %q (%v), want %q", subject, err, msg.Subject)
+	}
+	if header.Get("List-Unsubscribe") != msg.Headers["List-Unsubscribe"] {
+		t.Errorf("List-Unsubscribe %q", header.Get("List-Unsubscribe"))
+	}
+	if _, err := header.Date(); err != nil {
+		t.Errorf("Date: %v", err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type %q (%v)", header.Get("Content-Type"), err)
+	}
+	parts := multipart.NewReader(parsed.Body, params["boundary"])
+	for _, want := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		// NextPart decodes the quoted-printable content, whose line breaks
+		// are CRLF as email requires.
+		part, err := parts.NextPart()
+		if err != nil {
+			t.Fatalf("%s part: %v", want.contentType, err)
+		}
+		if got := part.Header.Get("Content-Type"); got != want.contentType {
+			t.Errorf("part Content-Type %q, want %q", got, want.contentType)
+		}
+		content, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.ReplaceAll(string(content), "\r\n", "\n") != want.content {
+			t.Errorf("%s part: got %q, want %q", want.contentType, content, want.content)
+		}
+	}
+	if _, err := parts.NextPart(); err != io.EOF {
+		t.Errorf("more than two parts: %v", err)
+	}
+}
+
+// smtpServer is a fake SMTP server accepting a single message.
+type smtpServer struct {
+	listener net.Listener
+	// done receives the envelope and data of the message, or nothing if the
+	// session failed.
+	done chan smtpSession
+}
+
+type smtpSession struct {
+	from string
+	to   []string
+	data string
+}
+
+func newSMTPServer(t *testing.T) *smtpServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	s := &smtpServer{listener: listener, done: make(chan smtpSession, 1)}
+	go s.serve()
+	return s
+}
+
+func (s *smtpServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpServer) serve() {
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+	reply("220 localhost ESMTP")
+
+	var session smtpSession
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		switch verb {
+		case "EHLO":
+			reply("250 localhost")
+		case "MAIL":
+			session.from = strings.TrimPrefix(line, "MAIL FROM:")
+			reply("250 OK")
+		case "RCPT":
+			session.to = append(session.to, strings.TrimPrefix(line, "RCPT TO:"))
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(line, "."))
+			}
+			session.data = data.String()
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			s.done <- session
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	server := newSMTPServer(t)
+	mailer, err := NewSMTPMailer(SMTPConfig{Host: "127.0.0.1", Port: server.port(), From: "Job Scraper <jobs@example.com>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := Message{To: "Ada <ada@example.com>", Subject: "1 new job matching your search", Text: "Hello", HTML: "<p>Hello</p>"}
+	if err := mailer.Send(msg); err != nil {
+		t.Fatal(err)
+	}
+
+	session := <-server.done
+	if session.from != "<jobs@example.com>" {
+		t.Errorf("MAIL FROM %s", session.from)
+	}
+	if len(session.to) != 1 || session.to[0] != "<ada@example.com>" {
+		t.Errorf("RCPT TO %v", session.to)
+	}
+	parsed, err := mail.ReadMessage(strings.NewReader(session.data))
+	if err != nil {
+		t.Fatalf("invalid message data: %v", err)
+	}
+	if parsed.Header.Get("Subject") != msg.Subject || parsed.Header.Get("To") != msg.To {
+		t.Errorf("message headers %v", parsed.Header)
+	}
+}
+
+func TestSMTPMailerInvalidRecipient(t *testing.T) {
+	mailer, err := NewSMTPMailer(SMTPConfig{Host: "127.0.0.1", From: "jobs@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mailer.Send(Message{To: "not an address"}); err == nil {
+		t.Error("sending to an invalid recipient succeeded")
+	}
+}
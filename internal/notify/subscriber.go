@@ -0,0 +1,101 @@
+// Package notify sends digests and alerts about newly discovered jobs.
+package notify
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ayagmar/gojobscraper/internal/filter"
+)
+
+// Frequency is how often a subscriber receives a digest
+// @Description Digest frequency
+type Frequency string
+
+const (
+	Hourly Frequency = "hourly"
+	Daily  Frequency = "daily"
+	Weekly Frequency = "weekly"
+)
+
+// Schedule decides when digests are sent
+// @Description Digest schedule
+type Schedule struct {
+	Frequency Frequency `json:"frequency" bson:"frequency"`
+	// Hour is the hour of the day daily and weekly digests are sent at.
+	Hour int `json:"hour" bson:"hour"`
+	// Weekday is the day weekly digests are sent on, 0 being Sunday.
+	Weekday time.Weekday `json:"weekday" bson:"weekday" swaggertype:"integer"`
+	// Timezone is the IANA time zone Hour and Weekday are in. Defaults to UTC.
+	Timezone string `json:"timezone,omitempty" bson:"timezone,omitempty"`
+}
+
+// Validate reports whether the schedule can be used.
+func (s Schedule) Validate() error {
+	switch s.Frequency {
+	case Hourly, Daily, Weekly:
+	default:
+		return fmt.Errorf("invalid frequency %q. Must be hourly, daily or weekly", s.Frequency)
+	}
+	if s.Hour < 0 || s.Hour > 23 {
+		return errors.New("invalid hour. Must be between 0 and 23")
+	}
+	if s.Weekday < time.Sunday || s.Weekday > time.Saturday {
+		return errors.New("invalid weekday. Must be between 0 (Sunday) and 6 (Saturday)")
+	}
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone: %w", err)
+	}
+	return nil
+}
+
+// Next returns the first time strictly after t a digest is due.
+func (s Schedule) Next(t time.Time) time.Time {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	t = t.In(loc)
+
+	if s.Frequency == Hourly {
+		return t.Truncate(time.Hour).Add(time.Hour)
+	}
+
+	next := time.Date(t.Year(), t.Month(), t.Day(), s.Hour, 0, 0, 0, loc)
+	for !next.After(t) || (s.Frequency == Weekly && next.Weekday() != s.Weekday) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// Subscriber receives digests of the new jobs matching its filter
+// @Description Email digest subscriber
+type Subscriber struct {
+	ID       string           `json:"id" bson:"id"`
+	Email    string           `json:"email" bson:"email"`
+	Name     string           `json:"name,omitempty" bson:"name,omitempty"`
+	Filter   filter.JobFilter `json:"filter" bson:"filter"`
+	Schedule Schedule         `json:"schedule" bson:"schedule"`
+	// UnsubscribeToken authenticates the unsubscribe link of the digests.
+	UnsubscribeToken string     `json:"-" bson:"unsubscribe_token"`
+	Unsubscribed     bool       `json:"unsubscribed" bson:"unsubscribed"`
+	LastDigestAt     *time.Time `json:"last_digest_at,omitempty" bson:"last_digest_at,omitempty"`
+	NextDigestAt     time.Time  `json:"next_digest_at" bson:"next_digest_at"`
+	// FailedDigests counts the digests that failed to send in a row. Their
+	// retries are spaced out.
+	FailedDigests int       `json:"failed_digests,omitempty" bson:"failed_digests,omitempty"`
+	OwnerID       string    `json:"owner_id" bson:"owner_id"`
+	CreatedAt     time.Time `json:"created_at" bson:"created_at"`
+}
+
+// NewUnsubscribeToken returns a random token for unsubscribe links.
+func NewUnsubscribeToken() (string, error) {
+	token := make([]byte, 24)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("failed to generate unsubscribe token: %w", err)
+	}
+	return hex.EncodeToString(token), nil
+}
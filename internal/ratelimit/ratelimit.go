@@ -0,0 +1,107 @@
+// Package ratelimit limits how fast API clients may send requests.
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// pruneInterval is how often the buckets of idle clients are dropped.
+const pruneInterval = time.Minute
+
+// Decision is the outcome of checking a request against the limit of its
+// client.
+type Decision struct {
+	Allowed bool
+	// Limit is the number of requests a client may send back to back.
+	Limit int
+	// Remaining is the number of requests the client may still send right
+	// away.
+	Remaining int
+	// Reset is when the client may send Limit requests again.
+	Reset time.Time
+	// RetryAfter is how long a rejected client has to wait before its next
+	// request is allowed.
+	RetryAfter time.Duration
+}
+
+// Limiter is a token-bucket rate limiter keyed by client.
+type Limiter struct {
+	mu        sync.Mutex
+	rate      float64
+	burst     int
+	clients   map[string]*bucket
+	lastPrune time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// New creates a limiter allowing every client requestsPerMinute requests per
+// minute, in bursts of up to burst requests.
+func New(requestsPerMinute float64, burst int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{
+		rate:    requestsPerMinute / 60,
+		burst:   burst,
+		clients: make(map[string]*bucket),
+	}
+}
+
+// Allow checks a request of client sent at now, taking a token from its
+// bucket when the request is allowed.
+func (l *Limiter) Allow(client string, now time.Time) Decision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastPrune) > pruneInterval {
+		l.prune(now)
+	}
+
+	b, ok := l.clients[client]
+	if !ok {
+		b = &bucket{tokens: float64(l.burst), last: now}
+		l.clients[client] = b
+	}
+	b.tokens = l.refill(b, now)
+	b.last = now
+
+	decision := Decision{Limit: l.burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = l.wait(1 - b.tokens)
+	}
+	decision.Remaining = int(math.Floor(b.tokens))
+	decision.Reset = now.Add(l.wait(float64(l.burst) - b.tokens))
+	return decision
+}
+
+func (l *Limiter) refill(b *bucket, now time.Time) float64 {
+	tokens := b.tokens + now.Sub(b.last).Seconds()*l.rate
+	return math.Min(tokens, float64(l.burst))
+}
+
+// wait returns how long it takes to earn the given number of tokens.
+func (l *Limiter) wait(tokens float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	return time.Duration(math.Ceil(tokens / l.rate * float64(time.Second)))
+}
+
+// prune drops the buckets that have refilled, which behave like new ones.
+func (l *Limiter) prune(now time.Time) {
+	for client, b := range l.clients {
+		if l.refill(b, now) >= float64(l.burst) {
+			delete(l.clients, client)
+		}
+	}
+	l.lastPrune = now
+}
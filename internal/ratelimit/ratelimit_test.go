@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAllowBurst(t *testing.T) {
+	l := New(60, 3)
+	now := time.Date(2024, time.March, 6, 10, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 3; i++ {
+		decision := l.Allow("a", now)
+		if !decision.Allowed || decision.Limit != 3 || decision.Remaining != 2-i {
+			t.Fatalf("request %d: %+v, want allowed with %d remaining", i, decision, 2-i)
+		}
+	}
+
+	decision := l.Allow("a", now)
+	if decision.Allowed || decision.Remaining != 0 {
+		t.Fatalf("request over the burst: %+v, want rejected", decision)
+	}
+	if decision.RetryAfter != time.Second {
+		t.Errorf("retry after %s, want 1s", decision.RetryAfter)
+	}
+	if !decision.Reset.Equal(now.Add(3 * time.Second)) {
+		t.Errorf("reset at %s, want in 3s", decision.Reset)
+	}
+
+	// Other clients have their own bucket.
+	if decision := l.Allow("b", now); !decision.Allowed || decision.Remaining != 2 {
+		t.Errorf("other client: %+v, want allowed", decision)
+	}
+}
+
+func TestAllowRefill(t *testing.T) {
+	l := New(60, 2)
+	now := time.Date(2024, time.March, 6, 10, 0, 0, 0, time.UTC)
+
+	l.Allow("a", now)
+	l.Allow("a", now)
+	if l.Allow("a", now.Add(500*time.Millisecond)).Allowed {
+		t.Error("allowed before a token was earned")
+	}
+	if decision := l.Allow("a", now.Add(1500*time.Millisecond)); !decision.Allowed {
+		t.Errorf("rejected once a token was earned: %+v", decision)
+	}
+
+	// Buckets refill up to the burst only.
+	if decision := l.Allow("a", now.Add(time.Hour)); decision.Remaining != 1 {
+		t.Errorf("%d remaining after an hour, want 1", decision.Remaining)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	l := New(60, 2)
+	now := time.Date(2024, time.March, 6, 10, 0, 0, 0, time.UTC)
+
+	l.Allow("idle", now)
+	l.Allow("busy", now.Add(pruneInterval))
+	l.Allow("busy", now.Add(pruneInterval))
+	l.Allow("new", now.Add(2*pruneInterval))
+
+	if _, ok := l.clients["idle"]; ok {
+		t.Error("refilled bucket kept")
+	}
+	if _, ok := l.clients["new"]; !ok {
+		t.Error("bucket of the current request dropped")
+	}
+}
@@ -0,0 +1,135 @@
+package scraper
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// BatchPolicy configures how streamed postings are grouped before saving.
+type BatchPolicy struct {
+	// Size is the number of postings that triggers a save. Values below 1
+	// save every posting on its own.
+	Size int
+	// FlushInterval bounds how long a posting waits for its batch to fill.
+	// Zero waits until the batch is full or the batcher is closed.
+	FlushInterval time.Duration
+}
+
+// batchRetryDelay is the pause before a failed batch is saved again.
+var batchRetryDelay = time.Second
+
+// JobBatcher collects streamed postings and saves them in micro-batches, so
+// the postings of a long scrape are stored while it is still running. It is
+// safe for concurrent use.
+type JobBatcher struct {
+	policy BatchPolicy
+	save   func([]JobPosting) error
+
+	// saving serializes saves, which run outside of mu so that postings keep
+	// being queued while a batch is stored.
+	saving   sync.Mutex
+	inflight sync.WaitGroup
+
+	mu      sync.Mutex
+	pending []JobPosting
+	timer   *time.Timer
+	saved   int
+	err     error
+}
+
+// NewJobBatcher creates a batcher passing each batch to save. Batches are
+// saved one at a time, and a batch that fails is retried once.
+func NewJobBatcher(policy BatchPolicy, save func([]JobPosting) error) *JobBatcher {
+	if policy.Size < 1 {
+		policy.Size = 1
+	}
+	return &JobBatcher{policy: policy, save: save}
+}
+
+// Add queues job and saves the pending batch once it is full. It has the
+// signature of a JobSink.
+func (b *JobBatcher) Add(job JobPosting) {
+	b.mu.Lock()
+	b.pending = append(b.pending, job)
+	if len(b.pending) < b.policy.Size {
+		if b.timer == nil && b.policy.FlushInterval > 0 {
+			b.timer = time.AfterFunc(b.policy.FlushInterval, b.Flush)
+		}
+		b.mu.Unlock()
+		return
+	}
+	batch := b.takeLocked()
+	b.mu.Unlock()
+
+	b.saveBatch(batch)
+}
+
+// Flush saves the pending postings.
+func (b *JobBatcher) Flush() {
+	b.mu.Lock()
+	batch := b.takeLocked()
+	b.mu.Unlock()
+
+	b.saveBatch(batch)
+}
+
+// takeLocked swaps out the pending postings. A non-empty batch must be passed
+// to saveBatch.
+func (b *JobBatcher) takeLocked() []JobPosting {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	batch := b.pending
+	b.pending = nil
+	if len(batch) > 0 {
+		b.inflight.Add(1)
+	}
+	return batch
+}
+
+func (b *JobBatcher) saveBatch(batch []JobPosting) {
+	if len(batch) == 0 {
+		return
+	}
+	defer b.inflight.Done()
+
+	b.saving.Lock()
+	err := b.save(batch)
+	if err != nil {
+		log.Printf("Error saving batch of %d jobs, retrying: %v", len(batch), err)
+		time.Sleep(batchRetryDelay)
+		err = b.save(batch)
+	}
+	b.saving.Unlock()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err != nil {
+		log.Printf("Error saving batch of %d jobs: %v", len(batch), err)
+		if b.err == nil {
+			b.err = err
+		}
+		return
+	}
+	b.saved += len(batch)
+}
+
+// Close saves the pending postings, waits for the batches being saved and
+// returns the first save error, if any.
+func (b *JobBatcher) Close() error {
+	b.Flush()
+	b.inflight.Wait()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.err
+}
+
+// Saved returns the number of postings saved so far.
+func (b *JobBatcher) Saved() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.saved
+}
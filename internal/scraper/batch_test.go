@@ -0,0 +1,105 @@
+package scraper
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestJobBatcherSavesOutsideLock(t *testing.T) {
+	release := make(chan struct{})
+	saving := make(chan struct{}, 1)
+	var mu sync.Mutex
+	var saved [][]JobPosting
+	b := NewJobBatcher(BatchPolicy{Size: 2}, func(jobs []JobPosting) error {
+		saving <- struct{}{}
+		<-release
+		mu.Lock()
+		saved = append(saved, jobs)
+		mu.Unlock()
+		return nil
+	})
+
+	b.Add(JobPosting{ID: "1"})
+	go b.Add(JobPosting{ID: "2"})
+	<-saving
+
+	// The first batch is still being saved; queuing must not wait for it.
+	added := make(chan struct{})
+	go func() {
+		b.Add(JobPosting{ID: "3"})
+		close(added)
+	}()
+	select {
+	case <-added:
+	case <-time.After(time.Second):
+		t.Fatal("Add blocked while a batch was being saved")
+	}
+
+	close(release)
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if b.Saved() != 3 || len(saved) != 2 {
+		t.Errorf("saved %d jobs in %d batches, want 3 in 2", b.Saved(), len(saved))
+	}
+}
+
+func TestJobBatcherRetriesOnce(t *testing.T) {
+	defer func(delay time.Duration) { batchRetryDelay = delay }(batchRetryDelay)
+	batchRetryDelay = time.Millisecond
+
+	attempts := 0
+	b := NewJobBatcher(BatchPolicy{Size: 1}, func(jobs []JobPosting) error {
+		attempts++
+		if attempts == 1 {
+			return errors.New("connection reset")
+		}
+		return nil
+	})
+	b.Add(JobPosting{ID: "1"})
+	if err := b.Close(); err != nil || b.Saved() != 1 || attempts != 2 {
+		t.Errorf("transient failure: error %v, %d saved after %d attempts", err, b.Saved(), attempts)
+	}
+
+	failing := errors.New("database down")
+	attempts = 0
+	b = NewJobBatcher(BatchPolicy{Size: 1}, func(jobs []JobPosting) error {
+		attempts++
+		return failing
+	})
+	b.Add(JobPosting{ID: "1"})
+	if err := b.Close(); !errors.Is(err, failing) || b.Saved() != 0 || attempts != 2 {
+		t.Errorf("persistent failure: error %v, %d saved after %d attempts", err, b.Saved(), attempts)
+	}
+}
+
+func TestJobBatcherCloseWaitsForTimerFlush(t *testing.T) {
+	var mu sync.Mutex
+	var ids []string
+	b := NewJobBatcher(BatchPolicy{Size: 100, FlushInterval: 10 * time.Millisecond}, func(jobs []JobPosting) error {
+		time.Sleep(50 * time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		for _, job := range jobs {
+			ids = append(ids, job.ID)
+		}
+		return nil
+	})
+
+	for i := 0; i < 3; i++ {
+		b.Add(JobPosting{ID: fmt.Sprint(i)})
+	}
+	time.Sleep(20 * time.Millisecond)
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(ids) != 3 || b.Saved() != 3 {
+		t.Errorf("saved %v before Close returned, want all 3 jobs", ids)
+	}
+}
@@ -0,0 +1,136 @@
+package scraper
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// ErrBlocked matches every BlockedError with errors.Is.
+var ErrBlocked = errors.New("blocked by target site")
+
+// BlockedError reports that a site refused to serve content, either
+// explicitly (403, 429, challenge page) or by serving an empty result page.
+type BlockedError struct {
+	URL    string
+	Reason string
+}
+
+func (e *BlockedError) Error() string {
+	return fmt.Sprintf("blocked fetching %s: %s", e.URL, e.Reason)
+}
+
+func (e *BlockedError) Is(target error) bool {
+	return target == ErrBlocked
+}
+
+// maxBlockPeek is how much of a response body is inspected for block markers.
+const maxBlockPeek = 64 * 1024
+
+// challengeMarkers are titles and containers only found on challenge and
+// block pages served instead of the requested content.
+var challengeMarkers = []string{
+	"/cdn-cgi/challenge-platform",
+	"cf-chl-",
+	"<title>Just a moment...</title>",
+	"<title>Attention Required!",
+	"<title>Blocked - Indeed.com</title>",
+	"<title>hCaptcha solve page</title>",
+	"<title>Security Check</title>",
+}
+
+// captchaMarkers are fragments of captcha widgets. Regular pages embed them
+// on forms too, so they only mark a block on an error response.
+var captchaMarkers = []string{
+	"challenges.cloudflare.com",
+	"hcaptcha.com",
+	"h-captcha",
+	"g-recaptcha",
+}
+
+// detectBlock returns why a response looks like a block, or an empty string
+// if it does not.
+func detectBlock(statusCode int, body []byte) string {
+	switch statusCode {
+	case http.StatusForbidden:
+		return "HTTP 403 Forbidden"
+	case http.StatusTooManyRequests:
+		return "HTTP 429 Too Many Requests"
+	}
+
+	for _, marker := range challengeMarkers {
+		if bytes.Contains(body, []byte(marker)) {
+			return fmt.Sprintf("challenge page (%s)", marker)
+		}
+	}
+	if statusCode < http.StatusBadRequest {
+		return ""
+	}
+	for _, marker := range captchaMarkers {
+		if bytes.Contains(body, []byte(marker)) {
+			return fmt.Sprintf("challenge page (%s)", marker)
+		}
+	}
+	return ""
+}
+
+// detectBlockResponse inspects resp for block signals without consuming its
+// body: the inspected prefix is put back in front of the unread remainder.
+func detectBlockResponse(resp *http.Response) (string, error) {
+	if reason := detectBlock(resp.StatusCode, nil); reason != "" {
+		return reason, nil
+	}
+	if !strings.Contains(resp.Header.Get("Content-Type"), "html") {
+		return "", nil
+	}
+
+	prefix, err := io.ReadAll(io.LimitReader(resp.Body, maxBlockPeek))
+	if err != nil {
+		return "", err
+	}
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(prefix), resp.Body), resp.Body}
+
+	return detectBlock(resp.StatusCode, prefix), nil
+}
+
+// blockTransport turns block responses into BlockedErrors and puts the
+// blocking domain into cool-down.
+type blockTransport struct {
+	next    http.RoundTripper
+	limiter *DomainLimiter
+}
+
+func (t *blockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	reason, err := detectBlockResponse(resp)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	host := req.URL.Hostname()
+	if reason == "" {
+		if t.limiter != nil {
+			t.limiter.Reward(host)
+		}
+		return resp, nil
+	}
+
+	resp.Body.Close()
+	if t.limiter != nil {
+		cooldown := t.limiter.Penalize(host)
+		log.Printf("Blocked by %s (%s), cooling down for %s", host, reason, cooldown)
+	}
+	return nil, &BlockedError{URL: req.URL.String(), Reason: reason}
+}
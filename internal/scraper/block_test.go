@@ -0,0 +1,119 @@
+package scraper
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readBlockFixture(t *testing.T, name string) string {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", "block", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestDetectBlockResponse(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		contentType string
+		fixture     string
+		want        string
+	}{
+		{"results page", http.StatusOK, "text/html; charset=utf-8", "results.html", ""},
+		{"forbidden", http.StatusForbidden, "text/html; charset=utf-8", "forbidden.html", "HTTP 403 Forbidden"},
+		{"too many requests", http.StatusTooManyRequests, "text/html", "too_many_requests.html", "HTTP 429 Too Many Requests"},
+		{"cloudflare challenge", http.StatusServiceUnavailable, "text/html; charset=UTF-8", "cloudflare_challenge.html", "challenge page (/cdn-cgi/challenge-platform)"},
+		{"hcaptcha", http.StatusOK, "text/html", "hcaptcha.html", "challenge page (<title>hCaptcha solve page</title>)"},
+		{"recaptcha", http.StatusOK, "text/html", "recaptcha.html", "challenge page (<title>Security Check</title>)"},
+		{"page with a recaptcha form", http.StatusOK, "text/html", "recaptcha_form.html", ""},
+		{"recaptcha on an error page", http.StatusServiceUnavailable, "text/html", "recaptcha_form.html", "challenge page (g-recaptcha)"},
+		{"block page served with 200", http.StatusOK, "text/html", "forbidden.html", "challenge page (<title>Blocked - Indeed.com</title>)"},
+		{"challenge markers outside html", http.StatusOK, "application/json", "hcaptcha.html", ""},
+	}
+	for _, tt := range tests {
+		body := readBlockFixture(t, tt.fixture)
+		resp := &http.Response{
+			StatusCode: tt.status,
+			Header:     http.Header{"Content-Type": {tt.contentType}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+		}
+
+		got, err := detectBlockResponse(resp)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+
+		// The inspected prefix is put back in front of the body.
+		rest, _ := io.ReadAll(resp.Body)
+		if string(rest) != body {
+			t.Errorf("%s: body changed by the inspection", tt.name)
+		}
+	}
+}
+
+func TestDetectBlockPeeksOnlyAtPrefix(t *testing.T) {
+	body := strings.Repeat(" ", maxBlockPeek) + readBlockFixture(t, "recaptcha.html")
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"text/html"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+
+	got, err := detectBlockResponse(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "" {
+		t.Errorf("marker past the inspected prefix detected: %q", got)
+	}
+	if rest, _ := io.ReadAll(resp.Body); len(rest) != len(body) {
+		t.Errorf("read %d bytes of the body, want %d", len(rest), len(body))
+	}
+}
+
+func TestBlockTransport(t *testing.T) {
+	fixture := "results.html"
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(status)
+		io.WriteString(w, readBlockFixture(t, fixture))
+	}))
+	defer server.Close()
+
+	limiter := NewDomainLimiter(Politeness{}, nil, BlockBackoff{Initial: time.Minute})
+	client := &http.Client{Transport: &blockTransport{next: http.DefaultTransport, limiter: limiter}}
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("results page: %v", err)
+	}
+	resp.Body.Close()
+
+	fixture, status = "cloudflare_challenge.html", http.StatusServiceUnavailable
+	_, err = client.Get(server.URL)
+	var blocked *BlockedError
+	if !errors.As(err, &blocked) || !errors.Is(err, ErrBlocked) {
+		t.Fatalf("challenge page: got %v, want a BlockedError", err)
+	}
+	if !strings.Contains(blocked.Reason, "challenge page") {
+		t.Errorf("block reason %q", blocked.Reason)
+	}
+
+	if wait := limiter.bucket("127.0.0.1").reserve(time.Now()); wait < 59*time.Second {
+		t.Errorf("blocked domain waits %s, want the one minute cool-down", wait)
+	}
+}
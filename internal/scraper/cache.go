@@ -0,0 +1,92 @@
+package scraper
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// CompanyStore gives the company cache access to companies persisted by
+// earlier runs of the application.
+type CompanyStore interface {
+	LookupCompany(platformCompanyURL string) (Company, bool, error)
+}
+
+type companyCacheEntry struct {
+	details   CompanyDetails
+	fetchedAt time.Time
+}
+
+// CompanyCache keeps company details for a TTL so that scrapes do not refetch
+// the same company page for every job of an employer. It is safe for
+// concurrent use and meant to be shared by all scrapes.
+type CompanyCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]companyCacheEntry
+	store   CompanyStore
+}
+
+// DefaultCompanyCacheTTL is the TTL of company details when none is
+// configured.
+const DefaultCompanyCacheTTL = 24 * time.Hour
+
+// NewCompanyCache creates a cache whose entries expire after ttl, or after
+// DefaultCompanyCacheTTL if ttl is not positive. If store is not nil,
+// in-memory misses fall back to companies persisted in the store that were
+// fetched within the TTL.
+func NewCompanyCache(ttl time.Duration, store CompanyStore) *CompanyCache {
+	if ttl <= 0 {
+		ttl = DefaultCompanyCacheTTL
+	}
+	return &CompanyCache{
+		ttl:     ttl,
+		entries: make(map[string]companyCacheEntry),
+		store:   store,
+	}
+}
+
+// Get returns the cached details of the company at platformCompanyURL.
+func (c *CompanyCache) Get(platformCompanyURL string) (CompanyDetails, bool) {
+	c.mu.Lock()
+	entry, ok := c.entries[platformCompanyURL]
+	if ok && time.Since(entry.fetchedAt) > c.ttl {
+		delete(c.entries, platformCompanyURL)
+		ok = false
+	}
+	c.mu.Unlock()
+
+	if ok {
+		return entry.details, true
+	}
+	if c.store == nil {
+		return CompanyDetails{}, false
+	}
+
+	company, found, err := c.store.LookupCompany(platformCompanyURL)
+	if err != nil {
+		log.Printf("Error looking up cached company %s: %v", platformCompanyURL, err)
+		return CompanyDetails{}, false
+	}
+	if !found || time.Since(company.UpdatedAt) > c.ttl {
+		return CompanyDetails{}, false
+	}
+
+	details := company.Details()
+	c.mu.Lock()
+	c.entries[platformCompanyURL] = companyCacheEntry{details: details, fetchedAt: company.UpdatedAt}
+	c.mu.Unlock()
+
+	return details, true
+}
+
+// Put stores freshly fetched company details.
+func (c *CompanyCache) Put(details CompanyDetails) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fetchedAt := details.FetchedAt
+	if fetchedAt.IsZero() {
+		fetchedAt = time.Now()
+	}
+	c.entries[details.PlatformCompanyURL] = companyCacheEntry{details: details, fetchedAt: fetchedAt}
+}
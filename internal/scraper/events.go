@@ -0,0 +1,37 @@
+package scraper
+
+import "time"
+
+// EventType identifies a step of a scrape reported to event hooks.
+// @Description Type of scrape progress event
+type EventType string
+
+const (
+	EventPageVisited EventType = "page_visited"
+	EventJobParsed   EventType = "job_parsed"
+	EventJobSaved    EventType = "job_saved"
+	EventError       EventType = "error"
+	EventFinished    EventType = "finished"
+)
+
+// ScrapeEvent reports the progress of a scrape
+// @Description Scrape progress event
+type ScrapeEvent struct {
+	// ID orders the events of a run. It is assigned when the event is
+	// published, not by the scraper, and is omitted from finished events
+	// rebuilt after the events of the run were dropped.
+	ID    int64      `json:"id,omitempty"`
+	Type  EventType  `json:"type"`
+	Time  time.Time  `json:"time"`
+	URL   string     `json:"url,omitempty"`
+	Page  int        `json:"page,omitempty"`
+	JobID string     `json:"job_id,omitempty"`
+	Title string     `json:"title,omitempty"`
+	Count int        `json:"count,omitempty"`
+	Error string     `json:"error,omitempty"`
+	Run   *ScrapeRun `json:"run,omitempty"`
+}
+
+// EventHook receives the progress events of a scrape. It may be called from
+// several goroutines at once.
+type EventHook func(event ScrapeEvent)
@@ -1,67 +1,176 @@
 package scraper
 
 import (
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"log"
-	"math/rand"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gocolly/colly/v2"
+	"golang.org/x/sync/singleflight"
 )
 
-type IndeedScraper struct{}
+type IndeedScraper struct {
+	opts    Options
+	session *Session
+
+	// mu guards summary, which detail workers update concurrently.
+	mu      sync.Mutex
+	summary RunSummary
+
+	// companyFetches collapses concurrent fetches of the same company page.
+	companyFetches singleflight.Group
+
+	// cardsOnPage and noResultsOnPage describe the last visited results page.
+	// They are only touched by the listing collector.
+	cardsOnPage     int
+	noResultsOnPage bool
+}
 
 func (s *IndeedScraper) Scrape(config ScrapeConfig) ([]JobPosting, error) {
-	log.Printf("Starting Indeed scraper for job title: %s, country: %s, pages: %d", config.JobTitle, config.Country, config.Pages)
+	return collectJobs(s, config)
+}
 
-	c := SetupColly(fmt.Sprintf("%s.indeed.com", config.Country))
-	if c == nil {
-		return nil, fmt.Errorf("failed to setup collector")
+func (s *IndeedScraper) Stream(config ScrapeConfig, sink JobSink) error {
+	log.Printf("Starting Indeed scraper for job title: %s, country: %s, pages: %d", config.JobTitle, config.Country, config.Pages)
+	s.session = NewSession(s.opts)
+	defer s.closeSession()
+
+	c := s.session.Collector(fmt.Sprintf("%s.indeed.com", config.Country))
+
+	// Listing pages are parsed into stubs that a pool of workers completes
+	// with their detail and company pages while the next pages are listed.
+	stubs := make(chan JobPosting)
+	results := make(chan JobPosting)
+
+	var workers sync.WaitGroup
+	for i := 0; i < s.detailConcurrency(); i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for stub := range stubs {
+				job, err := s.completeJob(stub)
+				if err != nil {
+					log.Printf("Error parsing job card: %v", err)
+					s.emit(ScrapeEvent{Type: EventError, URL: stub.URL, Error: err.Error()})
+					continue
+				}
+				results <- job
+			}
+		}()
 	}
-
-	jobs := make([]JobPosting, 0)
+	go func() {
+		workers.Wait()
+		close(results)
+	}()
+
+	scraped := 0
+	collected := make(chan struct{})
+	go func() {
+		defer close(collected)
+		for job := range results {
+			scraped++
+			log.Printf("Parsed job: %s at %s, URL: %s", job.Title, job.CompanyDetails.Company, job.URL)
+			s.emit(ScrapeEvent{Type: EventJobParsed, URL: job.URL, JobID: job.ID, Title: job.Title, Count: scraped})
+			sink(job)
+		}
+	}()
 
 	c.OnHTML("#mosaic-provider-jobcards .job_seen_beacon", func(e *colly.HTMLElement) {
-		job, err := s.parseJobCard(e)
-		if err != nil {
-			log.Printf("Error parsing job card: %v", err)
-			return
-		}
-		jobs = append(jobs, job)
-		log.Printf("Parsed job: %s at %s, URL: %s", job.Title, job.CompanyDetails.Company, job.URL)
+		s.cardsOnPage++
+		stubs <- s.parseJobCard(e)
+	})
+
+	c.OnHTML(".jobsearch-NoResult-messageContainer, [data-testid='no-results-message']", func(e *colly.HTMLElement) {
+		s.noResultsOnPage = true
 	})
 
 	err := s.visitPages(c, config)
+	close(stubs)
+	<-collected
+
+	if errors.Is(err, ErrBlocked) && scraped > 0 {
+		log.Printf("Blocked after scraping %d jobs from Indeed: %v", scraped, err)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("error visiting pages: %w", err)
+		return fmt.Errorf("error visiting pages: %w", err)
+	}
+
+	log.Printf("Scraped total of %d jobs from Indeed", scraped)
+	return nil
+}
+
+// closeSession records the connection and robots.txt statistics of the
+// scrape's session and releases it.
+func (s *IndeedScraper) closeSession() {
+	opened, reused := s.session.ConnectionStats()
+	s.mu.Lock()
+	s.summary.ConnectionsOpened = opened
+	s.summary.ConnectionsReused = reused
+	s.summary.RobotsDisallowed = s.session.RobotsDisallowed()
+	if s.opts.Robots != nil {
+		s.summary.RobotsPolicy = s.opts.Robots.SourcePolicy(Indeed)
+	} else {
+		s.summary.RobotsPolicy = RobotsIgnore
 	}
+	s.mu.Unlock()
 
-	log.Printf("Scraped total of %d jobs from Indeed", len(jobs))
-	return jobs, nil
+	s.session.Close()
+}
+
+// emit passes event to the scrape's event hook, if any.
+func (s *IndeedScraper) emit(event ScrapeEvent) {
+	if s.opts.Events == nil {
+		return
+	}
+	event.Time = time.Now()
+	s.opts.Events(event)
+}
+
+func (s *IndeedScraper) detailConcurrency() int {
+	if s.opts.DetailConcurrency < 1 {
+		return 1
+	}
+	return s.opts.DetailConcurrency
 }
 
-func (s *IndeedScraper) parseJobCard(e *colly.HTMLElement) (JobPosting, error) {
+// parseJobCard reads the fields available on a results page job card.
+func (s *IndeedScraper) parseJobCard(e *colly.HTMLElement) JobPosting {
 	dirtyURL := e.Request.AbsoluteURL(e.ChildAttr("h2.jobTitle a", "href"))
 	cleanURL := cleanJobURL(dirtyURL)
-	companyName := e.ChildText("[data-testid='company-name']")
 
-	job := JobPosting{
-		ID:            uuid.New().String(),
-		PlatformJobId: ExtractJobKey(cleanURL),
-		Title:         e.ChildText(".jobTitle span"),
-		Location:      e.ChildText("[data-testid='text-location']"),
-		Summary:       e.ChildText(".css-9446fg"),
-		URL:           cleanURL,
-		CreatedAt:     time.Now(),
-		Source:        Indeed,
+	return JobPosting{
+		ID:             uuid.New().String(),
+		PlatformJobId:  ExtractJobKey(cleanURL),
+		Title:          e.ChildText(".jobTitle span"),
+		Location:       e.ChildText("[data-testid='text-location']"),
+		Summary:        e.ChildText(".css-9446fg"),
+		URL:            cleanURL,
+		CompanyDetails: CompanyDetails{Company: e.ChildText("[data-testid='company-name']")},
+		Source:         Indeed,
 	}
+}
+
+// completeJob fetches the description and company details of a job card.
+func (s *IndeedScraper) completeJob(job JobPosting) (JobPosting, error) {
+	companyName := job.CompanyDetails.Company
 
 	description, companyDetails, err := s.fetchJobDetails(job.URL)
 	if err != nil {
-		return JobPosting{}, fmt.Errorf("error fetching job description: %w", err)
+		if !s.opts.PartialResults {
+			return JobPosting{}, fmt.Errorf("error fetching job description: %w", err)
+		}
+		log.Printf("Keeping incomplete job %s: error fetching job description: %v", job.URL, err)
+		s.emit(ScrapeEvent{Type: EventError, URL: job.URL, Error: err.Error()})
+		s.mu.Lock()
+		s.summary.IncompleteJobs++
+		s.mu.Unlock()
+		job.Incomplete = true
+		return job, nil
 	}
 
 	job.Description = description
@@ -72,10 +181,7 @@ func (s *IndeedScraper) parseJobCard(e *colly.HTMLElement) (JobPosting, error) {
 }
 
 func (s *IndeedScraper) fetchJobDetails(jobURL string) (string, CompanyDetails, error) {
-	c := SetupColly("www.indeed.com")
-	if c == nil {
-		return "", CompanyDetails{}, fmt.Errorf("failed to setup collector for job description")
-	}
+	c := s.session.Collector("www.indeed.com")
 
 	var description string
 	var companyDetails CompanyDetails
@@ -94,12 +200,54 @@ func (s *IndeedScraper) fetchJobDetails(jobURL string) (string, CompanyDetails,
 		return "", CompanyDetails{}, err
 	}
 
-	err = s.fetchCompanyDetails(&companyDetails)
+	if companyDetails.PlatformCompanyURL == "" {
+		return description, companyDetails, nil
+	}
+
+	if cached, ok := s.cachedCompanyDetails(companyDetails.PlatformCompanyURL); ok {
+		return description, cached, nil
+	}
+
+	fetched, err, _ := s.companyFetches.Do(companyDetails.PlatformCompanyURL, func() (interface{}, error) {
+		details := companyDetails
+		if err := s.fetchCompanyDetails(&details); err != nil {
+			return details, err
+		}
+		if s.opts.CompanyCache != nil {
+			s.opts.CompanyCache.Put(details)
+		}
+		return details, nil
+	})
 	if err != nil {
 		log.Printf("Error fetching company details: %v", err)
+		s.emit(ScrapeEvent{Type: EventError, URL: companyDetails.PlatformCompanyURL, Error: err.Error()})
 	}
 
-	return description, companyDetails, nil
+	return description, fetched.(CompanyDetails), nil
+}
+
+// cachedCompanyDetails looks up company details in the shared cache, counting
+// hits and misses for the run summary.
+func (s *IndeedScraper) cachedCompanyDetails(platformCompanyURL string) (CompanyDetails, bool) {
+	if s.opts.CompanyCache == nil {
+		return CompanyDetails{}, false
+	}
+
+	details, ok := s.opts.CompanyCache.Get(platformCompanyURL)
+	s.mu.Lock()
+	if ok {
+		s.summary.CompanyCacheHits++
+	} else {
+		s.summary.CompanyCacheMisses++
+	}
+	s.mu.Unlock()
+	return details, ok
+}
+
+func (s *IndeedScraper) Summary() RunSummary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.summary
 }
 func (s *IndeedScraper) visitPages(c *colly.Collector, config ScrapeConfig) error {
 	baseURL := fmt.Sprintf("https://%s.indeed.com/jobs", config.Country)
@@ -110,27 +258,33 @@ func (s *IndeedScraper) visitPages(c *colly.Collector, config ScrapeConfig) erro
 		query.Set("start", fmt.Sprintf("%d", page*10))
 		fullURL := fmt.Sprintf("%s?%s", baseURL, query.Encode())
 
+		s.cardsOnPage, s.noResultsOnPage = 0, false
 		err := c.Visit(fullURL)
 		if err != nil {
 			log.Printf("Error visiting page %d: %v", page, err)
+			s.emit(ScrapeEvent{Type: EventError, URL: fullURL, Page: page + 1, Error: err.Error()})
+			if errors.Is(err, ErrBlocked) {
+				return err
+			}
 			if page == 0 {
 				return fmt.Errorf("error visiting first page: %w", err)
 			}
 			continue
 		}
 
-		// Add a longer delay between pages
-		time.Sleep(time.Duration(rand.Intn(5)+5) * time.Second)
+		// A challenge page served with a 200 status matches no job cards
+		// without saying there are no results.
+		if page == 0 && s.cardsOnPage == 0 && !s.noResultsOnPage {
+			return &BlockedError{URL: fullURL, Reason: "first results page has no job cards"}
+		}
+		s.emit(ScrapeEvent{Type: EventPageVisited, URL: fullURL, Page: page + 1, Count: s.cardsOnPage})
 	}
 
 	return nil
 }
 
 func (s *IndeedScraper) fetchCompanyDetails(details *CompanyDetails) error {
-	c := SetupColly("www.indeed.com")
-	if c == nil {
-		return fmt.Errorf("failed to setup collector for company details")
-	}
+	c := s.session.Collector("www.indeed.com")
 
 	c.OnHTML("li[data-testid='companyInfo-industry']", func(e *colly.HTMLElement) {
 		details.CompanyIndustry = e.ChildText("div.css-kaq73 a")
@@ -140,11 +294,24 @@ func (s *IndeedScraper) fetchCompanyDetails(details *CompanyDetails) error {
 		details.CompanyURL = e.ChildAttr("div.css-kaq73 a", "href")
 	})
 
+	c.OnHTML("li[data-testid='companyInfo-employee']", func(e *colly.HTMLElement) {
+		details.CompanySize = e.ChildText("div.css-kaq73")
+	})
+
+	c.OnHTML("li[data-testid='companyInfo-headquartersLocation']", func(e *colly.HTMLElement) {
+		details.Headquarters = e.ChildText("div.css-kaq73")
+	})
+
+	c.OnHTML("[data-testid='companyInfo-rating'], [itemprop='ratingValue']", func(e *colly.HTMLElement) {
+		details.CompanyRating = strings.TrimSpace(e.Text)
+	})
+
 	err := c.Visit(details.PlatformCompanyURL)
 	if err != nil {
 		return fmt.Errorf("error visiting company page: %w", err)
 	}
 
+	details.FetchedAt = time.Now()
 	return nil
 }
 
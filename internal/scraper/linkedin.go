@@ -7,5 +7,13 @@ import (
 type LinkedInScraper struct{}
 
 func (s *LinkedInScraper) Scrape(config ScrapeConfig) ([]JobPosting, error) {
-	return nil, errors.New("LinkedIn scraper not implemented yet")
+	return collectJobs(s, config)
+}
+
+func (s *LinkedInScraper) Stream(config ScrapeConfig, sink JobSink) error {
+	return errors.New("LinkedIn scraper not implemented yet")
+}
+
+func (s *LinkedInScraper) Summary() RunSummary {
+	return RunSummary{}
 }
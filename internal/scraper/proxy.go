@@ -0,0 +1,397 @@
+package scraper
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// ErrNoProxyAvailable is returned when every proxy of the pool is banned.
+var ErrNoProxyAvailable = errors.New("no proxy available")
+
+// ProxyStrategy selects how requests are spread over the proxy pool.
+type ProxyStrategy string
+
+const (
+	// RoundRobin sends each request through the next available proxy.
+	RoundRobin ProxyStrategy = "round_robin"
+	// Sticky keeps every request of a scrape on the same proxy until it is
+	// banned, so the target sees one consistent client per session.
+	Sticky ProxyStrategy = "sticky"
+)
+
+// ProxyPoolConfig configures a ProxyPool.
+type ProxyPoolConfig struct {
+	// URLs are http, https or socks5 proxy URLs, optionally with credentials.
+	URLs     []string
+	Strategy ProxyStrategy
+	// BanDuration is how long a proxy is ejected after being blocked.
+	BanDuration time.Duration
+	// MaxFailures is the number of consecutive connection errors after which
+	// a proxy is ejected.
+	MaxFailures int
+	// HealthCheckURL is fetched through each proxy by CheckHealth.
+	HealthCheckURL string
+}
+
+// ProxyStats reports the state of a proxy of the pool
+// @Description Proxy usage and health
+type ProxyStats struct {
+	URL         string     `json:"url"`
+	Requests    int        `json:"requests"`
+	Failures    int        `json:"failures"`
+	Bans        int        `json:"bans"`
+	BannedUntil *time.Time `json:"banned_until,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastChecked *time.Time `json:"last_checked,omitempty"`
+}
+
+type proxyState struct {
+	url                 *url.URL
+	requests            int
+	failures            int
+	consecutiveFailures int
+	bans                int
+	bannedUntil         time.Time
+	lastError           string
+	lastChecked         time.Time
+}
+
+func (p *proxyState) available(now time.Time) bool {
+	return !now.Before(p.bannedUntil)
+}
+
+// ProxyPool rotates requests over a list of proxies and ejects proxies that
+// get blocked or keep failing. It is safe for concurrent use.
+type ProxyPool struct {
+	mu             sync.Mutex
+	proxies        []*proxyState
+	byURL          map[string]*proxyState
+	strategy       ProxyStrategy
+	banDuration    time.Duration
+	maxFailures    int
+	healthCheckURL string
+	next           int
+	sessions       map[string]*proxyState
+}
+
+func NewProxyPool(cfg ProxyPoolConfig) (*ProxyPool, error) {
+	if len(cfg.URLs) == 0 {
+		return nil, errors.New("proxy pool needs at least one proxy")
+	}
+
+	switch cfg.Strategy {
+	case "":
+		cfg.Strategy = RoundRobin
+	case RoundRobin, Sticky:
+	default:
+		return nil, fmt.Errorf("unsupported proxy strategy: %s", cfg.Strategy)
+	}
+
+	pool := &ProxyPool{
+		byURL:          make(map[string]*proxyState, len(cfg.URLs)),
+		strategy:       cfg.Strategy,
+		banDuration:    cfg.BanDuration,
+		maxFailures:    cfg.MaxFailures,
+		healthCheckURL: cfg.HealthCheckURL,
+		sessions:       make(map[string]*proxyState),
+	}
+	if pool.banDuration <= 0 {
+		pool.banDuration = 10 * time.Minute
+	}
+	if pool.maxFailures <= 0 {
+		pool.maxFailures = 3
+	}
+
+	for _, raw := range cfg.URLs {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL %q: %w", raw, err)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return nil, fmt.Errorf("unsupported proxy scheme %q in %s", u.Scheme, u.Redacted())
+		}
+
+		state := &proxyState{url: u}
+		pool.proxies = append(pool.proxies, state)
+		pool.byURL[u.String()] = state
+	}
+
+	return pool, nil
+}
+
+// LoadProxyList reads proxy URLs from a file with one URL per line. Blank
+// lines and lines starting with # are ignored.
+func LoadProxyList(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var urls []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		urls = append(urls, line)
+	}
+
+	return urls, scanner.Err()
+}
+
+// pick returns the proxy to use for a request of the given session.
+func (p *ProxyPool) pick(session string) (*url.URL, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	if p.strategy == Sticky {
+		if state, ok := p.sessions[session]; ok && state.available(now) {
+			state.requests++
+			return state.url, nil
+		}
+	}
+
+	for i := 0; i < len(p.proxies); i++ {
+		state := p.proxies[p.next]
+		p.next = (p.next + 1) % len(p.proxies)
+		if !state.available(now) {
+			continue
+		}
+
+		if p.strategy == Sticky {
+			p.sessions[session] = state
+		}
+		state.requests++
+		return state.url, nil
+	}
+
+	return nil, ErrNoProxyAvailable
+}
+
+// ReleaseSession forgets the proxy assigned to a sticky session.
+func (p *ProxyPool) ReleaseSession(session string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.sessions, session)
+}
+
+func (p *ProxyPool) reportSuccess(u *url.URL) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if state, ok := p.byURL[u.String()]; ok {
+		state.consecutiveFailures = 0
+	}
+}
+
+// reportFailure records a connection error, ejecting the proxy once it has
+// failed maxFailures times in a row.
+func (p *ProxyPool) reportFailure(u *url.URL, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state, ok := p.byURL[u.String()]
+	if !ok {
+		return
+	}
+	state.failures++
+	state.consecutiveFailures++
+	state.lastError = err.Error()
+	if state.consecutiveFailures >= p.maxFailures {
+		p.banLocked(state, fmt.Sprintf("%d consecutive failures", state.consecutiveFailures))
+	}
+}
+
+// ban ejects a proxy whose requests are being blocked by the target.
+func (p *ProxyPool) ban(u *url.URL, reason string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if state, ok := p.byURL[u.String()]; ok {
+		state.failures++
+		state.lastError = reason
+		p.banLocked(state, reason)
+	}
+}
+
+func (p *ProxyPool) banLocked(state *proxyState, reason string) {
+	state.bans++
+	state.consecutiveFailures = 0
+	state.bannedUntil = time.Now().Add(p.banDuration)
+	log.Printf("Ejected proxy %s until %s: %s", state.url.Redacted(), state.bannedUntil.Format(time.RFC3339), reason)
+}
+
+// Stats returns a snapshot of every proxy of the pool.
+func (p *ProxyPool) Stats() []ProxyStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	stats := make([]ProxyStats, len(p.proxies))
+	for i, state := range p.proxies {
+		stats[i] = ProxyStats{
+			URL:       state.url.Redacted(),
+			Requests:  state.requests,
+			Failures:  state.failures,
+			Bans:      state.bans,
+			LastError: state.lastError,
+		}
+		if !state.available(now) {
+			bannedUntil := state.bannedUntil
+			stats[i].BannedUntil = &bannedUntil
+		}
+		if !state.lastChecked.IsZero() {
+			lastChecked := state.lastChecked
+			stats[i].LastChecked = &lastChecked
+		}
+	}
+	return stats
+}
+
+// CheckHealth fetches the health check URL through every proxy, lifting the
+// ban of proxies that respond normally and ejecting those that do not.
+func (p *ProxyPool) CheckHealth(ctx context.Context) {
+	if p.healthCheckURL == "" {
+		return
+	}
+
+	p.mu.Lock()
+	proxies := make([]*url.URL, len(p.proxies))
+	for i, state := range p.proxies {
+		proxies[i] = state.url
+	}
+	p.mu.Unlock()
+
+	for _, u := range proxies {
+		err := p.probe(ctx, u)
+
+		p.mu.Lock()
+		state := p.byURL[u.String()]
+		state.lastChecked = time.Now()
+		if err == nil {
+			state.bannedUntil = time.Time{}
+			state.consecutiveFailures = 0
+		} else {
+			state.lastError = err.Error()
+			if state.available(time.Now()) {
+				p.banLocked(state, "health check: "+err.Error())
+			}
+		}
+		p.mu.Unlock()
+	}
+}
+
+// RunHealthChecks calls CheckHealth every interval until ctx is done.
+func (p *ProxyPool) RunHealthChecks(ctx context.Context, interval time.Duration) {
+	if interval <= 0 || p.healthCheckURL == "" {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.CheckHealth(ctx)
+		}
+	}
+}
+
+func (p *ProxyPool) probe(ctx context.Context, u *url.URL) error {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.healthCheckURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", getRandomUserAgent())
+
+	// Probes are minutes apart, so the connection is not kept for the next.
+	transport := &http.Transport{Proxy: http.ProxyURL(u)}
+	defer transport.CloseIdleConnections()
+
+	client := &http.Client{Transport: transport}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	reason, err := detectBlockResponse(resp)
+	if err != nil {
+		return err
+	}
+	if reason != "" {
+		return errors.New(reason)
+	}
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+type proxyContextKey struct{}
+
+// proxyFromContext is used as http.Transport.Proxy so that the proxy chosen by
+// proxyTransport is the one the request is sent through.
+func proxyFromContext(req *http.Request) (*url.URL, error) {
+	u, _ := req.Context().Value(proxyContextKey{}).(*url.URL)
+	return u, nil
+}
+
+// proxyTransport routes requests through the pool and reports the outcome of
+// each request back to it.
+type proxyTransport struct {
+	next    http.RoundTripper
+	pool    *ProxyPool
+	session string
+}
+
+func (t *proxyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	u, err := t.pool.pick(t.session)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.WithValue(req.Context(), proxyContextKey{}, u)
+	ctx = context.WithValue(ctx, colly.ProxyURLKey, u.Redacted())
+	resp, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		t.pool.reportFailure(u, err)
+		return nil, err
+	}
+
+	reason, err := detectBlockResponse(resp)
+	if err != nil {
+		resp.Body.Close()
+		t.pool.reportFailure(u, err)
+		return nil, err
+	}
+	if reason != "" {
+		t.pool.ban(u, reason)
+	} else {
+		t.pool.reportSuccess(u)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,222 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+)
+
+// standInProxy is a local HTTP proxy that answers every request itself with
+// status and body, counting the requests it received.
+type standInProxy struct {
+	*httptest.Server
+	requests atomic.Int64
+	status   atomic.Int64
+	body     atomic.Value
+}
+
+func newStandInProxy(t *testing.T) *standInProxy {
+	t.Helper()
+	p := &standInProxy{}
+	p.status.Store(http.StatusOK)
+	p.body.Store("<html><body>results</body></html>")
+	p.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p.requests.Add(1)
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(int(p.status.Load()))
+		io.WriteString(w, p.body.Load().(string))
+	}))
+	t.Cleanup(p.Close)
+	return p
+}
+
+func newTestPool(t *testing.T, cfg ProxyPoolConfig) *ProxyPool {
+	t.Helper()
+	pool, err := NewProxyPool(cfg)
+	if err != nil {
+		t.Fatalf("NewProxyPool: %v", err)
+	}
+	return pool
+}
+
+func mustPick(t *testing.T, pool *ProxyPool, session string) string {
+	t.Helper()
+	u, err := pool.pick(session)
+	if err != nil {
+		t.Fatalf("pick: %v", err)
+	}
+	return u.String()
+}
+
+func TestNewProxyPoolValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  ProxyPoolConfig
+	}{
+		{"no proxies", ProxyPoolConfig{}},
+		{"unknown strategy", ProxyPoolConfig{URLs: []string{"http://a:1"}, Strategy: "random"}},
+		{"unsupported scheme", ProxyPoolConfig{URLs: []string{"ftp://a:1"}}},
+	}
+	for _, tt := range tests {
+		if _, err := NewProxyPool(tt.cfg); err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+	}
+}
+
+func TestRoundRobin(t *testing.T) {
+	pool := newTestPool(t, ProxyPoolConfig{URLs: []string{"http://a:1", "http://b:1", "http://c:1"}})
+
+	var got []string
+	for i := 0; i < 4; i++ {
+		got = append(got, mustPick(t, pool, "s"))
+	}
+	want := []string{"http://a:1", "http://b:1", "http://c:1", "http://a:1"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("picks %v, want %v", got, want)
+		}
+	}
+}
+
+func TestStickySessions(t *testing.T) {
+	pool := newTestPool(t, ProxyPoolConfig{URLs: []string{"http://a:1", "http://b:1"}, Strategy: Sticky})
+
+	first := mustPick(t, pool, "one")
+	for i := 0; i < 3; i++ {
+		if got := mustPick(t, pool, "one"); got != first {
+			t.Fatalf("session moved from %s to %s", first, got)
+		}
+	}
+	if other := mustPick(t, pool, "two"); other == first {
+		t.Errorf("second session shares proxy %s", other)
+	}
+
+	u, _ := url.Parse(first)
+	pool.ban(u, "blocked")
+	if got := mustPick(t, pool, "one"); got == first {
+		t.Errorf("session stayed on banned proxy %s", got)
+	}
+
+	pool.ReleaseSession("one")
+	if _, ok := pool.sessions["one"]; ok {
+		t.Error("released session is still assigned")
+	}
+}
+
+func TestEjection(t *testing.T) {
+	pool := newTestPool(t, ProxyPoolConfig{URLs: []string{"http://a:1", "http://b:1"}, MaxFailures: 2})
+	a, _ := url.Parse("http://a:1")
+	b, _ := url.Parse("http://b:1")
+
+	pool.reportFailure(a, errors.New("connection refused"))
+	pool.reportSuccess(a)
+	pool.reportFailure(a, errors.New("connection refused"))
+	if stats := pool.Stats(); stats[0].BannedUntil != nil {
+		t.Fatal("proxy ejected although its failures were not consecutive")
+	}
+
+	pool.reportFailure(a, errors.New("connection refused"))
+	stats := pool.Stats()
+	if stats[0].BannedUntil == nil || stats[0].Bans != 1 || stats[0].Failures != 3 {
+		t.Fatalf("proxy not ejected after consecutive failures: %+v", stats[0])
+	}
+	for i := 0; i < 3; i++ {
+		if got := mustPick(t, pool, "s"); got != b.String() {
+			t.Fatalf("picked ejected proxy %s", got)
+		}
+	}
+
+	pool.ban(b, "HTTP 403 Forbidden")
+	if _, err := pool.pick("s"); !errors.Is(err, ErrNoProxyAvailable) {
+		t.Errorf("pick with every proxy banned: %v, want ErrNoProxyAvailable", err)
+	}
+}
+
+func TestProxyTransport(t *testing.T) {
+	healthy := newStandInProxy(t)
+	blocked := newStandInProxy(t)
+	blocked.status.Store(http.StatusForbidden)
+
+	pool := newTestPool(t, ProxyPoolConfig{URLs: []string{blocked.URL, healthy.URL}})
+	client := &http.Client{Transport: &proxyTransport{
+		next:    &http.Transport{Proxy: proxyFromContext},
+		pool:    pool,
+		session: "s",
+	}}
+
+	for i := 0; i < 4; i++ {
+		resp, err := client.Get("http://jobs.example/search?q=go")
+		if err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	if got := blocked.requests.Load(); got != 1 {
+		t.Errorf("blocked proxy received %d requests, want 1 before its ejection", got)
+	}
+	if got := healthy.requests.Load(); got != 3 {
+		t.Errorf("healthy proxy received %d requests, want 3", got)
+	}
+	stats := pool.Stats()
+	if stats[0].BannedUntil == nil || stats[0].LastError != "HTTP 403 Forbidden" {
+		t.Errorf("blocked proxy stats: %+v", stats[0])
+	}
+	if stats[1].Requests != 3 || stats[1].BannedUntil != nil {
+		t.Errorf("healthy proxy stats: %+v", stats[1])
+	}
+}
+
+func TestProxyTransportDetectsChallengePages(t *testing.T) {
+	challenge := newStandInProxy(t)
+	challenge.body.Store(`<html><head><title>Just a moment...</title></head></html>`)
+
+	pool := newTestPool(t, ProxyPoolConfig{URLs: []string{challenge.URL}})
+	client := &http.Client{Transport: &proxyTransport{next: &http.Transport{Proxy: proxyFromContext}, pool: pool}}
+
+	resp, err := client.Get("http://jobs.example/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != challenge.body.Load().(string) {
+		t.Errorf("body after inspection: %q", body)
+	}
+	if _, err := pool.pick(""); !errors.Is(err, ErrNoProxyAvailable) {
+		t.Errorf("proxy serving a challenge page was not ejected: %v", err)
+	}
+}
+
+func TestCheckHealth(t *testing.T) {
+	recovered := newStandInProxy(t)
+	failing := newStandInProxy(t)
+	failing.status.Store(http.StatusBadGateway)
+
+	pool := newTestPool(t, ProxyPoolConfig{
+		URLs:           []string{recovered.URL, failing.URL},
+		HealthCheckURL: "http://health.example/robots.txt",
+	})
+	u, _ := url.Parse(recovered.URL)
+	pool.ban(u, "HTTP 429 Too Many Requests")
+
+	pool.CheckHealth(context.Background())
+
+	stats := pool.Stats()
+	if stats[0].BannedUntil != nil || stats[0].LastChecked == nil {
+		t.Errorf("healthy proxy is still banned: %+v", stats[0])
+	}
+	if stats[1].BannedUntil == nil || stats[1].LastError != "HTTP 502" || stats[1].LastChecked == nil {
+		t.Errorf("failing proxy was not ejected: %+v", stats[1])
+	}
+	if recovered.requests.Load() != 1 || failing.requests.Load() != 1 {
+		t.Errorf("health check requests: %d and %d, want 1 each", recovered.requests.Load(), failing.requests.Load())
+	}
+}
@@ -0,0 +1,264 @@
+package scraper
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sourceDomains maps each source to the domain its requests are limited under,
+// so that country subdomains such as fr.indeed.com share one budget.
+var sourceDomains = map[ScraperType]string{
+	Indeed:   "indeed.com",
+	LinkedIn: "linkedin.com",
+}
+
+// Politeness configures how fast a domain may be crawled.
+type Politeness struct {
+	// RequestsPerSecond is the sustained request rate. Zero disables limiting.
+	RequestsPerSecond float64
+	// Burst is the number of requests that may be sent back to back.
+	Burst int
+	// Jitter is the upper bound of a random delay added to every request.
+	Jitter time.Duration
+}
+
+// BlockBackoff configures the cool-down applied to a domain that blocks us.
+// The cool-down starts at Initial and doubles on every consecutive block, up
+// to Max.
+type BlockBackoff struct {
+	Initial time.Duration
+	Max     time.Duration
+}
+
+// DomainLimiter is a token-bucket rate limiter keyed by domain. A single
+// instance is shared by every collector of the process, so concurrent scrapes
+// draw from the same per-domain budget. Domains that block us are paused for
+// an exponentially growing cool-down.
+type DomainLimiter struct {
+	mu            sync.Mutex
+	defaultPolicy Politeness
+	policies      map[string]Politeness
+	backoff       BlockBackoff
+	buckets       map[string]*tokenBucket
+}
+
+// NewDomainLimiter creates a limiter applying the per-source policies to the
+// domains of those sources and defaultPolicy to every other domain.
+func NewDomainLimiter(defaultPolicy Politeness, sources map[ScraperType]Politeness, backoff BlockBackoff) *DomainLimiter {
+	if backoff.Initial <= 0 {
+		backoff.Initial = 30 * time.Second
+	}
+	if backoff.Max < backoff.Initial {
+		backoff.Max = backoff.Initial
+	}
+
+	policies := make(map[string]Politeness, len(sources))
+	for source, policy := range sources {
+		if domain, ok := sourceDomains[source]; ok {
+			policies[domain] = policy
+		}
+	}
+
+	return &DomainLimiter{
+		defaultPolicy: defaultPolicy,
+		policies:      policies,
+		backoff:       backoff,
+		buckets:       make(map[string]*tokenBucket),
+	}
+}
+
+// Wait blocks until a request to host is allowed or ctx is done.
+func (l *DomainLimiter) Wait(ctx context.Context, host string) error {
+	return sleepContext(ctx, l.bucket(host).reserve(time.Now()))
+}
+
+// Penalize starts or extends the cool-down of the domain of host after it
+// blocked a request, and returns the cool-down duration.
+func (l *DomainLimiter) Penalize(host string) time.Duration {
+	return l.bucket(host).penalize(time.Now(), l.backoff)
+}
+
+// Reward resets the cool-down backoff of the domain of host after a request
+// went through unblocked.
+func (l *DomainLimiter) Reward(host string) {
+	l.bucket(host).reward()
+}
+
+// EnforceCrawlDelay keeps requests to the domain of host at least delay
+// apart, as asked by its robots.txt. The delay is a floor on top of the
+// configured politeness, which still applies when it is slower.
+func (l *DomainLimiter) EnforceCrawlDelay(host string, delay time.Duration) {
+	l.bucket(host).enforceMinInterval(delay)
+}
+
+func (l *DomainLimiter) bucket(host string) *tokenBucket {
+	domain := limitDomain(host)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket, ok := l.buckets[domain]
+	if !ok {
+		policy, found := l.policies[domain]
+		if !found {
+			policy = l.defaultPolicy
+		}
+		bucket = newTokenBucket(policy)
+		l.buckets[domain] = bucket
+	}
+	return bucket
+}
+
+// limitDomain returns the key a host is rate limited under.
+func limitDomain(host string) string {
+	host = strings.ToLower(host)
+	for _, domain := range sourceDomains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return domain
+		}
+	}
+	return host
+}
+
+// tokenBucket hands out reservations rather than blocking, so waiting happens
+// outside of any lock.
+type tokenBucket struct {
+	mu            sync.Mutex
+	policy        Politeness
+	tokens        float64
+	last          time.Time
+	cooldownUntil time.Time
+	cooldown      time.Duration
+	// minInterval is the crawl delay asked by the domain's robots.txt. It
+	// slows the bucket down without changing its configured policy.
+	minInterval time.Duration
+}
+
+func newTokenBucket(policy Politeness) *tokenBucket {
+	if policy.Burst < 1 {
+		policy.Burst = 1
+	}
+	return &tokenBucket{policy: policy, tokens: float64(policy.Burst)}
+}
+
+// reserve takes a token and returns how long the caller must wait for it.
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var jitter time.Duration
+	if b.policy.Jitter > 0 {
+		jitter = time.Duration(rand.Int63n(int64(b.policy.Jitter)))
+	}
+	if now.Before(b.cooldownUntil) {
+		jitter += b.cooldownUntil.Sub(now)
+		now = b.cooldownUntil
+	}
+
+	rate, burst := b.limits()
+	if rate <= 0 {
+		return jitter
+	}
+
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * rate
+		if b.tokens > burst {
+			b.tokens = burst
+		}
+	}
+	b.last = now
+	b.tokens--
+
+	if b.tokens >= 0 {
+		return jitter
+	}
+	deficit := -b.tokens / rate
+	return time.Duration(deficit*float64(time.Second)) + jitter
+}
+
+// limits returns the request rate and burst of the bucket, slowed down to
+// the crawl delay of the domain if its policy is faster.
+func (b *tokenBucket) limits() (rate, burst float64) {
+	rate, burst = b.policy.RequestsPerSecond, float64(b.policy.Burst)
+	if b.minInterval > 0 {
+		if maxRate := 1 / b.minInterval.Seconds(); rate <= 0 || rate > maxRate {
+			rate = maxRate
+		}
+		burst = 1
+	}
+	return rate, burst
+}
+
+func (b *tokenBucket) penalize(now time.Time, backoff BlockBackoff) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if now.Before(b.cooldownUntil) {
+		// Requests already in flight when the block began do not count as
+		// further blocks.
+		return b.cooldownUntil.Sub(now)
+	}
+
+	switch {
+	case b.cooldown == 0:
+		b.cooldown = backoff.Initial
+	case b.cooldown*2 > backoff.Max:
+		b.cooldown = backoff.Max
+	default:
+		b.cooldown *= 2
+	}
+	b.cooldownUntil = now.Add(b.cooldown)
+	// No tokens accumulate during the cool-down.
+	b.tokens = 0
+	b.last = b.cooldownUntil
+	return b.cooldown
+}
+
+func (b *tokenBucket) enforceMinInterval(interval time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.minInterval = interval
+	if b.tokens > 1 {
+		b.tokens = 1
+	}
+}
+
+func (b *tokenBucket) reward() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.cooldown = 0
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// limitedTransport waits for the domain limiter before every request.
+type limitedTransport struct {
+	next    http.RoundTripper
+	limiter *DomainLimiter
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.Wait(req.Context(), req.URL.Hostname()); err != nil {
+		return nil, err
+	}
+	return t.next.RoundTrip(req)
+}
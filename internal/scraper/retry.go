@@ -0,0 +1,133 @@
+package scraper
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy configures how transient fetch errors are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts per request, including the
+	// first one. Values below 2 disable retries.
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// RetryableStatusCodes are the HTTP statuses worth retrying. Connection
+	// errors and timeouts are always retried; blocks never are.
+	RetryableStatusCodes []int
+	// Timeout bounds each attempt from the moment it leaves the domain
+	// limiter queue until its body is read. Zero uses a 60s default.
+	Timeout time.Duration
+}
+
+// Validate reports settings that would make the policy misbehave.
+func (p RetryPolicy) Validate() error {
+	switch {
+	case p.MaxAttempts < 0:
+		return errors.New("max attempts must not be negative")
+	case p.InitialBackoff < 0 || p.MaxBackoff < 0:
+		return errors.New("backoffs must not be negative")
+	case p.MaxBackoff > 0 && p.MaxBackoff < p.InitialBackoff:
+		return fmt.Errorf("max backoff %s is below the initial backoff %s", p.MaxBackoff, p.InitialBackoff)
+	case p.Timeout < 0:
+		return errors.New("timeout must not be negative")
+	}
+	for _, code := range p.RetryableStatusCodes {
+		if code < 400 || code > 599 {
+			return fmt.Errorf("retryable status code %d is not an HTTP error status", code)
+		}
+	}
+	return nil
+}
+
+// attemptTimeout returns the deadline of each attempt.
+func (p RetryPolicy) attemptTimeout() time.Duration {
+	if p.Timeout > 0 {
+		return p.Timeout
+	}
+	return requestTimeout
+}
+
+// backoff returns the delay before the given retry.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	return Backoff(p.InitialBackoff, p.MaxBackoff, retry)
+}
+
+// Backoff returns the delay before the given retry, counted from 1, doubling
+// from initial up to maxDelay with jitter over the upper half.
+func Backoff(initial, maxDelay time.Duration, retry int) time.Duration {
+	d := initial
+	for i := 1; i < retry && d < maxDelay; i++ {
+		d *= 2
+	}
+	if maxDelay > 0 && d > maxDelay {
+		d = maxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+func (p RetryPolicy) retryableStatus(code int) bool {
+	for _, retryable := range p.RetryableStatusCodes {
+		if code == retryable {
+			return true
+		}
+	}
+	return false
+}
+
+// retryTransport retries requests that fail with a transient error.
+type retryTransport struct {
+	next   http.RoundTripper
+	policy RetryPolicy
+}
+
+// RoundTrip sends req, then a clone of it with a fresh body for every retry:
+// a RoundTripper must not modify the request it is given.
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	attemptReq := req
+	for attempt := 1; ; attempt++ {
+		resp, err := t.next.RoundTrip(attemptReq)
+
+		if attempt >= t.policy.MaxAttempts || !t.retryable(req, resp, err) {
+			return resp, err
+		}
+
+		if err != nil {
+			log.Printf("Attempt %d/%d for %s failed: %v", attempt, t.policy.MaxAttempts, req.URL, err)
+		} else {
+			log.Printf("Attempt %d/%d for %s returned HTTP %d", attempt, t.policy.MaxAttempts, req.URL, resp.StatusCode)
+			io.Copy(io.Discard, io.LimitReader(resp.Body, maxBlockPeek))
+			resp.Body.Close()
+		}
+
+		if err := sleepContext(req.Context(), t.policy.backoff(attempt)); err != nil {
+			return nil, err
+		}
+		attemptReq = req.Clone(req.Context())
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq.Body = body
+		}
+	}
+}
+
+func (t *retryTransport) retryable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Body != nil && req.GetBody == nil {
+		return false
+	}
+	if err != nil {
+		return !errors.Is(err, ErrBlocked) && req.Context().Err() == nil
+	}
+	return t.policy.retryableStatus(resp.StatusCode)
+}
@@ -0,0 +1,168 @@
+package scraper
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyValidate(t *testing.T) {
+	valid := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Second, MaxBackoff: 30 * time.Second, RetryableStatusCodes: []int{500, 503}, Timeout: 30 * time.Second}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("valid policy: %v", err)
+	}
+	if err := (RetryPolicy{}).Validate(); err != nil {
+		t.Errorf("zero policy: %v", err)
+	}
+
+	invalid := map[string]RetryPolicy{
+		"negative attempts":   {MaxAttempts: -1},
+		"negative backoff":    {InitialBackoff: -time.Second},
+		"max below initial":   {InitialBackoff: time.Minute, MaxBackoff: time.Second},
+		"negative timeout":    {Timeout: -time.Second},
+		"success status code": {RetryableStatusCodes: []int{200}},
+	}
+	for name, policy := range invalid {
+		if err := policy.Validate(); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+// TestAttemptTimeoutExcludesLimiterWait checks that requests queued behind
+// the domain limiter for longer than the attempt timeout still go through,
+// and that retries get a fresh deadline.
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		retry int
+		delay time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+		{6, 8 * time.Second},
+	}
+	for _, tt := range tests {
+		// Jitter spreads the delay over its upper half.
+		for i := 0; i < 20; i++ {
+			if got := Backoff(time.Second, 8*time.Second, tt.retry); got < tt.delay/2 || got > tt.delay {
+				t.Errorf("Backoff(%d) = %s, want between %s and %s", tt.retry, got, tt.delay/2, tt.delay)
+			}
+		}
+	}
+
+	if got := Backoff(0, 0, 3); got != 0 {
+		t.Errorf("Backoff without an initial delay = %s, want 0", got)
+	}
+}
+
+func TestAttemptTimeoutExcludesLimiterWait(t *testing.T) {
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	defer server.Close()
+
+	policy := RetryPolicy{
+		MaxAttempts:          2,
+		InitialBackoff:       10 * time.Millisecond,
+		MaxBackoff:           10 * time.Millisecond,
+		RetryableStatusCodes: []int{http.StatusServiceUnavailable},
+		Timeout:              100 * time.Millisecond,
+	}
+	limiter := NewDomainLimiter(Politeness{RequestsPerSecond: 10, Burst: 1}, nil, BlockBackoff{})
+
+	var transport http.RoundTripper = &deadlineTransport{next: http.DefaultTransport, timeout: policy.attemptTimeout()}
+	transport = &limitedTransport{next: transport, limiter: limiter}
+	transport = &retryTransport{next: transport, policy: policy}
+	client := &http.Client{Transport: transport}
+
+	// Five requests at 10 per second: the last ones wait in the limiter
+	// queue for several times the attempt timeout.
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				errs <- fmt.Errorf("HTTP %s", resp.Status)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("request failed: %v", err)
+	}
+	if got := requests.Load(); got != 6 {
+		t.Errorf("server received %d requests, want 6 with one retry", got)
+	}
+}
+
+// roundTripFunc adapts a function to an http.RoundTripper.
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestRetryTransportLeavesRequestIntact(t *testing.T) {
+	var bodies []string
+	var sent []*http.Request
+	next := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		bodies = append(bodies, string(body))
+		sent = append(sent, req)
+		status := http.StatusOK
+		if len(sent) == 1 {
+			status = http.StatusServiceUnavailable
+		}
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+	})
+	transport := &retryTransport{next: next, policy: RetryPolicy{MaxAttempts: 2, RetryableStatusCodes: []int{http.StatusServiceUnavailable}}}
+
+	req, err := http.NewRequest(http.MethodPost, "https://example.com/jobs", strings.NewReader("query"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := req.Body
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || len(sent) != 2 {
+		t.Fatalf("HTTP %d after %d attempts, want 200 after 2", resp.StatusCode, len(sent))
+	}
+	if bodies[0] != "query" || bodies[1] != "query" {
+		t.Errorf("bodies %q, want the full body on every attempt", bodies)
+	}
+	if sent[1] == req {
+		t.Error("retry sent the caller's request")
+	}
+	if req.Body != body {
+		t.Error("caller's request body replaced")
+	}
+}
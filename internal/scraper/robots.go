@@ -0,0 +1,213 @@
+package scraper
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/temoto/robotstxt"
+	"golang.org/x/sync/singleflight"
+)
+
+// RobotsPolicy decides what happens to requests disallowed by robots.txt.
+type RobotsPolicy string
+
+const (
+	// RobotsObey refuses disallowed requests and honors crawl-delay.
+	RobotsObey RobotsPolicy = "obey"
+	// RobotsWarn logs disallowed requests but sends them anyway.
+	RobotsWarn RobotsPolicy = "warn"
+	// RobotsIgnore does not fetch robots.txt at all.
+	RobotsIgnore RobotsPolicy = "ignore"
+)
+
+// ErrDisallowedByRobots matches every RobotsError with errors.Is.
+var ErrDisallowedByRobots = errors.New("disallowed by robots.txt")
+
+// RobotsError reports a request refused under the obey policy.
+type RobotsError struct {
+	URL string
+}
+
+func (e *RobotsError) Error() string {
+	return fmt.Sprintf("%s is disallowed by robots.txt", e.URL)
+}
+
+func (e *RobotsError) Is(target error) bool {
+	return target == ErrDisallowedByRobots
+}
+
+// robotsRetryAfter is how long a robots.txt that could not be fetched is
+// treated as allowing everything before it is fetched again.
+const robotsRetryAfter = 5 * time.Minute
+
+type robotsEntry struct {
+	data      *robotstxt.RobotsData
+	expiresAt time.Time
+}
+
+// RobotsChecker fetches and caches robots.txt per host and applies the
+// configured policy of each source. It is safe for concurrent use and meant
+// to be shared by all scrapes.
+type RobotsChecker struct {
+	mu            sync.Mutex
+	defaultPolicy RobotsPolicy
+	policies      map[string]RobotsPolicy
+	ttl           time.Duration
+	entries       map[string]robotsEntry
+	// fetches lets concurrent scrapes of a host share one robots.txt fetch.
+	fetches singleflight.Group
+}
+
+// NewRobotsChecker creates a checker applying the per-source policies to the
+// domains of those sources and defaultPolicy to every other domain.
+func NewRobotsChecker(defaultPolicy RobotsPolicy, sources map[ScraperType]RobotsPolicy, ttl time.Duration) (*RobotsChecker, error) {
+	if defaultPolicy == "" {
+		defaultPolicy = RobotsObey
+	}
+	if err := validRobotsPolicy(defaultPolicy); err != nil {
+		return nil, err
+	}
+
+	policies := make(map[string]RobotsPolicy, len(sources))
+	for source, policy := range sources {
+		if err := validRobotsPolicy(policy); err != nil {
+			return nil, err
+		}
+		if domain, ok := sourceDomains[source]; ok {
+			policies[domain] = policy
+		}
+	}
+
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+
+	return &RobotsChecker{
+		defaultPolicy: defaultPolicy,
+		policies:      policies,
+		ttl:           ttl,
+		entries:       make(map[string]robotsEntry),
+	}, nil
+}
+
+func validRobotsPolicy(policy RobotsPolicy) error {
+	switch policy {
+	case RobotsObey, RobotsWarn, RobotsIgnore:
+		return nil
+	default:
+		return fmt.Errorf("unsupported robots policy: %s", policy)
+	}
+}
+
+// Policy returns the policy applied to requests to host.
+func (r *RobotsChecker) Policy(host string) RobotsPolicy {
+	if policy, ok := r.policies[limitDomain(host)]; ok {
+		return policy
+	}
+	return r.defaultPolicy
+}
+
+// SourcePolicy returns the policy applied to the domain of source.
+func (r *RobotsChecker) SourcePolicy(source ScraperType) RobotsPolicy {
+	if domain, ok := sourceDomains[source]; ok {
+		return r.Policy(domain)
+	}
+	return r.defaultPolicy
+}
+
+// robots returns the robots.txt of the request's host, fetching it through
+// transport when it is not cached.
+func (r *RobotsChecker) robots(req *http.Request, transport http.RoundTripper) *robotstxt.RobotsData {
+	key := req.URL.Scheme + "://" + req.URL.Host
+
+	r.mu.Lock()
+	entry, ok := r.entries[key]
+	r.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.data
+	}
+
+	fetched, _, _ := r.fetches.Do(key, func() (interface{}, error) {
+		data, err := fetchRobots(req, key+"/robots.txt", transport)
+		entry := robotsEntry{data: data, expiresAt: time.Now().Add(r.ttl)}
+		if err != nil {
+			log.Printf("Error fetching %s/robots.txt, allowing all paths for now: %v", key, err)
+			entry = robotsEntry{expiresAt: time.Now().Add(robotsRetryAfter)}
+		}
+
+		r.mu.Lock()
+		r.entries[key] = entry
+		r.mu.Unlock()
+		return entry.data, nil
+	})
+
+	return fetched.(*robotstxt.RobotsData)
+}
+
+func fetchRobots(req *http.Request, robotsURL string, transport http.RoundTripper) (*robotstxt.RobotsData, error) {
+	robotsReq, err := http.NewRequestWithContext(req.Context(), http.MethodGet, robotsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	robotsReq.Header.Set("User-Agent", req.Header.Get("User-Agent"))
+
+	resp, err := transport.RoundTrip(robotsReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return robotstxt.FromResponse(resp)
+}
+
+// robotsTransport applies the robots.txt policy to every request of a
+// session before it is sent. robots.txt itself is fetched through fetch,
+// which skips block detection: a site refusing to serve its robots.txt is not
+// blocking the scrape.
+type robotsTransport struct {
+	next    http.RoundTripper
+	fetch   http.RoundTripper
+	checker *RobotsChecker
+	limiter *DomainLimiter
+	session *Session
+}
+
+func (t *robotsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Hostname()
+	policy := t.checker.Policy(host)
+	if policy == RobotsIgnore || strings.HasSuffix(req.URL.Path, "/robots.txt") {
+		return t.next.RoundTrip(req)
+	}
+
+	data := t.checker.robots(req, t.fetch)
+	if data == nil {
+		return t.next.RoundTrip(req)
+	}
+
+	group := data.FindGroup(req.Header.Get("User-Agent"))
+	if policy == RobotsObey && group.CrawlDelay > 0 && t.limiter != nil {
+		t.limiter.EnforceCrawlDelay(host, group.CrawlDelay)
+	}
+
+	path := req.URL.EscapedPath()
+	if req.URL.RawQuery != "" {
+		path += "?" + req.URL.RawQuery
+	}
+	if group.Test(path) {
+		return t.next.RoundTrip(req)
+	}
+
+	t.session.robotsDisallowed.Add(1)
+	if policy == RobotsWarn {
+		log.Printf("Fetching %s although robots.txt disallows it (policy: warn)", req.URL)
+		return t.next.RoundTrip(req)
+	}
+
+	log.Printf("Skipping %s: disallowed by robots.txt (policy: obey)", req.URL)
+	return nil, &RobotsError{URL: req.URL.String()}
+}
@@ -0,0 +1,119 @@
+package scraper
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// robotsServer serves robots with status at /robots.txt and a results page
+// everywhere else.
+type robotsServer struct {
+	*httptest.Server
+	host          string
+	robotsFetches atomic.Int64
+}
+
+func newRobotsServer(t *testing.T, status int, robots string) *robotsServer {
+	t.Helper()
+	s := &robotsServer{host: "127.0.0.1"}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		if r.URL.Path != "/robots.txt" {
+			io.WriteString(w, "<html>results</html>")
+			return
+		}
+		s.robotsFetches.Add(1)
+		// Slow enough for concurrent scrapes to overlap.
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(status)
+		io.WriteString(w, robots)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func newTestRobotsChecker(t *testing.T) *RobotsChecker {
+	t.Helper()
+	checker, err := NewRobotsChecker(RobotsObey, nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return checker
+}
+
+func TestForbiddenRobotsIsNotABlock(t *testing.T) {
+	quietLogs(t)
+	server := newRobotsServer(t, http.StatusForbidden, "<html><title>Blocked - Indeed.com</title></html>")
+	limiter := NewDomainLimiter(Politeness{}, nil, BlockBackoff{Initial: time.Minute})
+
+	s := NewSession(Options{Limiter: limiter, Robots: newTestRobotsChecker(t)})
+	defer s.Close()
+
+	if err := s.Collector(server.host).Visit(server.URL + "/jobs"); err != nil {
+		t.Fatalf("visit: %v", err)
+	}
+	if wait := limiter.bucket(server.host).reserve(time.Now()); wait > 0 {
+		t.Errorf("domain cooling down for %s after a refused robots.txt", wait)
+	}
+}
+
+func TestRobotsFetchedOncePerHost(t *testing.T) {
+	quietLogs(t)
+	server := newRobotsServer(t, http.StatusOK, "User-agent: *\nDisallow: /private\n")
+	checker := newTestRobotsChecker(t)
+
+	var wg sync.WaitGroup
+	var disallowed atomic.Int64
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s := NewSession(Options{Robots: checker})
+			defer s.Close()
+			if err := s.Collector(server.host).Visit(server.URL + "/private/jobs"); errors.Is(err, ErrDisallowedByRobots) {
+				disallowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := server.robotsFetches.Load(); got != 1 {
+		t.Errorf("robots.txt fetched %d times, want 1", got)
+	}
+	if got := disallowed.Load(); got != 8 {
+		t.Errorf("%d of 8 disallowed requests refused", got)
+	}
+}
+
+func TestCrawlDelayIsAFloor(t *testing.T) {
+	quietLogs(t)
+	server := newRobotsServer(t, http.StatusOK, "User-agent: *\nCrawl-delay: 2\n")
+	policy := Politeness{RequestsPerSecond: 5, Burst: 3}
+	limiter := NewDomainLimiter(policy, nil, BlockBackoff{})
+
+	s := NewSession(Options{Limiter: limiter, Robots: newTestRobotsChecker(t)})
+	defer s.Close()
+	if err := s.Collector(server.host).Visit(server.URL + "/jobs"); err != nil {
+		t.Fatalf("visit: %v", err)
+	}
+
+	bucket := limiter.bucket(server.host)
+	if bucket.policy.RequestsPerSecond != 5 || bucket.policy.Burst != 3 {
+		t.Errorf("crawl delay rewrote the policy: %+v", bucket.policy)
+	}
+	if rate, burst := bucket.limits(); rate != 0.5 || burst != 1 {
+		t.Errorf("limits with a 2s crawl delay: %v per second, burst %v", rate, burst)
+	}
+
+	slower := newTokenBucket(Politeness{RequestsPerSecond: 0.1, Burst: 2})
+	slower.enforceMinInterval(2 * time.Second)
+	if rate, burst := slower.limits(); rate != 0.1 || burst != 1 {
+		t.Errorf("crawl delay sped up a slower policy: %v per second, burst %v", rate, burst)
+	}
+}
@@ -1,25 +1,74 @@
 package scraper
 
 import (
+	"errors"
 	"fmt"
-	"log"
-	"net"
-	"net/http"
-	"time"
 
 	"github.com/corpix/uarand"
-	"github.com/gocolly/colly/v2"
-	"github.com/gocolly/colly/v2/extensions"
 )
 
 type Scraper interface {
+	// Scrape returns the postings found for config. If the source blocks the
+	// scrape, the returned error matches ErrBlocked and the postings scraped
+	// before the block are returned along with it.
 	Scrape(config ScrapeConfig) ([]JobPosting, error)
+	// Stream scrapes the postings for config and passes each one to sink as
+	// soon as it is complete, so callers can store them while the scrape is
+	// still running. Postings passed before an error are not taken back.
+	Stream(config ScrapeConfig, sink JobSink) error
+	// Summary returns the counters collected by the last call to Scrape.
+	Summary() RunSummary
 }
 
-func NewScraper(scraperType ScraperType) (Scraper, error) {
+// JobSink receives the postings of a streaming scrape. It is called from a
+// single goroutine at a time.
+type JobSink func(job JobPosting)
+
+// collectJobs runs a streaming scrape and gathers its postings, keeping them
+// when the scrape is blocked as documented by Scraper.Scrape.
+func collectJobs(s Scraper, config ScrapeConfig) ([]JobPosting, error) {
+	jobs := make([]JobPosting, 0)
+	err := s.Stream(config, func(job JobPosting) {
+		jobs = append(jobs, job)
+	})
+	if err != nil && !(errors.Is(err, ErrBlocked) && len(jobs) > 0) {
+		return nil, err
+	}
+	return jobs, err
+}
+
+// Options holds the dependencies shared by every scraper of the application.
+type Options struct {
+	// CompanyCache caches company details across scrapes. It may be nil.
+	CompanyCache *CompanyCache
+	// Limiter rate limits every request made by collectors. It may be nil.
+	Limiter *DomainLimiter
+	// Proxies routes requests through a proxy pool. It may be nil, in which
+	// case requests are sent directly.
+	Proxies *ProxyPool
+	// Retry is applied to listing, detail and company requests.
+	Retry RetryPolicy
+	// PartialResults keeps postings whose details could not be fetched,
+	// flagged as incomplete, instead of discarding them.
+	PartialResults bool
+	// Robots applies the robots.txt policy of each source. It may be nil, in
+	// which case robots.txt is not consulted.
+	Robots *RobotsChecker
+	// DetailConcurrency is the number of job detail pages fetched in parallel
+	// during a scrape.
+	DetailConcurrency int
+	// Batch groups streamed postings into the micro-batches they are saved in.
+	Batch BatchPolicy
+	// Events receives the progress events of a scrape. It may be nil. Unlike
+	// the other options it belongs to a single scrape.
+	Events EventHook
+}
+
+// NewScraper creates a scraper for a single scrape of the given source.
+func NewScraper(scraperType ScraperType, opts Options) (Scraper, error) {
 	switch scraperType {
 	case Indeed:
-		return &IndeedScraper{}, nil
+		return &IndeedScraper{opts: opts}, nil
 	case LinkedIn:
 		return &LinkedInScraper{}, nil
 	default:
@@ -30,51 +79,3 @@ func NewScraper(scraperType ScraperType) (Scraper, error) {
 func getRandomUserAgent() string {
 	return uarand.GetRandom()
 }
-
-func SetupColly(allowedDomains ...string) *colly.Collector {
-	c := colly.NewCollector(
-		colly.UserAgent(getRandomUserAgent()),
-		colly.AllowedDomains(allowedDomains...),
-		colly.MaxDepth(2),
-	)
-
-	extensions.RandomUserAgent(c)
-
-	c.OnRequest(func(r *colly.Request) {
-		r.Headers.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8")
-		r.Headers.Set("Accept-Language", "en-US,en;q=0.5")
-		r.Headers.Set("DNT", "1")
-		r.Headers.Set("Connection", "keep-alive")
-		r.Headers.Set("Upgrade-Insecure-Requests", "1")
-		r.Headers.Set("Sec-Fetch-Dest", "document")
-		r.Headers.Set("Sec-Fetch-Mode", "navigate")
-		r.Headers.Set("Sec-Fetch-Site", "none")
-		r.Headers.Set("Sec-Fetch-User", "?1")
-		r.Headers.Set("Cache-Control", "max-age=0")
-	})
-
-	transport := &http.Transport{
-		DisableCompression: false,
-		DialContext: (&net.Dialer{
-			Timeout:   30 * time.Second,
-			KeepAlive: 30 * time.Second,
-		}).DialContext,
-		MaxIdleConns:          100,
-		IdleConnTimeout:       90 * time.Second,
-		TLSHandshakeTimeout:   10 * time.Second,
-		ExpectContinueTimeout: 1 * time.Second,
-	}
-	c.WithTransport(transport)
-
-	c.SetRequestTimeout(60 * time.Second)
-
-	c.OnRequest(func(r *colly.Request) {
-		log.Printf("Scraping: %s", r.URL)
-	})
-
-	c.OnError(func(r *colly.Response, err error) {
-		log.Printf("Error scraping %s: %s", r.Request.URL, err)
-	})
-
-	return c
-}
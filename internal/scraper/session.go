@@ -0,0 +1,199 @@
+package scraper
+
+import (
+	"context"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync/atomic"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/google/uuid"
+)
+
+// Session owns the HTTP state of a single scrape: one transport chain, one
+// cookie jar and one browser fingerprint shared by every collector of the
+// scrape, so keep-alive connections and cookies survive from the listing
+// pages to the detail and company pages.
+type Session struct {
+	ID        string
+	opts      Options
+	base      *colly.Collector
+	transport *http.Transport
+	userAgent string
+
+	connectionsOpened atomic.Int64
+	connectionsReused atomic.Int64
+	robotsDisallowed  atomic.Int64
+}
+
+// NewSession creates a session using the shared dependencies of opts.
+func NewSession(opts Options) *Session {
+	s := &Session{
+		ID:        uuid.New().String(),
+		opts:      opts,
+		userAgent: getRandomUserAgent(),
+	}
+
+	s.transport = &http.Transport{
+		DisableCompression: false,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   10,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+
+	var roundTripper http.RoundTripper = s.transport
+	if opts.Proxies != nil {
+		s.transport.Proxy = proxyFromContext
+		roundTripper = &proxyTransport{next: roundTripper, pool: opts.Proxies, session: s.ID}
+	}
+	roundTripper = &traceTransport{next: roundTripper, session: s}
+
+	// robots.txt is fetched politely but without block detection or retries.
+	var robotsFetch http.RoundTripper = &deadlineTransport{next: roundTripper, timeout: requestTimeout}
+	if opts.Limiter != nil {
+		robotsFetch = &limitedTransport{next: robotsFetch, limiter: opts.Limiter}
+	}
+
+	roundTripper = &blockTransport{next: roundTripper, limiter: opts.Limiter}
+	roundTripper = &deadlineTransport{next: roundTripper, timeout: opts.Retry.attemptTimeout()}
+	if opts.Limiter != nil {
+		roundTripper = &limitedTransport{next: roundTripper, limiter: opts.Limiter}
+	}
+	if opts.Retry.MaxAttempts > 1 {
+		roundTripper = &retryTransport{next: roundTripper, policy: opts.Retry}
+	}
+	if opts.Robots != nil {
+		roundTripper = &robotsTransport{next: roundTripper, fetch: robotsFetch, checker: opts.Robots, limiter: opts.Limiter, session: s}
+	}
+
+	// Collectors cloned from base share its HTTP client, and with it the
+	// transport and cookie jar. URL revisits are allowed because clones also
+	// share the visited-URL store, and several jobs may link one company page.
+	s.base = colly.NewCollector(
+		colly.UserAgent(s.userAgent),
+		colly.MaxDepth(2),
+		colly.AllowURLRevisit(),
+	)
+	s.base.WithTransport(roundTripper)
+	// Each attempt is bounded by deadlineTransport once it leaves the limiter
+	// queue, so that waiting for the domain's budget and retry backoffs do
+	// not count against it.
+	s.base.SetRequestTimeout(0)
+	// robots.txt is handled by robotsTransport according to Options.Robots.
+	s.base.IgnoreRobotsTxt = true
+
+	return s
+}
+
+// Collector returns a new collector of the session restricted to
+// allowedDomains.
+func (s *Session) Collector(allowedDomains ...string) *colly.Collector {
+	c := s.base.Clone()
+	c.AllowedDomains = allowedDomains
+
+	c.OnRequest(func(r *colly.Request) {
+		r.Headers.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8")
+		r.Headers.Set("Accept-Language", "en-US,en;q=0.5")
+		r.Headers.Set("DNT", "1")
+		r.Headers.Set("Connection", "keep-alive")
+		r.Headers.Set("Upgrade-Insecure-Requests", "1")
+		r.Headers.Set("Sec-Fetch-Dest", "document")
+		r.Headers.Set("Sec-Fetch-Mode", "navigate")
+		r.Headers.Set("Sec-Fetch-Site", "none")
+		r.Headers.Set("Sec-Fetch-User", "?1")
+		r.Headers.Set("Cache-Control", "max-age=0")
+	})
+
+	c.OnRequest(func(r *colly.Request) {
+		log.Printf("Scraping: %s", r.URL)
+	})
+
+	c.OnError(func(r *colly.Response, err error) {
+		log.Printf("Error scraping %s: %s", r.Request.URL, err)
+	})
+
+	return c
+}
+
+// ConnectionStats returns how many connections the session opened and how
+// many requests reused an idle connection.
+func (s *Session) ConnectionStats() (opened, reused int) {
+	return int(s.connectionsOpened.Load()), int(s.connectionsReused.Load())
+}
+
+// RobotsDisallowed returns how many requests of the session were disallowed
+// by robots.txt, whether or not they were sent.
+func (s *Session) RobotsDisallowed() int {
+	return int(s.robotsDisallowed.Load())
+}
+
+// Close releases the session's idle connections and proxy assignment.
+func (s *Session) Close() {
+	s.transport.CloseIdleConnections()
+	if s.opts.Proxies != nil {
+		s.opts.Proxies.ReleaseSession(s.ID)
+	}
+}
+
+// requestTimeout bounds a request from the moment it is sent until its body
+// is read, unless the retry policy sets another timeout.
+const requestTimeout = 60 * time.Second
+
+// deadlineTransport bounds each request passing through it with timeout. The
+// deadline keeps running while the body is read and is released when it is
+// closed.
+type deadlineTransport struct {
+	next    http.RoundTripper
+	timeout time.Duration
+}
+
+func (t *deadlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	resp, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
+
+// traceTransport counts new and reused connections of a session.
+type traceTransport struct {
+	next    http.RoundTripper
+	session *Session
+}
+
+func (t *traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				t.session.connectionsReused.Add(1)
+			} else {
+				t.session.connectionsOpened.Add(1)
+			}
+		},
+	}
+	return t.next.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+}
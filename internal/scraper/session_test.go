@@ -0,0 +1,91 @@
+package scraper
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func newPageServer(tb testing.TB) (*httptest.Server, string) {
+	tb.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, "<html><body><div class=\"job\">Backend Engineer</div></body></html>")
+	}))
+	tb.Cleanup(server.Close)
+
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return server, u.Hostname()
+}
+
+func quietLogs(tb testing.TB) {
+	tb.Helper()
+	out := log.Writer()
+	log.SetOutput(io.Discard)
+	tb.Cleanup(func() { log.SetOutput(out) })
+}
+
+func visit(tb testing.TB, s *Session, host, pageURL string) {
+	tb.Helper()
+	if err := s.Collector(host).Visit(pageURL); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+// BenchmarkSessionConnections compares the connections opened by one session
+// shared by every request of a scrape with those opened when each request
+// gets a session of its own, as collectors did before sessions existed.
+func BenchmarkSessionConnections(b *testing.B) {
+	quietLogs(b)
+	server, host := newPageServer(b)
+
+	b.Run("shared", func(b *testing.B) {
+		s := NewSession(Options{})
+		defer s.Close()
+
+		for i := 0; i < b.N; i++ {
+			visit(b, s, host, fmt.Sprintf("%s/jobs?start=%d", server.URL, i))
+		}
+
+		opened, reused := s.ConnectionStats()
+		b.ReportMetric(float64(opened), "opened")
+		b.ReportMetric(float64(reused), "reused")
+	})
+
+	b.Run("per-request", func(b *testing.B) {
+		var opened, reused int
+		for i := 0; i < b.N; i++ {
+			s := NewSession(Options{})
+			visit(b, s, host, fmt.Sprintf("%s/jobs?start=%d", server.URL, i))
+			o, r := s.ConnectionStats()
+			opened += o
+			reused += r
+			s.Close()
+		}
+
+		b.ReportMetric(float64(opened), "opened")
+		b.ReportMetric(float64(reused), "reused")
+	})
+}
+
+func TestSessionReusesConnections(t *testing.T) {
+	quietLogs(t)
+	server, host := newPageServer(t)
+
+	s := NewSession(Options{})
+	defer s.Close()
+	for i := 0; i < 5; i++ {
+		visit(t, s, host, fmt.Sprintf("%s/jobs?start=%d", server.URL, i*10))
+	}
+
+	if opened, reused := s.ConnectionStats(); opened != 1 || reused != 4 {
+		t.Errorf("opened %d and reused %d connections, want 1 and 4", opened, reused)
+	}
+}
@@ -1,31 +1,111 @@
 package scraper
 
 import (
+	"errors"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 // JobPosting represents a job posting
 // @Description Job posting details
 type JobPosting struct {
-	ID             string         `json:"id"`
-	PlatformJobId  string         `json:"platform_job_id"`
-	Title          string         `json:"title"`
-	Location       string         `json:"location"`
-	Summary        string         `json:"summary"`
-	Description    string         `json:"description"`
-	URL            string         `json:"url"`
-	CompanyDetails CompanyDetails `json:"company_details"`
-	Source         ScraperType    `json:"source"`
-	CreatedAt      time.Time      `json:"createdAt"`
+	ID             string         `json:"id" bson:"id"`
+	PlatformJobId  string         `json:"platform_job_id" bson:"platform_job_id"`
+	Title          string         `json:"title" bson:"title"`
+	Location       string         `json:"location" bson:"location"`
+	Summary        string         `json:"summary" bson:"summary"`
+	Description    string         `json:"description" bson:"description"`
+	URL            string         `json:"url" bson:"url"`
+	CompanyDetails CompanyDetails `json:"company_details" bson:"company_details"`
+	CompanyID      string         `json:"company_id,omitempty" bson:"company_id,omitempty"`
+	Source         ScraperType    `json:"source" bson:"source"`
+	// CreatedAt is when the posting was first saved.
+	CreatedAt  time.Time      `json:"createdAt" bson:"created_at"`
+	Incomplete bool           `json:"incomplete" bson:"incomplete"`
+	Duplicates []DuplicateRef `json:"duplicates,omitempty" bson:"-"`
+	// Hidden postings matched a blocklist rule. They are stored but left
+	// out of listings and notifications.
+	Hidden       bool   `json:"hidden,omitempty" bson:"hidden"`
+	HiddenReason string `json:"hidden_reason,omitempty" bson:"hidden_reason"`
+}
+
+// DuplicateRef links a canonical job posting to another posting of the same role
+// @Description Reference to a duplicate job posting
+type DuplicateRef struct {
+	ID            string      `json:"id"`
+	PlatformJobId string      `json:"platform_job_id"`
+	Source        ScraperType `json:"source"`
+	URL           string      `json:"url"`
 }
 
 // CompanyDetails represents details about a company
 // @Description Company details
 type CompanyDetails struct {
-	PlatformCompanyURL string `json:"platform_company_url"`
-	CompanyURL         string `json:"url"`
-	CompanyIndustry    string `json:"industry"`
-	Company            string `json:"name"`
+	PlatformCompanyURL string `json:"platform_company_url" bson:"platform_company_url"`
+	CompanyURL         string `json:"url" bson:"url"`
+	CompanyIndustry    string `json:"industry" bson:"industry"`
+	Company            string `json:"name" bson:"name"`
+	CompanySize        string `json:"size,omitempty" bson:"size,omitempty"`
+	CompanyRating      string `json:"rating,omitempty" bson:"rating,omitempty"`
+	Headquarters       string `json:"headquarters,omitempty" bson:"headquarters,omitempty"`
+	// FetchedAt is when the details were fetched from the company page. It
+	// is zero when they were only read from a posting.
+	FetchedAt time.Time `json:"-" bson:"-"`
+}
+
+// Company represents an employer stored once and referenced by its job postings
+// @Description Company shared by job postings
+type Company struct {
+	ID                 string      `json:"id" bson:"id"`
+	PlatformCompanyURL string      `json:"platform_company_url" bson:"platform_company_url"`
+	Name               string      `json:"name" bson:"name"`
+	Industry           string      `json:"industry,omitempty" bson:"industry,omitempty"`
+	Website            string      `json:"website,omitempty" bson:"website,omitempty"`
+	Size               string      `json:"size,omitempty" bson:"size,omitempty"`
+	Rating             string      `json:"rating,omitempty" bson:"rating,omitempty"`
+	Headquarters       string      `json:"headquarters,omitempty" bson:"headquarters,omitempty"`
+	Source             ScraperType `json:"source" bson:"source"`
+	CreatedAt          time.Time   `json:"createdAt" bson:"created_at"`
+	// UpdatedAt is when the details were last fetched from the company
+	// page. It is zero for companies only known from their postings.
+	UpdatedAt time.Time `json:"updatedAt" bson:"updated_at"`
+}
+
+// CompanyID returns the stable ID of the company found at platformCompanyURL,
+// so postings can reference a company before it has been stored.
+func CompanyID(platformCompanyURL string) string {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte(platformCompanyURL)).String()
+}
+
+// Details returns the company as the details embedded in a job posting.
+func (c Company) Details() CompanyDetails {
+	return CompanyDetails{
+		PlatformCompanyURL: c.PlatformCompanyURL,
+		CompanyURL:         c.Website,
+		CompanyIndustry:    c.Industry,
+		Company:            c.Name,
+		CompanySize:        c.Size,
+		CompanyRating:      c.Rating,
+		Headquarters:       c.Headquarters,
+		FetchedAt:          c.UpdatedAt,
+	}
+}
+
+// NewCompany builds the company described by a posting's company details.
+func NewCompany(details CompanyDetails, source ScraperType) Company {
+	return Company{
+		ID:                 CompanyID(details.PlatformCompanyURL),
+		PlatformCompanyURL: details.PlatformCompanyURL,
+		Name:               details.Company,
+		Industry:           details.CompanyIndustry,
+		Website:            details.CompanyURL,
+		Size:               details.CompanySize,
+		Rating:             details.CompanyRating,
+		Headquarters:       details.Headquarters,
+		Source:             source,
+		UpdatedAt:          details.FetchedAt,
+	}
 }
 
 // ScraperType represents the type of job scraper
@@ -45,3 +125,79 @@ type ScrapeConfig struct {
 	Pages    int
 	Source   ScraperType
 }
+
+// RunSummary holds counters collected during a single scrape
+// @Description Counters collected during a scrape
+type RunSummary struct {
+	CompanyCacheHits   int `json:"company_cache_hits" bson:"company_cache_hits"`
+	CompanyCacheMisses int `json:"company_cache_misses" bson:"company_cache_misses"`
+	IncompleteJobs     int `json:"incomplete_jobs" bson:"incomplete_jobs"`
+	ConnectionsOpened  int `json:"connections_opened" bson:"connections_opened"`
+	ConnectionsReused  int `json:"connections_reused" bson:"connections_reused"`
+	// RobotsPolicy is the robots.txt policy the scrape ran under.
+	RobotsPolicy     RobotsPolicy `json:"robots_policy,omitempty" bson:"robots_policy,omitempty"`
+	RobotsDisallowed int          `json:"robots_disallowed" bson:"robots_disallowed"`
+	// BlockedJobs counts the postings dropped or hidden by the blocklist.
+	BlockedJobs int `json:"blocked_jobs" bson:"blocked_jobs"`
+}
+
+// RunStatus represents the state of a scrape run
+// @Description State of a scrape run
+type RunStatus string
+
+const (
+	RunRunning   RunStatus = "running"
+	RunSucceeded RunStatus = "succeeded"
+	RunFailed    RunStatus = "failed"
+	RunBlocked   RunStatus = "blocked"
+)
+
+// ScrapeRun represents a single execution of a scrape
+// @Description Scrape run status and results
+type ScrapeRun struct {
+	ID          string      `json:"id" bson:"id"`
+	UserID      string      `json:"user_id,omitempty" bson:"user_id,omitempty"`
+	Source      ScraperType `json:"source" bson:"source"`
+	JobTitle    string      `json:"job_title" bson:"job_title"`
+	Country     string      `json:"country" bson:"country"`
+	Pages       int         `json:"pages" bson:"pages"`
+	Status      RunStatus   `json:"status" bson:"status"`
+	Error       string      `json:"error,omitempty" bson:"error,omitempty"`
+	JobsScraped int         `json:"jobs_scraped" bson:"jobs_scraped"`
+	Summary     RunSummary  `json:"summary" bson:"summary"`
+	StartedAt   time.Time   `json:"started_at" bson:"started_at"`
+	FinishedAt  *time.Time  `json:"finished_at,omitempty" bson:"finished_at,omitempty"`
+}
+
+// NewScrapeRun creates a running scrape run for config.
+func NewScrapeRun(config ScrapeConfig) ScrapeRun {
+	return ScrapeRun{
+		ID:        uuid.New().String(),
+		Source:    config.Source,
+		JobTitle:  config.JobTitle,
+		Country:   config.Country,
+		Pages:     config.Pages,
+		Status:    RunRunning,
+		StartedAt: time.Now(),
+	}
+}
+
+// Finish records the outcome of the run. Blocked scrapes are marked blocked
+// rather than failed, even if some postings were scraped before the block.
+func (r *ScrapeRun) Finish(jobsScraped int, summary RunSummary, err error) {
+	now := time.Now()
+	r.FinishedAt = &now
+	r.JobsScraped = jobsScraped
+	r.Summary = summary
+
+	switch {
+	case err == nil:
+		r.Status = RunSucceeded
+	case errors.Is(err, ErrBlocked):
+		r.Status = RunBlocked
+		r.Error = err.Error()
+	default:
+		r.Status = RunFailed
+		r.Error = err.Error()
+	}
+}
@@ -0,0 +1,105 @@
+// Package search evaluates saved searches against newly saved job postings
+// and records the postings they match.
+package search
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ayagmar/gojobscraper/internal/filter"
+	"github.com/ayagmar/gojobscraper/internal/scraper"
+)
+
+// SavedSearch is a named set of criteria kept server-side
+// @Description Saved search
+type SavedSearch struct {
+	ID        string          `json:"id" bson:"id"`
+	OwnerID   string          `json:"owner_id" bson:"owner_id"`
+	Name      string          `json:"name" bson:"name"`
+	Criteria  filter.Criteria `json:"criteria" bson:"criteria"`
+	CreatedAt time.Time       `json:"created_at" bson:"created_at"`
+	// FeedToken authenticates the Atom and RSS feeds of the search.
+	FeedToken string `json:"feed_token,omitempty" bson:"feed_token,omitempty"`
+}
+
+// NewFeedToken returns a random token for the feed URLs of a search.
+func NewFeedToken() (string, error) {
+	token := make([]byte, 24)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("failed to generate feed token: %w", err)
+	}
+	return hex.EncodeToString(token), nil
+}
+
+// Match records that a saved search matched a job posting
+// @Description Job posting matched by a saved search
+type Match struct {
+	SearchID string `json:"search_id" bson:"search_id"`
+	// Source and PlatformJobID identify the matched posting across
+	// re-scrapes.
+	Source        scraper.ScraperType `json:"source" bson:"source"`
+	PlatformJobID string              `json:"platform_job_id" bson:"platform_job_id"`
+	// JobID is the ID of the posting when the match was last read or
+	// recorded. A posting scraped again after being cleared gets a new ID.
+	JobID     string    `json:"job_id" bson:"job_id"`
+	MatchedAt time.Time `json:"matched_at" bson:"matched_at"`
+	// Job is the matched posting, attached when matches are read.
+	Job *scraper.JobPosting `json:"job,omitempty" bson:"-"`
+}
+
+// Store provides the saved searches and records their matches.
+type Store interface {
+	GetSearches() ([]SavedSearch, error)
+	// SaveMatches records matches, ignoring the ones already recorded.
+	SaveMatches(matches []Match) error
+}
+
+// Engine matches newly saved job postings against the saved searches.
+type Engine struct {
+	store  Store
+	logger *log.Logger
+}
+
+// NewEngine creates an engine recording matches in store.
+func NewEngine(store Store, logger *log.Logger) *Engine {
+	return &Engine{store: store, logger: logger}
+}
+
+// JobsSaved records the saved searches matched by the newly inserted jobs.
+func (e *Engine) JobsSaved(jobs []scraper.JobPosting) {
+	if len(jobs) == 0 {
+		return
+	}
+
+	searches, err := e.store.GetSearches()
+	if err != nil {
+		e.logger.Printf("Error loading saved searches: %v", err)
+		return
+	}
+
+	now := time.Now()
+	var matches []Match
+	for _, search := range searches {
+		for _, job := range jobs {
+			if search.Criteria.Match(job) {
+				matches = append(matches, Match{
+					SearchID:      search.ID,
+					Source:        job.Source,
+					PlatformJobID: job.PlatformJobId,
+					JobID:         job.ID,
+					MatchedAt:     now,
+				})
+			}
+		}
+	}
+	if len(matches) == 0 {
+		return
+	}
+
+	if err := e.store.SaveMatches(matches); err != nil {
+		e.logger.Printf("Error saving %d saved search matches: %v", len(matches), err)
+	}
+}
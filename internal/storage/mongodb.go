@@ -2,23 +2,86 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
+	"github.com/ayagmar/gojobscraper/internal/filter"
 	"github.com/ayagmar/gojobscraper/internal/scraper"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type MongoDBStorage struct {
-	client     *mongo.Client
-	database   *mongo.Database
-	collection *mongo.Collection
+	client      *mongo.Client
+	database    *mongo.Database
+	collection  *mongo.Collection
+	quarantine  *mongo.Collection
+	companies   *mongo.Collection
+	runs        *mongo.Collection
+	webhooks    *mongo.Collection
+	deliveries  *mongo.Collection
+	subscribers *mongo.Collection
+	alertRules  *mongo.Collection
+	searches    *mongo.Collection
+	matches     *mongo.Collection
+	blockRules  *mongo.Collection
+	// applications is kept apart from jobs so re-scrapes leave it intact.
+	applications *mongo.Collection
+	users        *mongo.Collection
+	apiKeys      *mongo.Collection
+	// scrapeQuotas counts the pages each user reserved per day.
+	scrapeQuotas *mongo.Collection
+}
+
+// quarantinedJob is a posting that could not be stored under a valid identity.
+type quarantinedJob struct {
+	Job           scraper.JobPosting `bson:"job"`
+	Reason        string             `bson:"reason"`
+	QuarantinedAt time.Time          `bson:"quarantined_at"`
 }
 
 func NewMongoDBStorage(uri, dbName string) (*MongoDBStorage, error) {
+	client, err := connectMongoDB(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	database := client.Database(dbName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := checkMongoDBSchema(ctx, database); err != nil {
+		_ = client.Disconnect(ctx)
+		return nil, err
+	}
+
+	return &MongoDBStorage{
+		client:       client,
+		database:     database,
+		collection:   database.Collection("jobs"),
+		quarantine:   database.Collection("quarantined_jobs"),
+		companies:    database.Collection("companies"),
+		runs:         database.Collection("scrape_runs"),
+		scrapeQuotas: database.Collection("scrape_quotas"),
+		webhooks:     database.Collection("webhooks"),
+		deliveries:   database.Collection("webhook_deliveries"),
+		subscribers:  database.Collection("subscribers"),
+		alertRules:   database.Collection("alert_rules"),
+		searches:     database.Collection("saved_searches"),
+		matches:      database.Collection("search_matches"),
+		blockRules:   database.Collection("blocklist_rules"),
+		applications: database.Collection("applications"),
+		users:        database.Collection("users"),
+		apiKeys:      database.Collection("api_keys"),
+	}, nil
+}
+
+func connectMongoDB(uri string) (*mongo.Client, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -33,50 +96,142 @@ func NewMongoDBStorage(uri, dbName string) (*MongoDBStorage, error) {
 	}
 
 	log.Println("Successfully connected to MongoDB")
+	return client, nil
+}
 
-	database := client.Database(dbName)
-	collection := database.Collection("jobs")
-
-	// Create a unique index on platform_job_id
-	_, err = collection.Indexes().CreateOne(
-		context.Background(),
-		mongo.IndexModel{
-			Keys:    bson.D{{Key: "platform_job_id", Value: 1}},
-			Options: options.Index().SetUnique(true),
-		},
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create index: %w", err)
+// quarantineJobs stores postings in the quarantine, keyed by their ID so
+// that saving them again, such as when a batch is retried, stores them once.
+func quarantineJobs(ctx context.Context, quarantine *mongo.Collection, jobs []scraper.JobPosting, reason string) error {
+	now := time.Now()
+	operations := make([]mongo.WriteModel, len(jobs))
+	for i, job := range jobs {
+		operations[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"job.id": job.ID}).
+			SetUpdate(bson.M{"$setOnInsert": quarantinedJob{Job: job, Reason: reason, QuarantinedAt: now}}).
+			SetUpsert(true)
 	}
 
-	return &MongoDBStorage{
-		client:     client,
-		database:   database,
-		collection: collection,
-	}, nil
+	if _, err := quarantine.BulkWrite(ctx, operations, options.BulkWrite().SetOrdered(false)); err != nil {
+		return fmt.Errorf("failed to quarantine jobs: %w", err)
+	}
+	return nil
 }
 
-func (m *MongoDBStorage) SaveJobs(jobs []scraper.JobPosting) error {
+// SaveJobs upserts postings keyed by (source, platform_job_id). A posting's ID
+// and creation time are kept from the first time it was saved. Postings
+// without a platform ID are quarantined instead of being saved. The companies
+// of the postings are upserted and referenced through CompanyID. The postings
+// that did not exist yet are returned, including when some of the postings
+// failed to save: saving them again only updates the inserted ones.
+func (m *MongoDBStorage) SaveJobs(jobs []scraper.JobPosting) ([]scraper.JobPosting, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	// Postings are stamped when they are saved rather than when they were
+	// parsed, so that digests, which read the postings created since the
+	// last one, do not miss postings parsed before a digest but saved after.
+	now := time.Now()
+
 	var operations []mongo.WriteModel
+	var saved []scraper.JobPosting
+	var unidentified []scraper.JobPosting
+	var companies []scraper.Company
 	for _, job := range jobs {
+		if job.PlatformJobId == "" {
+			unidentified = append(unidentified, job)
+			continue
+		}
+
+		if job.CompanyDetails.PlatformCompanyURL != "" {
+			company := scraper.NewCompany(job.CompanyDetails, job.Source)
+			job.CompanyID = company.ID
+			companies = append(companies, company)
+		}
+
+		job.CreatedAt = now
+		update, err := jobUpdate(job)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode job %s: %w", job.ID, err)
+		}
+
 		operation := mongo.NewUpdateOneModel().
-			SetFilter(bson.M{"platform_job_id": job.PlatformJobId}).
-			SetUpdate(bson.M{"$set": job}).
+			SetFilter(bson.M{"source": job.Source, "platform_job_id": job.PlatformJobId}).
+			SetUpdate(update).
 			SetUpsert(true)
 		operations = append(operations, operation)
+		saved = append(saved, job)
+	}
+
+	if len(unidentified) > 0 {
+		if err := quarantineJobs(ctx, m.quarantine, unidentified, "missing platform job id"); err != nil {
+			return nil, err
+		}
+		log.Printf("Quarantined %d jobs without a platform job id", len(unidentified))
+	}
+
+	if len(operations) == 0 {
+		return nil, nil
+	}
+
+	if err := saveCompanies(ctx, m.companies, companies); err != nil {
+		return nil, err
 	}
 
 	opts := options.BulkWrite().SetOrdered(false)
 	result, err := m.collection.BulkWrite(ctx, operations, opts)
+	if result == nil {
+		return nil, fmt.Errorf("failed to save jobs: %w", err)
+	}
+
+	// UpsertedIDs is keyed by the index of the operations that inserted a
+	// document, which is also the index of their posting in saved. The
+	// writes of an unordered bulk write that did not fail are applied, and
+	// reported, even if others did.
+	inserted := make([]scraper.JobPosting, 0, len(result.UpsertedIDs))
+	for i, job := range saved {
+		if _, ok := result.UpsertedIDs[int64(i)]; ok {
+			inserted = append(inserted, job)
+		}
+	}
 	if err != nil {
-		return fmt.Errorf("failed to save jobs: %w", err)
+		return inserted, fmt.Errorf("failed to save jobs: %w", err)
 	}
 
 	log.Printf("Upserted %d jobs, matched %d jobs", result.UpsertedCount, result.MatchedCount)
-	return nil
+	return inserted, nil
+}
+
+// jobUpdate builds an upsert document that refreshes a posting's scraped
+// fields while keeping the ID and creation time of an existing document.
+func jobUpdate(job scraper.JobPosting) (bson.M, error) {
+	raw, err := bson.Marshal(job)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields bson.M
+	if err := bson.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	delete(fields, "id")
+	delete(fields, "created_at")
+	setOnInsert := bson.M{"id": job.ID, "created_at": job.CreatedAt}
+
+	// An incomplete posting must not erase details fetched by an earlier,
+	// complete scrape, so its detail fields are only written on insert.
+	if job.Incomplete {
+		for _, key := range []string{"description", "company_details", "company_id", "incomplete"} {
+			if value, ok := fields[key]; ok {
+				setOnInsert[key] = value
+				delete(fields, key)
+			}
+		}
+	}
+
+	return bson.M{
+		"$set":         fields,
+		"$setOnInsert": setOnInsert,
+	}, nil
 }
 
 func (m *MongoDBStorage) GetJobs() ([]scraper.JobPosting, error) {
@@ -99,6 +254,120 @@ func (m *MongoDBStorage) GetJobs() ([]scraper.JobPosting, error) {
 	return jobs, nil
 }
 
+func (m *MongoDBStorage) GetJob(id string) (scraper.JobPosting, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var job scraper.JobPosting
+	err := m.collection.FindOne(ctx, bson.M{"id": id}).Decode(&job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return scraper.JobPosting{}, ErrNotFound
+	}
+	if err != nil {
+		return scraper.JobPosting{}, fmt.Errorf("failed to query job: %w", err)
+	}
+
+	return job, nil
+}
+
+// EachJob calls fn with every job matching jobFilter, newest first, reading
+// them from a cursor rather than loading them all. Hidden jobs are left out
+// unless includeHidden is set. It stops at the first error returned by fn.
+func (m *MongoDBStorage) EachJob(ctx context.Context, jobFilter filter.JobFilter, includeHidden bool, fn func(job scraper.JobPosting) error) error {
+	query := bson.M{}
+	if jobFilter.Source != "" {
+		query["source"] = jobFilter.Source
+	}
+	if !includeHidden {
+		query["hidden"] = bson.M{"$ne": true}
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetBatchSize(500)
+	cursor, err := m.collection.Find(ctx, query, opts)
+	if err != nil {
+		return fmt.Errorf("failed to query jobs: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var job scraper.JobPosting
+		if err := cursor.Decode(&job); err != nil {
+			return fmt.Errorf("failed to decode job: %w", err)
+		}
+		if !jobFilter.Match(job) {
+			continue
+		}
+		if err := fn(job); err != nil {
+			return err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("failed to iterate jobs: %w", err)
+	}
+	return nil
+}
+
+// GetJobsSince returns the first limit visible jobs matching jobFilter first
+// saved after since, oldest first, and the number of those jobs.
+func (m *MongoDBStorage) GetJobsSince(since time.Time, jobFilter filter.JobFilter, limit int) ([]scraper.JobPosting, int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	query := jobFilterQuery(jobFilter)
+	query["created_at"] = bson.M{"$gt": since}
+	query["hidden"] = bson.M{"$ne": true}
+
+	total, err := m.collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count jobs: %w", err)
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetLimit(int64(limit))
+	cursor, err := m.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query jobs: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var jobs []scraper.JobPosting
+	if err = cursor.All(ctx, &jobs); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode jobs: %w", err)
+	}
+
+	return jobs, int(total), nil
+}
+
+// jobFilterQuery translates jobFilter into a query on the jobs collection.
+func jobFilterQuery(jobFilter filter.JobFilter) bson.M {
+	query := bson.M{}
+	if jobFilter.Source != "" {
+		query["source"] = jobFilter.Source
+	}
+	if jobFilter.Location != "" {
+		query["location"] = wordsPattern(jobFilter.Location)
+	}
+	if jobFilter.Keyword != "" {
+		keyword := wordsPattern(jobFilter.Keyword)
+		query["$or"] = bson.A{
+			bson.M{"title": keyword},
+			bson.M{"summary": keyword},
+			bson.M{"description": keyword},
+			bson.M{"company_details.name": keyword},
+		}
+	}
+	return query
+}
+
+// wordsPattern matches the strings in which phrase appears as whole words,
+// ignoring case, as filters match them.
+func wordsPattern(phrase string) primitive.Regex {
+	return primitive.Regex{Pattern: filter.WordsPattern(phrase), Options: "i"}
+}
+
 func (m *MongoDBStorage) ClearJobs() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
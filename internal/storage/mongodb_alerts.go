@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ayagmar/gojobscraper/internal/notify"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (m *MongoDBStorage) SaveAlertRule(rule notify.AlertRule) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Replace().SetUpsert(true)
+	if _, err := m.alertRules.ReplaceOne(ctx, bson.M{"id": rule.ID}, rule, opts); err != nil {
+		return fmt.Errorf("failed to save alert rule: %w", err)
+	}
+	return nil
+}
+
+func (m *MongoDBStorage) GetAlertRules() ([]notify.AlertRule, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := m.alertRules.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alert rules: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	rules := []notify.AlertRule{}
+	if err = cursor.All(ctx, &rules); err != nil {
+		return nil, fmt.Errorf("failed to decode alert rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+func (m *MongoDBStorage) GetAlertRule(id string) (notify.AlertRule, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var rule notify.AlertRule
+	err := m.alertRules.FindOne(ctx, bson.M{"id": id}).Decode(&rule)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return notify.AlertRule{}, ErrNotFound
+	}
+	if err != nil {
+		return notify.AlertRule{}, fmt.Errorf("failed to query alert rule: %w", err)
+	}
+
+	return rule, nil
+}
+
+func (m *MongoDBStorage) DeleteAlertRule(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := m.alertRules.DeleteOne(ctx, bson.M{"id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete alert rule: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ayagmar/gojobscraper/internal/scraper"
+	"github.com/ayagmar/gojobscraper/internal/tracking"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (m *MongoDBStorage) SaveApplication(application tracking.Application) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Replace().SetUpsert(true)
+	if _, err := m.applications.ReplaceOne(ctx, applicationKey(application.OwnerID, application.Source, application.PlatformJobID), application, opts); err != nil {
+		return fmt.Errorf("failed to save application: %w", err)
+	}
+	return nil
+}
+
+func (m *MongoDBStorage) GetApplication(ownerID string, source scraper.ScraperType, platformJobID string) (tracking.Application, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var application tracking.Application
+	err := m.applications.FindOne(ctx, applicationKey(ownerID, source, platformJobID)).Decode(&application)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return tracking.Application{}, ErrNotFound
+	}
+	if err != nil {
+		return tracking.Application{}, fmt.Errorf("failed to query application: %w", err)
+	}
+
+	return application, nil
+}
+
+func (m *MongoDBStorage) DeleteApplication(ownerID string, source scraper.ScraperType, platformJobID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := m.applications.DeleteOne(ctx, applicationKey(ownerID, source, platformJobID))
+	if err != nil {
+		return fmt.Errorf("failed to delete application: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// jobKey identifies a posting across re-scrapes.
+type jobKey struct {
+	source        scraper.ScraperType
+	platformJobID string
+}
+
+// applicationKey selects the application of an owner to a posting.
+func applicationKey(ownerID string, source scraper.ScraperType, platformJobID string) bson.M {
+	return bson.M{"owner_id": ownerID, "source": source, "platform_job_id": platformJobID}
+}
+
+// GetApplications returns the applications matching query, most recently
+// updated first, with their job postings attached.
+func (m *MongoDBStorage) GetApplications(query tracking.Query) ([]tracking.Application, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if query.OwnerID != "" {
+		filter["owner_id"] = query.OwnerID
+	}
+	if query.Status != "" {
+		filter["status"] = query.Status
+	}
+	if query.Tag != "" {
+		filter["tags"] = query.Tag
+	}
+	if query.RemindBefore != nil {
+		filter["remind_at"] = bson.M{"$lte": *query.RemindBefore}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "updated_at", Value: -1}})
+	cursor, err := m.applications.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query applications: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	applications := []tracking.Application{}
+	if err = cursor.All(ctx, &applications); err != nil {
+		return nil, fmt.Errorf("failed to decode applications: %w", err)
+	}
+
+	if len(applications) == 0 {
+		return applications, nil
+	}
+
+	keys := make(bson.A, len(applications))
+	for i, application := range applications {
+		keys[i] = bson.M{"source": application.Source, "platform_job_id": application.PlatformJobID}
+	}
+	jobCursor, err := m.collection.Find(ctx, bson.M{"$or": keys})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query application jobs: %w", err)
+	}
+	defer jobCursor.Close(ctx)
+
+	var jobs []scraper.JobPosting
+	if err = jobCursor.All(ctx, &jobs); err != nil {
+		return nil, fmt.Errorf("failed to decode application jobs: %w", err)
+	}
+	byKey := make(map[jobKey]scraper.JobPosting, len(jobs))
+	for _, job := range jobs {
+		byKey[jobKey{job.Source, job.PlatformJobId}] = job
+	}
+	for i := range applications {
+		if job, ok := byKey[jobKey{applications[i].Source, applications[i].PlatformJobID}]; ok {
+			applications[i].JobID = job.ID
+			applications[i].Job = &job
+		}
+	}
+
+	return applications, nil
+}
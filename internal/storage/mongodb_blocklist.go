@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ayagmar/gojobscraper/internal/blocklist"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (m *MongoDBStorage) SaveBlockRule(rule blocklist.Rule) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Replace().SetUpsert(true)
+	if _, err := m.blockRules.ReplaceOne(ctx, bson.M{"id": rule.ID}, rule, opts); err != nil {
+		return fmt.Errorf("failed to save blocklist rule: %w", err)
+	}
+	return nil
+}
+
+func (m *MongoDBStorage) GetBlockRules() ([]blocklist.Rule, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := m.blockRules.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query blocklist rules: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	rules := []blocklist.Rule{}
+	if err = cursor.All(ctx, &rules); err != nil {
+		return nil, fmt.Errorf("failed to decode blocklist rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+func (m *MongoDBStorage) DeleteBlockRule(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := m.blockRules.DeleteOne(ctx, bson.M{"id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete blocklist rule: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
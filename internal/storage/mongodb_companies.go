@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ayagmar/gojobscraper/internal/scraper"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// saveCompanies upserts companies keyed by their platform URL. Only non-empty
+// fields are written, so details found by earlier scrapes are kept when a
+// later scrape could not fetch them. updated_at only moves when the details
+// were fetched from the company page, so that companies served from the cache
+// still expire.
+func saveCompanies(ctx context.Context, collection *mongo.Collection, companies []scraper.Company) error {
+	if len(companies) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	operations := make([]mongo.WriteModel, 0, len(companies))
+	for _, company := range companies {
+		fields := bson.M{"source": company.Source}
+		for key, value := range map[string]string{
+			"name":         company.Name,
+			"industry":     company.Industry,
+			"website":      company.Website,
+			"size":         company.Size,
+			"rating":       company.Rating,
+			"headquarters": company.Headquarters,
+		} {
+			if value != "" {
+				fields[key] = value
+			}
+		}
+
+		update := bson.M{
+			"$set":         fields,
+			"$setOnInsert": bson.M{"id": company.ID, "created_at": now},
+		}
+		if !company.UpdatedAt.IsZero() {
+			update["$max"] = bson.M{"updated_at": company.UpdatedAt}
+		}
+
+		operation := mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"platform_company_url": company.PlatformCompanyURL}).
+			SetUpdate(update).
+			SetUpsert(true)
+		operations = append(operations, operation)
+	}
+
+	opts := options.BulkWrite().SetOrdered(false)
+	if _, err := collection.BulkWrite(ctx, operations, opts); err != nil {
+		return fmt.Errorf("failed to save companies: %w", err)
+	}
+	return nil
+}
+
+func (m *MongoDBStorage) GetCompanies() ([]scraper.Company, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	cursor, err := m.companies.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query companies: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var companies []scraper.Company
+	if err = cursor.All(ctx, &companies); err != nil {
+		return nil, fmt.Errorf("failed to decode companies: %w", err)
+	}
+
+	log.Printf("Retrieved %d companies from MongoDB storage", len(companies))
+	return companies, nil
+}
+
+func (m *MongoDBStorage) GetCompany(id string) (scraper.Company, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var company scraper.Company
+	err := m.companies.FindOne(ctx, bson.M{"id": id}).Decode(&company)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return scraper.Company{}, ErrNotFound
+	}
+	if err != nil {
+		return scraper.Company{}, fmt.Errorf("failed to query company: %w", err)
+	}
+
+	return company, nil
+}
+
+// GetJobsByCompany returns the jobs of a company, newest first. Hidden jobs
+// are left out unless includeHidden is set.
+func (m *MongoDBStorage) GetJobsByCompany(companyID string, includeHidden bool) ([]scraper.JobPosting, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	query := bson.M{"company_id": companyID}
+	if !includeHidden {
+		query["hidden"] = bson.M{"$ne": true}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := m.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query company jobs: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var jobs []scraper.JobPosting
+	if err = cursor.All(ctx, &jobs); err != nil {
+		return nil, fmt.Errorf("failed to decode company jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// LookupCompany finds a company by its platform URL, for use as the
+// persistent layer of the scraper's company cache.
+func (m *MongoDBStorage) LookupCompany(platformCompanyURL string) (scraper.Company, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var company scraper.Company
+	err := m.companies.FindOne(ctx, bson.M{"platform_company_url": platformCompanyURL}).Decode(&company)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return scraper.Company{}, false, nil
+	}
+	if err != nil {
+		return scraper.Company{}, false, fmt.Errorf("failed to query company: %w", err)
+	}
+
+	return company, true, nil
+}
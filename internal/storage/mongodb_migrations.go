@@ -0,0 +1,861 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/ayagmar/gojobscraper/internal/scraper"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const migrationsCollection = "schema_migrations"
+
+// mongoMigration is a versioned schema change of the MongoDB backend.
+type mongoMigration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+	Down        func(ctx context.Context, db *mongo.Database) error
+}
+
+// mongoMigrations lists every MongoDB migration in version order. Released
+// migrations must never be edited; add a new version instead.
+var mongoMigrations = []mongoMigration{
+	{
+		Version:     1,
+		Description: "key jobs by source and platform_job_id",
+		Up:          migrateJobIdentityUp,
+		Down:        migrateJobIdentityDown,
+	},
+	{
+		Version:     2,
+		Description: "normalize companies into their own collection",
+		Up:          migrateCompaniesUp,
+		Down:        migrateCompaniesDown,
+	},
+	{
+		Version:     3,
+		Description: "index scrape runs",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("scrape_runs").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+				{Keys: bson.D{{Key: "started_at", Value: -1}}},
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			// Only the indexes are rolled back; the run history is kept.
+			for _, name := range []string{"id_1", "started_at_-1"} {
+				if _, err := db.Collection("scrape_runs").Indexes().DropOne(ctx, name); err != nil && !isIndexNotFound(err) {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version:     4,
+		Description: "add webhooks and their delivery log",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("webhooks").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true),
+			})
+			if err != nil {
+				return err
+			}
+			_, err = db.Collection("webhook_deliveries").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+				{Keys: bson.D{{Key: "webhook_id", Value: 1}, {Key: "created_at", Value: -1}}},
+				{Keys: bson.D{{Key: "status", Value: 1}}},
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			// Only the indexes are rolled back; webhooks and deliveries are kept.
+			if err := dropIndexes(ctx, db.Collection("webhook_deliveries"), "id_1", "webhook_id_1_created_at_-1", "status_1"); err != nil {
+				return err
+			}
+			return dropIndexes(ctx, db.Collection("webhooks"), "id_1")
+		},
+	},
+	{
+		Version:     5,
+		Description: "add email digest subscribers",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("subscribers").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+				{Keys: bson.D{{Key: "unsubscribe_token", Value: 1}}, Options: options.Index().SetUnique(true)},
+				{Keys: bson.D{{Key: "unsubscribed", Value: 1}, {Key: "next_digest_at", Value: 1}}},
+			})
+			if err != nil {
+				return err
+			}
+			// Digests look up the jobs first seen since the previous digest.
+			_, err = db.Collection("jobs").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "created_at", Value: 1}},
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			if err := dropIndexes(ctx, db.Collection("jobs"), "created_at_1"); err != nil {
+				return err
+			}
+			return dropIndexes(ctx, db.Collection("subscribers"), "id_1", "unsubscribe_token_1", "unsubscribed_1_next_digest_at_1")
+		},
+	},
+	{
+		Version:     6,
+		Description: "add chat alert rules",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("alert_rules").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "id", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return dropIndexes(ctx, db.Collection("alert_rules"), "id_1")
+		},
+	},
+	{
+		Version:     7,
+		Description: "add saved searches and their matches",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("saved_searches").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "id", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			})
+			if err != nil {
+				return err
+			}
+			_, err = db.Collection("search_matches").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{Keys: bson.D{{Key: "search_id", Value: 1}, {Key: "job_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+				{Keys: bson.D{{Key: "search_id", Value: 1}, {Key: "matched_at", Value: -1}}},
+			})
+			if err != nil {
+				return err
+			}
+			// Matches are resolved to their postings by ID.
+			_, err = db.Collection("jobs").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "id", Value: 1}},
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			if err := dropIndexes(ctx, db.Collection("jobs"), "id_1"); err != nil {
+				return err
+			}
+			if err := dropIndexes(ctx, db.Collection("search_matches"), "search_id_1_job_id_1", "search_id_1_matched_at_-1"); err != nil {
+				return err
+			}
+			return dropIndexes(ctx, db.Collection("saved_searches"), "id_1")
+		},
+	},
+	{
+		Version:     8,
+		Description: "add blocklist rules",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("blocklist_rules").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "id", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return dropIndexes(ctx, db.Collection("blocklist_rules"), "id_1")
+		},
+	},
+	{
+		Version:     9,
+		Description: "add application tracking",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("applications").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{Keys: bson.D{{Key: "job_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+				{Keys: bson.D{{Key: "status", Value: 1}, {Key: "updated_at", Value: -1}}},
+				{Keys: bson.D{{Key: "tags", Value: 1}}},
+				{Keys: bson.D{{Key: "remind_at", Value: 1}}},
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return dropIndexes(ctx, db.Collection("applications"), "job_id_1", "status_1_updated_at_-1", "tags_1", "remind_at_1")
+		},
+	},
+	{
+		Version:     10,
+		Description: "add users, API keys and record owners",
+		Up:          migrateUsersUp,
+		Down:        migrateUsersDown,
+	},
+	{
+		Version:     11,
+		Description: "index scrape runs by user for quotas",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("scrape_runs").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "started_at", Value: -1}},
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("scrape_runs").Indexes().DropOne(ctx, "user_id_1_started_at_-1")
+			if isIndexNotFound(err) {
+				return nil
+			}
+			return err
+		},
+	},
+	{
+		Version:     12,
+		Description: "key applications by source and platform_job_id",
+		Up:          migrateApplicationKeysUp,
+		Down:        migrateApplicationKeysDown,
+	},
+	{
+		Version:     13,
+		Description: "count scrape quotas per user and day",
+		Up:          migrateScrapeQuotasUp,
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			// The counters hold no user data; Up rebuilds today's from the runs.
+			return db.Collection("scrape_quotas").Drop(ctx)
+		},
+	},
+	{
+		Version:     14,
+		Description: "index quarantined jobs by job id",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			// Not unique: retried batches quarantined postings more than once.
+			_, err := db.Collection("quarantined_jobs").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "job.id", Value: 1}},
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("quarantined_jobs").Indexes().DropOne(ctx, "job.id_1")
+			if isIndexNotFound(err) {
+				return nil
+			}
+			return err
+		},
+	},
+	{
+		Version:     15,
+		Description: "key search matches by source and platform_job_id",
+		Up:          migrateSearchMatchKeysUp,
+		Down:        migrateSearchMatchKeysDown,
+	},
+}
+
+// appliedMigration is the record kept for each migration that has run.
+type appliedMigration struct {
+	Version     int       `bson:"version"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"applied_at"`
+}
+
+// MongoDBMigrator runs the MongoDB migrations and records them in the
+// schema_migrations collection.
+type MongoDBMigrator struct {
+	client     *mongo.Client
+	database   *mongo.Database
+	migrations []mongoMigration
+}
+
+func NewMongoDBMigrator(uri, dbName string) (*MongoDBMigrator, error) {
+	client, err := connectMongoDB(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MongoDBMigrator{
+		client:     client,
+		database:   client.Database(dbName),
+		migrations: mongoMigrations,
+	}, nil
+}
+
+func (m *MongoDBMigrator) Up(ctx context.Context, target int) ([]int, error) {
+	applied, err := loadAppliedMigrations(ctx, m.database)
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []int
+	for _, migration := range m.migrations {
+		if target > 0 && migration.Version > target {
+			break
+		}
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		log.Printf("Applying migration %d: %s", migration.Version, migration.Description)
+		if err := migration.Up(ctx, m.database); err != nil {
+			return versions, fmt.Errorf("migration %d failed: %w", migration.Version, err)
+		}
+
+		record := appliedMigration{
+			Version:     migration.Version,
+			Description: migration.Description,
+			AppliedAt:   time.Now(),
+		}
+		if _, err := m.database.Collection(migrationsCollection).InsertOne(ctx, record); err != nil {
+			return versions, fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+		}
+		versions = append(versions, migration.Version)
+	}
+
+	return versions, nil
+}
+
+func (m *MongoDBMigrator) Down(ctx context.Context, steps int) ([]int, error) {
+	applied, err := loadAppliedMigrations(ctx, m.database)
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []int
+	for i := len(m.migrations) - 1; i >= 0 && len(versions) < steps; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+
+		log.Printf("Reverting migration %d: %s", migration.Version, migration.Description)
+		if err := migration.Down(ctx, m.database); err != nil {
+			return versions, fmt.Errorf("reverting migration %d failed: %w", migration.Version, err)
+		}
+
+		_, err := m.database.Collection(migrationsCollection).DeleteOne(ctx, bson.M{"version": migration.Version})
+		if err != nil {
+			return versions, fmt.Errorf("failed to unrecord migration %d: %w", migration.Version, err)
+		}
+		versions = append(versions, migration.Version)
+	}
+
+	return versions, nil
+}
+
+func (m *MongoDBMigrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := loadAppliedMigrations(ctx, m.database)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, len(m.migrations))
+	for i, migration := range m.migrations {
+		statuses[i] = MigrationStatus{Version: migration.Version, Description: migration.Description}
+		if record, ok := applied[migration.Version]; ok {
+			appliedAt := record.AppliedAt
+			statuses[i].AppliedAt = &appliedAt
+		}
+	}
+
+	return statuses, nil
+}
+
+func (m *MongoDBMigrator) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return m.client.Disconnect(ctx)
+}
+
+func loadAppliedMigrations(ctx context.Context, db *mongo.Database) (map[int]appliedMigration, error) {
+	cursor, err := db.Collection(migrationsCollection).Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query applied migrations: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var records []appliedMigration
+	if err = cursor.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("failed to decode applied migrations: %w", err)
+	}
+
+	applied := make(map[int]appliedMigration, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// checkMongoDBSchema returns ErrSchemaOutdated unless every known migration
+// has been applied to db.
+func checkMongoDBSchema(ctx context.Context, db *mongo.Database) error {
+	applied, err := loadAppliedMigrations(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	var pending []int
+	for _, migration := range mongoMigrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration.Version)
+		}
+	}
+	if len(pending) > 0 {
+		sort.Ints(pending)
+		return fmt.Errorf("%w: pending migrations %v", ErrSchemaOutdated, pending)
+	}
+
+	return nil
+}
+
+// migrateJobIdentityUp moves documents written before postings were keyed by
+// (source, platform_job_id) onto the current schema: legacy field names are
+// renamed, documents without a platform ID are quarantined, and the unique
+// index on platform_job_id alone is replaced by a compound one.
+func migrateJobIdentityUp(ctx context.Context, db *mongo.Database) error {
+	jobs := db.Collection("jobs")
+
+	// Documents saved without bson tags used lowercased Go field names.
+	renames := []bson.M{
+		{
+			"companydetails.platformcompanyurl": "companydetails.platform_company_url",
+			"companydetails.companyurl":         "companydetails.url",
+			"companydetails.companyindustry":    "companydetails.industry",
+			"companydetails.company":            "companydetails.name",
+		},
+		{
+			"platformjobid":  "platform_job_id",
+			"companydetails": "company_details",
+			"createdat":      "created_at",
+		},
+	}
+	for _, rename := range renames {
+		if _, err := jobs.UpdateMany(ctx, bson.M{}, bson.M{"$rename": rename}); err != nil {
+			return fmt.Errorf("failed to rename legacy fields: %w", err)
+		}
+	}
+
+	if err := quarantineUnidentifiedJobs(ctx, db); err != nil {
+		return err
+	}
+
+	if _, err := jobs.Indexes().DropOne(ctx, "platform_job_id_1"); err != nil && !isIndexNotFound(err) {
+		return fmt.Errorf("failed to drop legacy index: %w", err)
+	}
+
+	_, err := jobs.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "source", Value: 1}, {Key: "platform_job_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create index: %w", err)
+	}
+
+	return nil
+}
+
+// migrateJobIdentityDown drops the compound index. Renamed fields and
+// quarantined documents are left as they are.
+func migrateJobIdentityDown(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("jobs").Indexes().DropOne(ctx, "source_1_platform_job_id_1")
+	if err != nil && !isIndexNotFound(err) {
+		return fmt.Errorf("failed to drop index: %w", err)
+	}
+	return nil
+}
+
+// migrateCompaniesUp indexes the companies collection and backfills it, and
+// the company_id reference, from the details embedded in stored postings.
+func migrateCompaniesUp(ctx context.Context, db *mongo.Database) error {
+	companies := db.Collection("companies")
+	jobs := db.Collection("jobs")
+
+	_, err := companies.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "platform_company_url", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create company indexes: %w", err)
+	}
+
+	_, err = jobs.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "company_id", Value: 1}}})
+	if err != nil {
+		return fmt.Errorf("failed to create company_id index: %w", err)
+	}
+
+	cursor, err := jobs.Find(ctx, bson.M{"company_details.platform_company_url": bson.M{"$nin": bson.A{"", nil}}})
+	if err != nil {
+		return fmt.Errorf("failed to query jobs with companies: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	backfilled := 0
+	for cursor.Next(ctx) {
+		var job scraper.JobPosting
+		if err := cursor.Decode(&job); err != nil {
+			return fmt.Errorf("failed to decode job: %w", err)
+		}
+
+		company := scraper.NewCompany(job.CompanyDetails, job.Source)
+		if err := saveCompanies(ctx, companies, []scraper.Company{company}); err != nil {
+			return err
+		}
+
+		filter := bson.M{"source": job.Source, "platform_job_id": job.PlatformJobId}
+		if _, err := jobs.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"company_id": company.ID}}); err != nil {
+			return fmt.Errorf("failed to reference company from job: %w", err)
+		}
+		backfilled++
+	}
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("failed to iterate jobs: %w", err)
+	}
+
+	log.Printf("Linked %d stored jobs to their companies", backfilled)
+	return nil
+}
+
+// migrateCompaniesDown drops the companies collection and the company_id
+// references. Company details embedded in postings are unaffected.
+func migrateCompaniesDown(ctx context.Context, db *mongo.Database) error {
+	if err := db.Collection("companies").Drop(ctx); err != nil {
+		return fmt.Errorf("failed to drop companies: %w", err)
+	}
+
+	jobs := db.Collection("jobs")
+	if _, err := jobs.Indexes().DropOne(ctx, "company_id_1"); err != nil && !isIndexNotFound(err) {
+		return fmt.Errorf("failed to drop company_id index: %w", err)
+	}
+	if _, err := jobs.UpdateMany(ctx, bson.M{}, bson.M{"$unset": bson.M{"company_id": ""}}); err != nil {
+		return fmt.Errorf("failed to remove company references: %w", err)
+	}
+
+	return nil
+}
+
+// quarantineUnidentifiedJobs moves stored postings with an empty or missing
+// platform ID out of the jobs collection.
+func quarantineUnidentifiedJobs(ctx context.Context, db *mongo.Database) error {
+	jobs := db.Collection("jobs")
+	filter := bson.M{"$or": bson.A{
+		bson.M{"platform_job_id": bson.M{"$exists": false}},
+		bson.M{"platform_job_id": ""},
+	}}
+
+	cursor, err := jobs.Find(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to query unidentified jobs: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var unidentified []scraper.JobPosting
+	if err = cursor.All(ctx, &unidentified); err != nil {
+		return fmt.Errorf("failed to decode unidentified jobs: %w", err)
+	}
+	if len(unidentified) == 0 {
+		return nil
+	}
+
+	if err := quarantineJobs(ctx, db.Collection("quarantined_jobs"), unidentified, "missing platform job id"); err != nil {
+		return err
+	}
+
+	result, err := jobs.DeleteMany(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to remove unidentified jobs: %w", err)
+	}
+
+	log.Printf("Quarantined %d stored jobs without a platform job id", result.DeletedCount)
+	return nil
+}
+
+func migrateUsersUp(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("users").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create users index: %w", err)
+	}
+
+	_, err = db.Collection("api_keys").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create api_keys indexes: %w", err)
+	}
+
+	for _, collection := range []string{"saved_searches", "subscribers"} {
+		_, err := db.Collection(collection).Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys: bson.D{{Key: "owner_id", Value: 1}},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create %s owner index: %w", collection, err)
+		}
+	}
+
+	// Every user tracks its own application to a job.
+	applications := db.Collection("applications")
+	if _, err := applications.Indexes().DropOne(ctx, "job_id_1"); err != nil && !isIndexNotFound(err) {
+		return fmt.Errorf("failed to drop applications job_id index: %w", err)
+	}
+	_, err = applications.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "job_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "updated_at", Value: -1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create applications owner indexes: %w", err)
+	}
+
+	return nil
+}
+
+// migrateUsersDown fails to restore the unique applications job_id index
+// when several users tracked the same job.
+func migrateUsersDown(ctx context.Context, db *mongo.Database) error {
+	applications := db.Collection("applications")
+	for _, index := range []string{"owner_id_1_job_id_1", "owner_id_1_updated_at_-1"} {
+		if _, err := applications.Indexes().DropOne(ctx, index); err != nil && !isIndexNotFound(err) {
+			return fmt.Errorf("failed to drop applications index %s: %w", index, err)
+		}
+	}
+	_, err := applications.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "job_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to restore applications job_id index: %w", err)
+	}
+
+	for _, collection := range []string{"saved_searches", "subscribers"} {
+		if _, err := db.Collection(collection).Indexes().DropOne(ctx, "owner_id_1"); err != nil && !isIndexNotFound(err) {
+			return fmt.Errorf("failed to drop %s owner index: %w", collection, err)
+		}
+	}
+
+	// Users and their API keys are kept, without their indexes.
+	if err := dropIndexes(ctx, db.Collection("api_keys"), "id_1", "hash_1", "user_id_1"); err != nil {
+		return err
+	}
+	return dropIndexes(ctx, db.Collection("users"), "id_1")
+}
+
+// dropIndexes drops the named indexes of a collection, skipping those that
+// do not exist. Rollbacks drop the indexes a migration added rather than
+// its collections, so that no user data is lost.
+func dropIndexes(ctx context.Context, collection *mongo.Collection, names ...string) error {
+	for _, name := range names {
+		if _, err := collection.Indexes().DropOne(ctx, name); err != nil && !isIndexNotFound(err) {
+			return fmt.Errorf("failed to drop %s index %s: %w", collection.Name(), name, err)
+		}
+	}
+	return nil
+}
+
+func isIndexNotFound(err error) bool {
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) {
+		// IndexNotFound, or NamespaceNotFound when the collection is new.
+		return cmdErr.Code == 27 || cmdErr.Code == 26
+	}
+	return false
+}
+
+// migrateApplicationKeysUp copies the source and platform job ID of their
+// postings onto applications and keys applications on them. Applications
+// whose posting is gone keep their job ID as platform job ID, with no source.
+func migrateApplicationKeysUp(ctx context.Context, db *mongo.Database) error {
+	applications := db.Collection("applications")
+
+	cursor, err := applications.Find(ctx, bson.M{"platform_job_id": bson.M{"$exists": false}},
+		options.Find().SetProjection(bson.M{"owner_id": 1, "job_id": 1}))
+	if err != nil {
+		return fmt.Errorf("failed to query applications: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	orphaned := 0
+	for cursor.Next(ctx) {
+		var application struct {
+			OwnerID string `bson:"owner_id"`
+			JobID   string `bson:"job_id"`
+		}
+		if err := cursor.Decode(&application); err != nil {
+			return fmt.Errorf("failed to decode application: %w", err)
+		}
+
+		var job scraper.JobPosting
+		err := db.Collection("jobs").FindOne(ctx, bson.M{"id": application.JobID}).Decode(&job)
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			job = scraper.JobPosting{PlatformJobId: application.JobID}
+			orphaned++
+		case err != nil:
+			return fmt.Errorf("failed to query job %s: %w", application.JobID, err)
+		}
+
+		_, err = applications.UpdateOne(ctx,
+			bson.M{"owner_id": application.OwnerID, "job_id": application.JobID},
+			bson.M{"$set": bson.M{"source": job.Source, "platform_job_id": job.PlatformJobId}})
+		if err != nil {
+			return fmt.Errorf("failed to key application to job %s: %w", application.JobID, err)
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("failed to iterate applications: %w", err)
+	}
+	if orphaned > 0 {
+		log.Printf("Keyed %d applications whose job is gone by their job id", orphaned)
+	}
+
+	if _, err := applications.Indexes().DropOne(ctx, "owner_id_1_job_id_1"); err != nil && !isIndexNotFound(err) {
+		return fmt.Errorf("failed to drop applications job_id index: %w", err)
+	}
+	_, err = applications.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "owner_id", Value: 1}, {Key: "source", Value: 1}, {Key: "platform_job_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create applications platform_job_id index: %w", err)
+	}
+	return nil
+}
+
+// migrateApplicationKeysDown keys applications on their job ID again. The
+// source and platform job ID fields are left in place.
+func migrateApplicationKeysDown(ctx context.Context, db *mongo.Database) error {
+	applications := db.Collection("applications")
+	if _, err := applications.Indexes().DropOne(ctx, "owner_id_1_source_1_platform_job_id_1"); err != nil && !isIndexNotFound(err) {
+		return fmt.Errorf("failed to drop applications platform_job_id index: %w", err)
+	}
+	_, err := applications.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "owner_id", Value: 1}, {Key: "job_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to restore applications job_id index: %w", err)
+	}
+	return nil
+}
+
+// migrateSearchMatchKeysUp copies the source and platform job ID of their
+// postings onto search matches and keys matches on them. Matches whose
+// posting is gone can never be resolved again and are deleted.
+func migrateSearchMatchKeysUp(ctx context.Context, db *mongo.Database) error {
+	matches := db.Collection("search_matches")
+
+	cursor, err := matches.Find(ctx, bson.M{"platform_job_id": bson.M{"$exists": false}},
+		options.Find().SetProjection(bson.M{"search_id": 1, "job_id": 1}))
+	if err != nil {
+		return fmt.Errorf("failed to query search matches: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	orphaned := 0
+	for cursor.Next(ctx) {
+		var match struct {
+			SearchID string `bson:"search_id"`
+			JobID    string `bson:"job_id"`
+		}
+		if err := cursor.Decode(&match); err != nil {
+			return fmt.Errorf("failed to decode search match: %w", err)
+		}
+		key := bson.M{"search_id": match.SearchID, "job_id": match.JobID}
+
+		var job scraper.JobPosting
+		err := db.Collection("jobs").FindOne(ctx, bson.M{"id": match.JobID}).Decode(&job)
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			if _, err := matches.DeleteOne(ctx, key); err != nil {
+				return fmt.Errorf("failed to delete match of job %s: %w", match.JobID, err)
+			}
+			orphaned++
+			continue
+		case err != nil:
+			return fmt.Errorf("failed to query job %s: %w", match.JobID, err)
+		}
+
+		_, err = matches.UpdateOne(ctx, key,
+			bson.M{"$set": bson.M{"source": job.Source, "platform_job_id": job.PlatformJobId}})
+		if err != nil {
+			return fmt.Errorf("failed to key match to job %s: %w", match.JobID, err)
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("failed to iterate search matches: %w", err)
+	}
+	if orphaned > 0 {
+		log.Printf("Deleted %d search matches whose job is gone", orphaned)
+	}
+
+	if err := dropIndexes(ctx, matches, "search_id_1_job_id_1"); err != nil {
+		return err
+	}
+	_, err = matches.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "search_id", Value: 1}, {Key: "source", Value: 1}, {Key: "platform_job_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create search_matches platform_job_id index: %w", err)
+	}
+	return nil
+}
+
+// migrateSearchMatchKeysDown keys search matches on their job ID again. The
+// source and platform job ID fields are left in place.
+func migrateSearchMatchKeysDown(ctx context.Context, db *mongo.Database) error {
+	matches := db.Collection("search_matches")
+	if err := dropIndexes(ctx, matches, "search_id_1_source_1_platform_job_id_1"); err != nil {
+		return err
+	}
+	_, err := matches.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "search_id", Value: 1}, {Key: "job_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to restore search_matches job_id index: %w", err)
+	}
+	return nil
+}
+
+// migrateScrapeQuotasUp creates the daily page counters of scrape quotas,
+// starting from the pages of the runs started today. Counters expire a week
+// after their day.
+func migrateScrapeQuotasUp(ctx context.Context, db *mongo.Database) error {
+	quotas := db.Collection("scrape_quotas")
+	_, err := quotas.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "day", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "day", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(7 * 24 * 60 * 60)},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create scrape_quotas indexes: %w", err)
+	}
+
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+	cursor, err := db.Collection("scrape_runs").Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": bson.M{"$nin": bson.A{nil, ""}}, "started_at": bson.M{"$gte": day}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$user_id", "pages": bson.M{"$sum": "$pages"}}}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to count scraped pages: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var totals []struct {
+		UserID string `bson:"_id"`
+		Pages  int    `bson:"pages"`
+	}
+	if err := cursor.All(ctx, &totals); err != nil {
+		return fmt.Errorf("failed to decode scraped pages: %w", err)
+	}
+	for _, total := range totals {
+		_, err := quotas.UpdateOne(ctx,
+			bson.M{"user_id": total.UserID, "day": day},
+			bson.M{"$set": bson.M{"pages": total.Pages}},
+			options.Update().SetUpsert(true))
+		if err != nil {
+			return fmt.Errorf("failed to save scraped pages of user %s: %w", total.UserID, err)
+		}
+	}
+	return nil
+}
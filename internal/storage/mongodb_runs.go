@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ayagmar/gojobscraper/internal/scraper"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (m *MongoDBStorage) SaveRun(run scraper.ScrapeRun) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Replace().SetUpsert(true)
+	if _, err := m.runs.ReplaceOne(ctx, bson.M{"id": run.ID}, run, opts); err != nil {
+		return fmt.Errorf("failed to save scrape run: %w", err)
+	}
+	return nil
+}
+
+func (m *MongoDBStorage) GetRun(id string) (scraper.ScrapeRun, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var run scraper.ScrapeRun
+	err := m.runs.FindOne(ctx, bson.M{"id": id}).Decode(&run)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return scraper.ScrapeRun{}, ErrNotFound
+	}
+	if err != nil {
+		return scraper.ScrapeRun{}, fmt.Errorf("failed to query scrape run: %w", err)
+	}
+
+	return run, nil
+}
+
+func (m *MongoDBStorage) GetRuns() ([]scraper.ScrapeRun, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "started_at", Value: -1}})
+	cursor, err := m.runs.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query scrape runs: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var runs []scraper.ScrapeRun
+	if err = cursor.All(ctx, &runs); err != nil {
+		return nil, fmt.Errorf("failed to decode scrape runs: %w", err)
+	}
+
+	return runs, nil
+}
+
+// ReserveScrapePages adds pages to the pages a user scraped on day, unless
+// the total would exceed limit. The check and the update are a single
+// operation, so concurrent scrapes cannot exceed the limit together.
+func (m *MongoDBStorage) ReserveScrapePages(userID string, day time.Time, pages, limit int) (bool, error) {
+	if pages > limit {
+		return false, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// A counter without room is not matched, and the upsert then fails on
+	// the unique user_id and day index. The upsert also fails when another
+	// reservation created the counter first, so it is tried again once.
+	for attempt := 0; ; attempt++ {
+		_, err := m.scrapeQuotas.UpdateOne(ctx,
+			bson.M{"user_id": userID, "day": day, "pages": bson.M{"$lte": limit - pages}},
+			bson.M{"$inc": bson.M{"pages": pages}},
+			options.Update().SetUpsert(true))
+		if mongo.IsDuplicateKeyError(err) {
+			if attempt == 0 {
+				continue
+			}
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to reserve scrape pages: %w", err)
+		}
+		return true, nil
+	}
+}
+
+// ReleaseScrapePages gives back pages reserved by ReserveScrapePages.
+func (m *MongoDBStorage) ReleaseScrapePages(userID string, day time.Time, pages int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := m.scrapeQuotas.UpdateOne(ctx,
+		bson.M{"user_id": userID, "day": day},
+		bson.M{"$inc": bson.M{"pages": -pages}})
+	if err != nil {
+		return fmt.Errorf("failed to release scrape pages: %w", err)
+	}
+	return nil
+}
+
+// GetScrapedPages returns the pages a user reserved on day.
+func (m *MongoDBStorage) GetScrapedPages(userID string, day time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var counter struct {
+		Pages int `bson:"pages"`
+	}
+	err := m.scrapeQuotas.FindOne(ctx, bson.M{"user_id": userID, "day": day}).Decode(&counter)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to query scraped pages: %w", err)
+	}
+	return counter.Pages, nil
+}
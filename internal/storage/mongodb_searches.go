@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ayagmar/gojobscraper/internal/scraper"
+	"github.com/ayagmar/gojobscraper/internal/search"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (m *MongoDBStorage) SaveSearch(s search.SavedSearch) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Replace().SetUpsert(true)
+	if _, err := m.searches.ReplaceOne(ctx, bson.M{"id": s.ID}, s, opts); err != nil {
+		return fmt.Errorf("failed to save search: %w", err)
+	}
+	return nil
+}
+
+func (m *MongoDBStorage) GetSearches() ([]search.SavedSearch, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := m.searches.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query searches: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	searches := []search.SavedSearch{}
+	if err = cursor.All(ctx, &searches); err != nil {
+		return nil, fmt.Errorf("failed to decode searches: %w", err)
+	}
+
+	return searches, nil
+}
+
+func (m *MongoDBStorage) GetSearch(id string) (search.SavedSearch, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var s search.SavedSearch
+	err := m.searches.FindOne(ctx, bson.M{"id": id}).Decode(&s)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return search.SavedSearch{}, ErrNotFound
+	}
+	if err != nil {
+		return search.SavedSearch{}, fmt.Errorf("failed to query search: %w", err)
+	}
+
+	return s, nil
+}
+
+// DeleteSearch removes a saved search along with its matches.
+func (m *MongoDBStorage) DeleteSearch(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	result, err := m.searches.DeleteOne(ctx, bson.M{"id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete search: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+
+	if _, err := m.matches.DeleteMany(ctx, bson.M{"search_id": id}); err != nil {
+		return fmt.Errorf("failed to delete search matches: %w", err)
+	}
+	return nil
+}
+
+func (m *MongoDBStorage) SaveMatches(matches []search.Match) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	operations := make([]mongo.WriteModel, 0, len(matches))
+	for _, match := range matches {
+		operation := mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"search_id": match.SearchID, "source": match.Source, "platform_job_id": match.PlatformJobID}).
+			SetUpdate(bson.M{"$setOnInsert": match}).
+			SetUpsert(true)
+		operations = append(operations, operation)
+	}
+
+	opts := options.BulkWrite().SetOrdered(false)
+	if _, err := m.matches.BulkWrite(ctx, operations, opts); err != nil {
+		return fmt.Errorf("failed to save search matches: %w", err)
+	}
+	return nil
+}
+
+// GetSearchMatches returns the most recent matches of a saved search, newest
+// first, with their job postings attached. Matches of postings that no
+// longer exist are left out.
+func (m *MongoDBStorage) GetSearchMatches(searchID string, limit int) ([]search.Match, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "matched_at", Value: -1}}).SetLimit(int64(limit))
+	cursor, err := m.matches.Find(ctx, bson.M{"search_id": searchID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query search matches: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var matches []search.Match
+	if err = cursor.All(ctx, &matches); err != nil {
+		return nil, fmt.Errorf("failed to decode search matches: %w", err)
+	}
+
+	if len(matches) == 0 {
+		return matches, nil
+	}
+
+	keys := make(bson.A, len(matches))
+	for i, match := range matches {
+		keys[i] = bson.M{"source": match.Source, "platform_job_id": match.PlatformJobID}
+	}
+	jobCursor, err := m.collection.Find(ctx, bson.M{"$or": keys})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query matched jobs: %w", err)
+	}
+	defer jobCursor.Close(ctx)
+
+	var jobs []scraper.JobPosting
+	if err = jobCursor.All(ctx, &jobs); err != nil {
+		return nil, fmt.Errorf("failed to decode matched jobs: %w", err)
+	}
+	byKey := make(map[jobKey]scraper.JobPosting, len(jobs))
+	for _, job := range jobs {
+		byKey[jobKey{job.Source, job.PlatformJobId}] = job
+	}
+
+	result := make([]search.Match, 0, len(matches))
+	for _, match := range matches {
+		if job, ok := byKey[jobKey{match.Source, match.PlatformJobID}]; ok {
+			match.JobID = job.ID
+			match.Job = &job
+			result = append(result, match)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ayagmar/gojobscraper/internal/notify"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (m *MongoDBStorage) SaveSubscriber(subscriber notify.Subscriber) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Replace().SetUpsert(true)
+	if _, err := m.subscribers.ReplaceOne(ctx, bson.M{"id": subscriber.ID}, subscriber, opts); err != nil {
+		return fmt.Errorf("failed to save subscriber: %w", err)
+	}
+	return nil
+}
+
+func (m *MongoDBStorage) GetSubscribers() ([]notify.Subscriber, error) {
+	return m.findSubscribers(bson.M{})
+}
+
+func (m *MongoDBStorage) GetDueSubscribers(now time.Time) ([]notify.Subscriber, error) {
+	return m.findSubscribers(bson.M{"unsubscribed": false, "next_digest_at": bson.M{"$lte": now}})
+}
+
+func (m *MongoDBStorage) findSubscribers(query bson.M) ([]notify.Subscriber, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := m.subscribers.Find(ctx, query, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query subscribers: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	subscribers := []notify.Subscriber{}
+	if err = cursor.All(ctx, &subscribers); err != nil {
+		return nil, fmt.Errorf("failed to decode subscribers: %w", err)
+	}
+
+	return subscribers, nil
+}
+
+func (m *MongoDBStorage) GetSubscriber(id string) (notify.Subscriber, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var subscriber notify.Subscriber
+	err := m.subscribers.FindOne(ctx, bson.M{"id": id}).Decode(&subscriber)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return notify.Subscriber{}, ErrNotFound
+	}
+	if err != nil {
+		return notify.Subscriber{}, fmt.Errorf("failed to query subscriber: %w", err)
+	}
+
+	return subscriber, nil
+}
+
+func (m *MongoDBStorage) DeleteSubscriber(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := m.subscribers.DeleteOne(ctx, bson.M{"id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete subscriber: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (m *MongoDBStorage) Unsubscribe(token string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := m.subscribers.UpdateOne(ctx,
+		bson.M{"unsubscribe_token": token},
+		bson.M{"$set": bson.M{"unsubscribed": true}})
+	if err != nil {
+		return fmt.Errorf("failed to unsubscribe: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ayagmar/gojobscraper/internal/auth"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (m *MongoDBStorage) SaveUser(user auth.User) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Replace().SetUpsert(true)
+	if _, err := m.users.ReplaceOne(ctx, bson.M{"id": user.ID}, user, opts); err != nil {
+		return fmt.Errorf("failed to save user: %w", err)
+	}
+	return nil
+}
+
+func (m *MongoDBStorage) GetUser(id string) (auth.User, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var user auth.User
+	err := m.users.FindOne(ctx, bson.M{"id": id}).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return auth.User{}, ErrNotFound
+	}
+	if err != nil {
+		return auth.User{}, fmt.Errorf("failed to query user: %w", err)
+	}
+
+	return user, nil
+}
+
+func (m *MongoDBStorage) GetUsers() ([]auth.User, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := m.users.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	users := []auth.User{}
+	if err = cursor.All(ctx, &users); err != nil {
+		return nil, fmt.Errorf("failed to decode users: %w", err)
+	}
+
+	return users, nil
+}
+
+func (m *MongoDBStorage) CountUsers() (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	count, err := m.users.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count users: %w", err)
+	}
+	return int(count), nil
+}
+
+// DeleteUser removes a user, revokes its API keys and deletes its
+// subscribers and saved searches, whose feed tokens go with them. Its
+// applications are kept.
+func (m *MongoDBStorage) DeleteUser(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	result, err := m.users.DeleteOne(ctx, bson.M{"id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+
+	_, err = m.apiKeys.UpdateMany(ctx,
+		bson.M{"user_id": id, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now()}})
+	if err != nil {
+		return fmt.Errorf("failed to revoke API keys of user: %w", err)
+	}
+
+	if _, err := m.subscribers.DeleteMany(ctx, bson.M{"owner_id": id}); err != nil {
+		return fmt.Errorf("failed to delete subscribers of user: %w", err)
+	}
+
+	cursor, err := m.searches.Find(ctx, bson.M{"owner_id": id}, options.Find().SetProjection(bson.M{"id": 1}))
+	if err != nil {
+		return fmt.Errorf("failed to query searches of user: %w", err)
+	}
+	var searches []struct {
+		ID string `bson:"id"`
+	}
+	if err := cursor.All(ctx, &searches); err != nil {
+		return fmt.Errorf("failed to decode searches of user: %w", err)
+	}
+	var searchIDs []string
+	for _, s := range searches {
+		searchIDs = append(searchIDs, s.ID)
+	}
+	if len(searchIDs) == 0 {
+		return nil
+	}
+
+	if _, err := m.searches.DeleteMany(ctx, bson.M{"id": bson.M{"$in": searchIDs}}); err != nil {
+		return fmt.Errorf("failed to delete searches of user: %w", err)
+	}
+	if _, err := m.matches.DeleteMany(ctx, bson.M{"search_id": bson.M{"$in": searchIDs}}); err != nil {
+		return fmt.Errorf("failed to delete search matches of user: %w", err)
+	}
+	return nil
+}
+
+func (m *MongoDBStorage) SaveAPIKey(key auth.APIKey) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Replace().SetUpsert(true)
+	if _, err := m.apiKeys.ReplaceOne(ctx, bson.M{"id": key.ID}, key, opts); err != nil {
+		return fmt.Errorf("failed to save API key: %w", err)
+	}
+	return nil
+}
+
+func (m *MongoDBStorage) GetAPIKey(id string) (auth.APIKey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var key auth.APIKey
+	err := m.apiKeys.FindOne(ctx, bson.M{"id": id}).Decode(&key)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return auth.APIKey{}, ErrNotFound
+	}
+	if err != nil {
+		return auth.APIKey{}, fmt.Errorf("failed to query API key: %w", err)
+	}
+
+	return key, nil
+}
+
+func (m *MongoDBStorage) GetAPIKeys(userID string) ([]auth.APIKey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := m.apiKeys.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API keys: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	keys := []auth.APIKey{}
+	if err = cursor.All(ctx, &keys); err != nil {
+		return nil, fmt.Errorf("failed to decode API keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (m *MongoDBStorage) LookupAPIKey(hash string) (auth.APIKey, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var key auth.APIKey
+	err := m.apiKeys.FindOne(ctx, bson.M{"hash": hash}).Decode(&key)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return auth.APIKey{}, false, nil
+	}
+	if err != nil {
+		return auth.APIKey{}, false, fmt.Errorf("failed to query API key: %w", err)
+	}
+
+	return key, true, nil
+}
+
+func (m *MongoDBStorage) TouchAPIKey(id string, at time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := m.apiKeys.UpdateOne(ctx, bson.M{"id": id}, bson.M{"$set": bson.M{"last_used_at": at}}); err != nil {
+		return fmt.Errorf("failed to record API key use: %w", err)
+	}
+	return nil
+}
+
+// ClaimUnowned gives the saved searches, subscribers and applications
+// created before users existed to the given user.
+func (m *MongoDBStorage) ClaimUnowned(ownerID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	unowned := bson.M{"$or": bson.A{
+		bson.M{"owner_id": bson.M{"$exists": false}},
+		bson.M{"owner_id": ""},
+	}}
+	claim := bson.M{"$set": bson.M{"owner_id": ownerID}}
+	for _, collection := range []*mongo.Collection{m.searches, m.subscribers, m.applications} {
+		result, err := collection.UpdateMany(ctx, unowned, claim)
+		if err != nil {
+			return fmt.Errorf("failed to claim unowned %s: %w", collection.Name(), err)
+		}
+		if result.ModifiedCount > 0 {
+			log.Printf("Assigned %d unowned %s to user %s", result.ModifiedCount, collection.Name(), ownerID)
+		}
+	}
+	return nil
+}
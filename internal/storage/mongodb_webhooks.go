@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ayagmar/gojobscraper/internal/webhook"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxDeliveries is the number of most recent deliveries returned per webhook.
+const maxDeliveries = 100
+
+func (m *MongoDBStorage) SaveWebhook(hook webhook.Webhook) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Replace().SetUpsert(true)
+	if _, err := m.webhooks.ReplaceOne(ctx, bson.M{"id": hook.ID}, hook, opts); err != nil {
+		return fmt.Errorf("failed to save webhook: %w", err)
+	}
+	return nil
+}
+
+func (m *MongoDBStorage) GetWebhooks() ([]webhook.Webhook, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := m.webhooks.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhooks: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var hooks []webhook.Webhook
+	if err = cursor.All(ctx, &hooks); err != nil {
+		return nil, fmt.Errorf("failed to decode webhooks: %w", err)
+	}
+
+	return hooks, nil
+}
+
+func (m *MongoDBStorage) GetWebhook(id string) (webhook.Webhook, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var hook webhook.Webhook
+	err := m.webhooks.FindOne(ctx, bson.M{"id": id}).Decode(&hook)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return webhook.Webhook{}, ErrNotFound
+	}
+	if err != nil {
+		return webhook.Webhook{}, fmt.Errorf("failed to query webhook: %w", err)
+	}
+
+	return hook, nil
+}
+
+// DeleteWebhook removes a webhook. Its delivery log is kept.
+func (m *MongoDBStorage) DeleteWebhook(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := m.webhooks.DeleteOne(ctx, bson.M{"id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (m *MongoDBStorage) SaveDelivery(delivery webhook.Delivery) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Replace().SetUpsert(true)
+	if _, err := m.deliveries.ReplaceOne(ctx, bson.M{"id": delivery.ID}, delivery, opts); err != nil {
+		return fmt.Errorf("failed to save webhook delivery: %w", err)
+	}
+	return nil
+}
+
+func (m *MongoDBStorage) GetDelivery(id string) (webhook.Delivery, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var delivery webhook.Delivery
+	err := m.deliveries.FindOne(ctx, bson.M{"id": id}).Decode(&delivery)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return webhook.Delivery{}, ErrNotFound
+	}
+	if err != nil {
+		return webhook.Delivery{}, fmt.Errorf("failed to query webhook delivery: %w", err)
+	}
+
+	return delivery, nil
+}
+
+// GetDeliveries returns the most recent deliveries of a webhook, newest first.
+func (m *MongoDBStorage) GetDeliveries(webhookID string) ([]webhook.Delivery, error) {
+	return m.findDeliveries(bson.M{"webhook_id": webhookID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(maxDeliveries))
+}
+
+func (m *MongoDBStorage) GetPendingDeliveries() ([]webhook.Delivery, error) {
+	return m.findDeliveries(bson.M{"status": webhook.DeliveryPending},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+}
+
+func (m *MongoDBStorage) findDeliveries(query bson.M, opts *options.FindOptions) ([]webhook.Delivery, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	cursor, err := m.deliveries.Find(ctx, query, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook deliveries: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	deliveries := []webhook.Delivery{}
+	if err = cursor.All(ctx, &deliveries); err != nil {
+		return nil, fmt.Errorf("failed to decode webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
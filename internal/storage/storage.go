@@ -1,12 +1,182 @@
 package storage
 
 import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ayagmar/gojobscraper/internal/auth"
+	"github.com/ayagmar/gojobscraper/internal/blocklist"
+	"github.com/ayagmar/gojobscraper/internal/filter"
+	"github.com/ayagmar/gojobscraper/internal/notify"
 	"github.com/ayagmar/gojobscraper/internal/scraper"
+	"github.com/ayagmar/gojobscraper/internal/search"
+	"github.com/ayagmar/gojobscraper/internal/tracking"
+	"github.com/ayagmar/gojobscraper/internal/webhook"
+)
+
+var (
+	// ErrNotFound is returned when a requested record does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrSchemaOutdated is returned when a database has pending migrations.
+	ErrSchemaOutdated = errors.New("database schema is out of date, run `migrate up`")
 )
 
+// Storage is the full set of persistence operations used by the API.
+type Storage interface {
+	JobStorage
+	CompanyStorage
+	RunStorage
+	WebhookStorage
+	SubscriberStorage
+	AlertStorage
+	SearchStorage
+	BlocklistStorage
+	ApplicationStorage
+	UserStorage
+}
+
 type JobStorage interface {
-	SaveJobs(jobs []scraper.JobPosting) error
+	// SaveJobs upserts jobs and returns the postings that were inserted
+	// rather than updated, including those inserted before an error.
+	SaveJobs(jobs []scraper.JobPosting) ([]scraper.JobPosting, error)
 	GetJobs() ([]scraper.JobPosting, error)
+	GetJob(id string) (scraper.JobPosting, error)
+	// EachJob calls fn with every job matching jobFilter, newest first,
+	// without loading them all at once. Hidden jobs are left out unless
+	// includeHidden is set.
+	EachJob(ctx context.Context, jobFilter filter.JobFilter, includeHidden bool, fn func(job scraper.JobPosting) error) error
+	// GetJobsSince returns the first limit jobs matching jobFilter first
+	// saved after since, oldest first, and the number of those jobs. Hidden
+	// jobs are left out.
+	GetJobsSince(since time.Time, jobFilter filter.JobFilter, limit int) ([]scraper.JobPosting, int, error)
 	ClearJobs() error
 	Close() error
 }
+
+// CompanyStorage persists the companies referenced by job postings. Companies
+// are created and enriched as a side effect of JobStorage.SaveJobs.
+type CompanyStorage interface {
+	GetCompanies() ([]scraper.Company, error)
+	GetCompany(id string) (scraper.Company, error)
+	// GetJobsByCompany returns the jobs of a company. Hidden jobs are left
+	// out unless includeHidden is set.
+	GetJobsByCompany(companyID string, includeHidden bool) ([]scraper.JobPosting, error)
+	LookupCompany(platformCompanyURL string) (scraper.Company, bool, error)
+}
+
+// RunStorage persists scrape runs.
+type RunStorage interface {
+	// SaveRun inserts or replaces a run by ID.
+	SaveRun(run scraper.ScrapeRun) error
+	GetRun(id string) (scraper.ScrapeRun, error)
+	GetRuns() ([]scraper.ScrapeRun, error)
+	// ReserveScrapePages atomically adds pages to the pages a user scraped
+	// on day and returns true, or returns false if the total would exceed
+	// limit.
+	ReserveScrapePages(userID string, day time.Time, pages, limit int) (bool, error)
+	// ReleaseScrapePages gives back pages reserved for a scrape that did
+	// not start.
+	ReleaseScrapePages(userID string, day time.Time, pages int) error
+	// GetScrapedPages returns the pages a user reserved on day.
+	GetScrapedPages(userID string, day time.Time) (int, error)
+}
+
+// WebhookStorage persists webhooks and their delivery log.
+type WebhookStorage interface {
+	webhook.Store
+	SaveWebhook(hook webhook.Webhook) error
+	DeleteWebhook(id string) error
+	GetDeliveries(webhookID string) ([]webhook.Delivery, error)
+}
+
+// SubscriberStorage persists the subscribers of email digests.
+type SubscriberStorage interface {
+	notify.SubscriberStore
+	GetSubscribers() ([]notify.Subscriber, error)
+	GetSubscriber(id string) (notify.Subscriber, error)
+	DeleteSubscriber(id string) error
+	// Unsubscribe stops the digests of the subscriber with the given
+	// unsubscribe token.
+	Unsubscribe(token string) error
+}
+
+// AlertStorage persists the rules of chat alerts.
+type AlertStorage interface {
+	notify.AlertStore
+	SaveAlertRule(rule notify.AlertRule) error
+	GetAlertRule(id string) (notify.AlertRule, error)
+	DeleteAlertRule(id string) error
+}
+
+// SearchStorage persists saved searches and the postings they matched.
+// Matches are keyed by search, source and platform job ID so that they
+// survive postings being cleared and scraped again under a new ID.
+type SearchStorage interface {
+	search.Store
+	SaveSearch(s search.SavedSearch) error
+	GetSearch(id string) (search.SavedSearch, error)
+	// DeleteSearch removes a saved search along with its matches.
+	DeleteSearch(id string) error
+	// GetSearchMatches returns up to limit of the most recent matches of a
+	// saved search, newest first, with their postings attached.
+	GetSearchMatches(searchID string, limit int) ([]search.Match, error)
+}
+
+// BlocklistStorage persists the blocklist rules applied to scraped postings.
+type BlocklistStorage interface {
+	blocklist.Store
+	SaveBlockRule(rule blocklist.Rule) error
+	DeleteBlockRule(id string) error
+}
+
+// ApplicationStorage persists the applications tracked for job postings,
+// keyed by owner, source and platform job ID so that they survive postings
+// being cleared and scraped again under a new ID.
+type ApplicationStorage interface {
+	// SaveApplication inserts or replaces the application of its owner to
+	// its job.
+	SaveApplication(application tracking.Application) error
+	GetApplication(ownerID string, source scraper.ScraperType, platformJobID string) (tracking.Application, error)
+	DeleteApplication(ownerID string, source scraper.ScraperType, platformJobID string) error
+	// GetApplications returns the applications matching query, most
+	// recently updated first, with their postings attached.
+	GetApplications(query tracking.Query) ([]tracking.Application, error)
+}
+
+// UserStorage persists API users and their keys.
+type UserStorage interface {
+	auth.Store
+	SaveUser(user auth.User) error
+	GetUsers() ([]auth.User, error)
+	CountUsers() (int, error)
+	// DeleteUser removes a user, revokes its API keys and deletes its
+	// subscribers and saved searches.
+	DeleteUser(id string) error
+	// SaveAPIKey inserts or replaces a key by ID.
+	SaveAPIKey(key auth.APIKey) error
+	GetAPIKey(id string) (auth.APIKey, error)
+	GetAPIKeys(userID string) ([]auth.APIKey, error)
+	// ClaimUnowned gives the saved searches, subscribers and applications
+	// created before users existed to the given user.
+	ClaimUnowned(ownerID string) error
+}
+
+// MigrationStatus describes a schema migration and whether it has been applied.
+type MigrationStatus struct {
+	Version     int
+	Description string
+	AppliedAt   *time.Time
+}
+
+// Migrator applies and reverts the ordered schema migrations of a backend.
+type Migrator interface {
+	// Up applies pending migrations up to and including target, or all of
+	// them when target is 0. It returns the versions that were applied.
+	Up(ctx context.Context, target int) ([]int, error)
+	// Down reverts the given number of most recently applied migrations and
+	// returns the versions that were reverted.
+	Down(ctx context.Context, steps int) ([]int, error)
+	Status(ctx context.Context) ([]MigrationStatus, error)
+	Close() error
+}
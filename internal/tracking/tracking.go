@@ -0,0 +1,98 @@
+// Package tracking follows the applications made to job postings. Tracking
+// data is kept apart from scraped postings so re-scrapes leave it intact.
+package tracking
+
+import (
+	"strings"
+	"time"
+
+	"github.com/ayagmar/gojobscraper/internal/scraper"
+)
+
+// Status is the pipeline stage of an application
+// @Description Application pipeline status
+type Status string
+
+const (
+	StatusSaved        Status = "saved"
+	StatusApplied      Status = "applied"
+	StatusInterviewing Status = "interviewing"
+	StatusOffer        Status = "offer"
+	StatusRejected     Status = "rejected"
+)
+
+// ValidStatus reports whether s is a known status.
+func ValidStatus(s Status) bool {
+	switch s {
+	case StatusSaved, StatusApplied, StatusInterviewing, StatusOffer, StatusRejected:
+		return true
+	}
+	return false
+}
+
+// StatusChange records when an application entered a status
+// @Description Application status change
+type StatusChange struct {
+	Status Status    `json:"status" bson:"status"`
+	At     time.Time `json:"at" bson:"at"`
+}
+
+// Application tracks the application made to a job posting
+// @Description Application to a job posting
+type Application struct {
+	// Source and PlatformJobID identify the posting applied to across
+	// re-scrapes.
+	Source        scraper.ScraperType `json:"source" bson:"source"`
+	PlatformJobID string              `json:"platform_job_id" bson:"platform_job_id"`
+	// JobID is the ID of the posting when the application was last read or
+	// saved. A posting scraped again after being cleared gets a new ID.
+	JobID  string   `json:"job_id" bson:"job_id"`
+	Status Status   `json:"status" bson:"status"`
+	Notes  string   `json:"notes,omitempty" bson:"notes,omitempty"`
+	Tags   []string `json:"tags" bson:"tags"`
+	// RemindAt is when to follow up on the application.
+	RemindAt *time.Time `json:"remind_at,omitempty" bson:"remind_at,omitempty"`
+	// History lists the statuses of the application, oldest first.
+	History   []StatusChange `json:"history" bson:"history"`
+	OwnerID   string         `json:"owner_id" bson:"owner_id"`
+	CreatedAt time.Time      `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at" bson:"updated_at"`
+	// Job is the posting applied to, attached when applications are listed.
+	Job *scraper.JobPosting `json:"job,omitempty" bson:"-"`
+}
+
+// SetStatus moves the application to status at the given time, recording
+// the change when the status differs from the current one.
+func (a *Application) SetStatus(status Status, at time.Time) {
+	if a.Status == status && len(a.History) > 0 {
+		return
+	}
+	a.Status = status
+	a.History = append(a.History, StatusChange{Status: status, At: at})
+}
+
+// Query selects applications. Empty fields match every application.
+type Query struct {
+	Status Status
+	Tag    string
+	// OwnerID selects the applications tracked by one user.
+	OwnerID string
+	// RemindBefore selects applications with a reminder due before it.
+	RemindBefore *time.Time
+}
+
+// NormalizeTags trims tags and drops empty and repeated ones, ignoring case.
+func NormalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
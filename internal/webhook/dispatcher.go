@@ -0,0 +1,312 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ayagmar/gojobscraper/internal/scraper"
+	"github.com/google/uuid"
+)
+
+// maxResponseBody is how much of a receiver's response is kept in the
+// delivery log.
+const maxResponseBody = 1024
+
+// Store persists webhooks and their deliveries.
+type Store interface {
+	GetWebhooks() ([]Webhook, error)
+	GetWebhook(id string) (Webhook, error)
+	SaveDelivery(delivery Delivery) error
+	GetDelivery(id string) (Delivery, error)
+	GetPendingDeliveries() ([]Delivery, error)
+}
+
+// Config configures how deliveries are sent and retried.
+type Config struct {
+	// MaxAttempts is the total number of attempts per delivery.
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Timeout bounds each attempt.
+	Timeout time.Duration
+	// Workers is the number of deliveries sent in parallel.
+	Workers int
+}
+
+// Dispatcher records deliveries for the webhooks subscribed to an event and
+// sends them in the background, retrying failed attempts with exponential
+// backoff.
+type Dispatcher struct {
+	store  Store
+	config Config
+	client *http.Client
+	queue  chan Delivery
+	logger *log.Logger
+	ctx    context.Context
+}
+
+// NewDispatcher creates a dispatcher. Deliveries are only sent once Start has
+// been called.
+func NewDispatcher(store Store, config Config, logger *log.Logger) *Dispatcher {
+	if config.MaxAttempts < 1 {
+		config.MaxAttempts = 1
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if config.Workers < 1 {
+		config.Workers = 1
+	}
+
+	return &Dispatcher{
+		store:  store,
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+		queue:  make(chan Delivery, 1000),
+		logger: logger,
+		ctx:    context.Background(),
+	}
+}
+
+// Start launches the delivery workers and resumes the deliveries left pending
+// by a previous process. Workers stop when ctx is done.
+func (d *Dispatcher) Start(ctx context.Context) {
+	d.ctx = ctx
+	for i := 0; i < d.config.Workers; i++ {
+		go d.work()
+	}
+
+	pending, err := d.store.GetPendingDeliveries()
+	if err != nil {
+		d.logger.Printf("Error loading pending webhook deliveries: %v", err)
+		return
+	}
+	for _, delivery := range pending {
+		d.schedule(delivery)
+	}
+	if len(pending) > 0 {
+		d.logger.Printf("Resumed %d pending webhook deliveries", len(pending))
+	}
+}
+
+// RunFinished notifies the webhooks subscribed to the outcome of run.
+func (d *Dispatcher) RunFinished(run scraper.ScrapeRun) {
+	event := EventRunFinished
+	if run.Status != scraper.RunSucceeded {
+		event = EventRunFailed
+	}
+
+	webhooks, err := d.store.GetWebhooks()
+	if err != nil {
+		d.logger.Printf("Error loading webhooks for %s: %v", event, err)
+		return
+	}
+	for _, webhook := range webhooks {
+		if webhook.Subscribed(event) {
+			d.dispatch(webhook, event, run)
+		}
+	}
+}
+
+// JobsSaved notifies the webhooks subscribed to new jobs of the newly
+// inserted jobs matching their filter.
+func (d *Dispatcher) JobsSaved(jobs []scraper.JobPosting) {
+	if len(jobs) == 0 {
+		return
+	}
+
+	webhooks, err := d.store.GetWebhooks()
+	if err != nil {
+		d.logger.Printf("Error loading webhooks for %s: %v", EventJobsNew, err)
+		return
+	}
+	for _, webhook := range webhooks {
+		if !webhook.Subscribed(EventJobsNew) {
+			continue
+		}
+
+		var matching []scraper.JobPosting
+		for _, job := range jobs {
+			if webhook.Filter.Match(job) {
+				matching = append(matching, job)
+			}
+		}
+		if len(matching) > 0 {
+			d.dispatch(webhook, EventJobsNew, JobsData{Jobs: matching})
+		}
+	}
+}
+
+// Redeliver sends the payload of a past delivery again as a new delivery.
+func (d *Dispatcher) Redeliver(deliveryID string) (Delivery, error) {
+	original, err := d.store.GetDelivery(deliveryID)
+	if err != nil {
+		return Delivery{}, err
+	}
+
+	delivery := newDelivery(original.WebhookID, original.Event, original.Payload)
+	delivery.RedeliveryOf = original.ID
+	if err := d.store.SaveDelivery(delivery); err != nil {
+		return Delivery{}, fmt.Errorf("failed to save delivery: %w", err)
+	}
+
+	d.schedule(delivery)
+	return delivery, nil
+}
+
+func (d *Dispatcher) dispatch(webhook Webhook, event EventType, data interface{}) {
+	id := uuid.New().String()
+	payload, err := json.Marshal(Payload{ID: id, Event: event, CreatedAt: time.Now(), Data: data})
+	if err != nil {
+		d.logger.Printf("Error encoding %s payload for webhook %s: %v", event, webhook.ID, err)
+		return
+	}
+
+	delivery := newDelivery(webhook.ID, event, payload)
+	delivery.ID = id
+	if err := d.store.SaveDelivery(delivery); err != nil {
+		d.logger.Printf("Error saving %s delivery for webhook %s: %v", event, webhook.ID, err)
+		return
+	}
+
+	d.schedule(delivery)
+}
+
+func newDelivery(webhookID string, event EventType, payload json.RawMessage) Delivery {
+	return Delivery{
+		ID:        uuid.New().String(),
+		WebhookID: webhookID,
+		Event:     event,
+		Payload:   payload,
+		Status:    DeliveryPending,
+		Attempts:  []Attempt{},
+		CreatedAt: time.Now(),
+	}
+}
+
+// schedule queues delivery once its next attempt is due.
+func (d *Dispatcher) schedule(delivery Delivery) {
+	var wait time.Duration
+	if delivery.NextAttemptAt != nil {
+		wait = time.Until(*delivery.NextAttemptAt)
+	}
+	if wait <= 0 {
+		d.enqueue(delivery)
+		return
+	}
+	time.AfterFunc(wait, func() { d.enqueue(delivery) })
+}
+
+func (d *Dispatcher) enqueue(delivery Delivery) {
+	if d.ctx.Err() != nil {
+		return
+	}
+	select {
+	case d.queue <- delivery:
+	default:
+		// The delivery stays pending in the store and is resumed on restart.
+		d.logger.Printf("Webhook delivery queue full, leaving delivery %s pending", delivery.ID)
+	}
+}
+
+func (d *Dispatcher) work() {
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case delivery := <-d.queue:
+			d.deliver(delivery)
+		}
+	}
+}
+
+// deliver makes one attempt of delivery and records its outcome.
+func (d *Dispatcher) deliver(delivery Delivery) {
+	webhook, err := d.store.GetWebhook(delivery.WebhookID)
+	if err != nil {
+		d.logger.Printf("Dropping webhook delivery %s: %v", delivery.ID, err)
+		delivery.Status = DeliveryFailed
+		delivery.NextAttemptAt = nil
+		delivery.Attempts = append(delivery.Attempts, Attempt{At: time.Now(), Error: err.Error()})
+		d.save(delivery)
+		return
+	}
+
+	attempt := d.attempt(webhook, delivery)
+	delivery.Attempts = append(delivery.Attempts, attempt)
+
+	switch {
+	case attempt.Error == "":
+		delivery.Status = DeliverySucceeded
+		delivery.NextAttemptAt = nil
+		delivery.DeliveredAt = &attempt.At
+	case len(delivery.Attempts) >= d.config.MaxAttempts:
+		d.logger.Printf("Webhook delivery %s to %s failed after %d attempts: %s", delivery.ID, webhook.URL, len(delivery.Attempts), attempt.Error)
+		delivery.Status = DeliveryFailed
+		delivery.NextAttemptAt = nil
+	default:
+		next := time.Now().Add(d.backoff(len(delivery.Attempts)))
+		delivery.NextAttemptAt = &next
+	}
+
+	d.save(delivery)
+	if delivery.Status == DeliveryPending {
+		d.schedule(delivery)
+	}
+}
+
+func (d *Dispatcher) save(delivery Delivery) {
+	if err := d.store.SaveDelivery(delivery); err != nil {
+		d.logger.Printf("Error saving webhook delivery %s: %v", delivery.ID, err)
+	}
+}
+
+// attempt POSTs the signed payload of delivery to webhook. Any response other
+// than a 2xx is a failed attempt.
+func (d *Dispatcher) attempt(webhook Webhook, delivery Delivery) Attempt {
+	start := time.Now()
+	attempt := Attempt{At: start}
+
+	req, err := http.NewRequestWithContext(d.ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+
+	timestamp := start.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gojobscraper-webhooks")
+	req.Header.Set("X-Webhook-ID", webhook.ID)
+	req.Header.Set("X-Webhook-Delivery", delivery.ID)
+	req.Header.Set("X-Webhook-Event", string(delivery.Event))
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Webhook-Signature", Sign(webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	attempt.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	attempt.StatusCode = resp.StatusCode
+	attempt.ResponseBody = string(body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		attempt.Error = fmt.Sprintf("receiver responded with HTTP %d", resp.StatusCode)
+	}
+	return attempt
+}
+
+// backoff returns the delay before the given retry.
+func (d *Dispatcher) backoff(retry int) time.Duration {
+	return scraper.Backoff(d.config.InitialBackoff, d.config.MaxBackoff, retry)
+}
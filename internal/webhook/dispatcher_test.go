@@ -0,0 +1,291 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memStore keeps webhooks and deliveries in memory and reports every saved
+// delivery on saved.
+type memStore struct {
+	mu         sync.Mutex
+	webhooks   map[string]Webhook
+	deliveries map[string]Delivery
+	saved      chan Delivery
+}
+
+func newMemStore(webhooks ...Webhook) *memStore {
+	s := &memStore{
+		webhooks:   make(map[string]Webhook),
+		deliveries: make(map[string]Delivery),
+		saved:      make(chan Delivery, 100),
+	}
+	for _, webhook := range webhooks {
+		s.webhooks[webhook.ID] = webhook
+	}
+	return s
+}
+
+func (s *memStore) GetWebhooks() ([]Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var webhooks []Webhook
+	for _, webhook := range s.webhooks {
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, nil
+}
+
+func (s *memStore) GetWebhook(id string) (Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	webhook, ok := s.webhooks[id]
+	if !ok {
+		return Webhook{}, errors.New("webhook not found")
+	}
+	return webhook, nil
+}
+
+func (s *memStore) SaveDelivery(delivery Delivery) error {
+	s.mu.Lock()
+	delivery.Attempts = append([]Attempt(nil), delivery.Attempts...)
+	s.deliveries[delivery.ID] = delivery
+	s.mu.Unlock()
+	s.saved <- delivery
+	return nil
+}
+
+func (s *memStore) GetDelivery(id string) (Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delivery, ok := s.deliveries[id]
+	if !ok {
+		return Delivery{}, errors.New("delivery not found")
+	}
+	return delivery, nil
+}
+
+func (s *memStore) GetPendingDeliveries() ([]Delivery, error) {
+	return nil, nil
+}
+
+// waitDone returns the first saved delivery that is no longer pending.
+func (s *memStore) waitDone(t *testing.T) Delivery {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case delivery := <-s.saved:
+			if delivery.Status != DeliveryPending {
+				return delivery
+			}
+		case <-timeout:
+			t.Fatal("delivery not finished")
+		}
+	}
+}
+
+// receiver records the requests sent to it and answers them with status.
+type receiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	status   int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func newReceiver(t *testing.T, status int) *receiver {
+	rec := &receiver{status: status}
+	rec.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rec.mu.Lock()
+		rec.requests = append(rec.requests, r)
+		rec.bodies = append(rec.bodies, body)
+		status := rec.status
+		rec.mu.Unlock()
+		w.WriteHeader(status)
+		io.WriteString(w, http.StatusText(status))
+	}))
+	t.Cleanup(rec.Close)
+	return rec
+}
+
+func startDispatcher(t *testing.T, store Store, config Config) *Dispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	d := NewDispatcher(store, config, log.New(io.Discard, "", 0))
+	d.Start(ctx)
+	return d
+}
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"event":"run.finished"}`)
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("1700000000." + string(body)))
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	if got := Sign("secret", 1700000000, body); got != want {
+		t.Errorf("Sign = %s, want %s", got, want)
+	}
+	if Sign("other", 1700000000, body) == want {
+		t.Error("signature does not depend on the secret")
+	}
+	if Sign("secret", 1700000001, body) == want {
+		t.Error("signature does not depend on the timestamp")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	d := NewDispatcher(newMemStore(), Config{InitialBackoff: time.Second, MaxBackoff: 8 * time.Second}, log.New(io.Discard, "", 0))
+	if got := d.backoff(3); got < 2*time.Second || got > 4*time.Second {
+		t.Errorf("backoff(3) = %s, want between 2s and 4s", got)
+	}
+	if got := d.backoff(6); got > 8*time.Second {
+		t.Errorf("backoff(6) = %s, want at most MaxBackoff", got)
+	}
+
+	if got := NewDispatcher(newMemStore(), Config{}, log.New(io.Discard, "", 0)).backoff(3); got != 0 {
+		t.Errorf("backoff without InitialBackoff = %s, want 0", got)
+	}
+}
+
+func TestDeliverySigned(t *testing.T) {
+	rec := newReceiver(t, http.StatusNoContent)
+	webhook := Webhook{ID: "wh", URL: rec.URL, Secret: "s3cret", Events: []EventType{EventRunFinished}}
+	store := newMemStore(webhook)
+	d := startDispatcher(t, store, Config{MaxAttempts: 3})
+
+	d.dispatch(webhook, EventRunFinished, map[string]string{"run": "1"})
+	delivery := store.waitDone(t)
+
+	if delivery.Status != DeliverySucceeded || delivery.DeliveredAt == nil || len(delivery.Attempts) != 1 {
+		t.Fatalf("delivery %+v, want succeeded after one attempt", delivery)
+	}
+	if code := delivery.Attempts[0].StatusCode; code != http.StatusNoContent {
+		t.Errorf("attempt status code %d", code)
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	req, body := rec.requests[0], rec.bodies[0]
+	if req.Header.Get("X-Webhook-Delivery") != delivery.ID || req.Header.Get("X-Webhook-Event") != string(EventRunFinished) {
+		t.Errorf("delivery headers %v", req.Header)
+	}
+	timestamp, err := strconv.ParseInt(req.Header.Get("X-Webhook-Timestamp"), 10, 64)
+	if err != nil {
+		t.Fatalf("X-Webhook-Timestamp: %v", err)
+	}
+	if got, want := req.Header.Get("X-Webhook-Signature"), Sign(webhook.Secret, timestamp, body); got != want {
+		t.Errorf("X-Webhook-Signature = %s, want %s", got, want)
+	}
+
+	var payload Payload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.ID != delivery.ID || payload.Event != EventRunFinished {
+		t.Errorf("payload %+v", payload)
+	}
+}
+
+func TestDeliveryFailsAfterMaxAttempts(t *testing.T) {
+	rec := newReceiver(t, http.StatusInternalServerError)
+	webhook := Webhook{ID: "wh", URL: rec.URL, Events: []EventType{EventRunFinished}}
+	store := newMemStore(webhook)
+	d := startDispatcher(t, store, Config{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 4 * time.Millisecond})
+
+	d.dispatch(webhook, EventRunFinished, nil)
+	delivery := store.waitDone(t)
+
+	if delivery.Status != DeliveryFailed || delivery.NextAttemptAt != nil || delivery.DeliveredAt != nil {
+		t.Errorf("delivery %+v, want failed with no next attempt", delivery)
+	}
+	if len(delivery.Attempts) != 3 {
+		t.Fatalf("%d attempts, want 3", len(delivery.Attempts))
+	}
+	for i, attempt := range delivery.Attempts {
+		if attempt.StatusCode != http.StatusInternalServerError || attempt.Error != "receiver responded with HTTP 500" {
+			t.Errorf("attempt %d: %+v, want a failed HTTP 500 attempt", i, attempt)
+		}
+		if attempt.ResponseBody != "Internal Server Error" {
+			t.Errorf("attempt %d: response body %q", i, attempt.ResponseBody)
+		}
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if len(rec.requests) != 3 {
+		t.Errorf("receiver got %d requests, want 3", len(rec.requests))
+	}
+}
+
+func TestDeliveryRetriesUntilSuccess(t *testing.T) {
+	rec := newReceiver(t, http.StatusBadGateway)
+	webhook := Webhook{ID: "wh", URL: rec.URL, Events: []EventType{EventRunFinished}}
+	store := newMemStore(webhook)
+	d := startDispatcher(t, store, Config{MaxAttempts: 5, InitialBackoff: 50 * time.Millisecond})
+
+	d.dispatch(webhook, EventRunFinished, nil)
+
+	// The failed first attempt schedules the next one after the backoff.
+	var retrying Delivery
+	for retrying.NextAttemptAt == nil {
+		retrying = <-store.saved
+	}
+	if len(retrying.Attempts) != 1 || retrying.Attempts[0].StatusCode != http.StatusBadGateway {
+		t.Errorf("retrying delivery %+v", retrying)
+	}
+	rec.mu.Lock()
+	rec.status = http.StatusOK
+	rec.mu.Unlock()
+
+	delivery := store.waitDone(t)
+	if delivery.Status != DeliverySucceeded || len(delivery.Attempts) != 2 {
+		t.Errorf("delivery %+v, want succeeded on the second attempt", delivery)
+	}
+}
+
+func TestRedeliver(t *testing.T) {
+	rec := newReceiver(t, http.StatusOK)
+	webhook := Webhook{ID: "wh", URL: rec.URL, Events: []EventType{EventRunFailed}}
+	store := newMemStore(webhook)
+	d := startDispatcher(t, store, Config{MaxAttempts: 1})
+
+	d.dispatch(webhook, EventRunFailed, nil)
+	original := store.waitDone(t)
+
+	redelivery, err := d.Redeliver(original.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if redelivery.ID == original.ID || redelivery.RedeliveryOf != original.ID {
+		t.Errorf("redelivery %s of %q, want a new delivery of %s", redelivery.ID, redelivery.RedeliveryOf, original.ID)
+	}
+	if string(redelivery.Payload) != string(original.Payload) || redelivery.Event != original.Event {
+		t.Errorf("redelivery payload %s, want %s", redelivery.Payload, original.Payload)
+	}
+
+	sent := store.waitDone(t)
+	if sent.ID != redelivery.ID || sent.Status != DeliverySucceeded {
+		t.Errorf("redelivery %+v, want succeeded", sent)
+	}
+	if saved, _ := store.GetDelivery(original.ID); len(saved.Attempts) != 1 {
+		t.Errorf("original delivery has %d attempts, want it left unchanged", len(saved.Attempts))
+	}
+
+	if _, err := d.Redeliver("missing"); err == nil {
+		t.Error("redelivering an unknown delivery succeeded")
+	}
+}
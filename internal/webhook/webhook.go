@@ -0,0 +1,117 @@
+// Package webhook notifies registered HTTP endpoints of scrape runs and newly
+// discovered jobs.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/ayagmar/gojobscraper/internal/filter"
+	"github.com/ayagmar/gojobscraper/internal/scraper"
+)
+
+// EventType identifies what a webhook delivery reports
+// @Description Type of webhook event
+type EventType string
+
+const (
+	// EventRunFinished is sent when a scrape run succeeds.
+	EventRunFinished EventType = "run.finished"
+	// EventRunFailed is sent when a scrape run fails or is blocked.
+	EventRunFailed EventType = "run.failed"
+	// EventJobsNew is sent when newly inserted jobs match the webhook filter.
+	EventJobsNew EventType = "jobs.new"
+)
+
+// ValidEventType reports whether t is a known event type.
+func ValidEventType(t EventType) bool {
+	return t == EventRunFinished || t == EventRunFailed || t == EventJobsNew
+}
+
+// Webhook is an endpoint notified of events
+// @Description Registered webhook endpoint
+type Webhook struct {
+	ID  string `json:"id" bson:"id"`
+	URL string `json:"url" bson:"url"`
+	// Secret signs the deliveries. It is only returned when the webhook is
+	// created.
+	Secret string      `json:"-" bson:"secret"`
+	Events []EventType `json:"events" bson:"events"`
+	// Filter selects the jobs sent in jobs.new deliveries.
+	Filter    filter.JobFilter `json:"filter" bson:"filter"`
+	CreatedAt time.Time        `json:"created_at" bson:"created_at"`
+}
+
+// Subscribed reports whether the webhook receives events of type t.
+func (w Webhook) Subscribed(t EventType) bool {
+	for _, event := range w.Events {
+		if event == t {
+			return true
+		}
+	}
+	return false
+}
+
+// DeliveryStatus is the state of a webhook delivery
+// @Description State of a webhook delivery
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// Delivery is a payload sent, or to be sent, to a webhook
+// @Description Webhook delivery and its attempts
+type Delivery struct {
+	ID        string          `json:"id" bson:"id"`
+	WebhookID string          `json:"webhook_id" bson:"webhook_id"`
+	Event     EventType       `json:"event" bson:"event"`
+	Payload   json.RawMessage `json:"payload" bson:"payload" swaggertype:"object"`
+	Status    DeliveryStatus  `json:"status" bson:"status"`
+	Attempts  []Attempt       `json:"attempts" bson:"attempts"`
+	// RedeliveryOf is the ID of the delivery this one repeats, if any.
+	RedeliveryOf  string     `json:"redelivery_of,omitempty" bson:"redelivery_of,omitempty"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty" bson:"next_attempt_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at" bson:"created_at"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty" bson:"delivered_at,omitempty"`
+}
+
+// Attempt records a single POST of a delivery
+// @Description Webhook delivery attempt
+type Attempt struct {
+	At           time.Time `json:"at" bson:"at"`
+	StatusCode   int       `json:"status_code,omitempty" bson:"status_code,omitempty"`
+	ResponseBody string    `json:"response_body,omitempty" bson:"response_body,omitempty"`
+	Error        string    `json:"error,omitempty" bson:"error,omitempty"`
+	DurationMs   int64     `json:"duration_ms" bson:"duration_ms"`
+}
+
+// Payload is the JSON body of a delivery.
+type Payload struct {
+	ID        string      `json:"id"`
+	Event     EventType   `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// JobsData is the data of a jobs.new payload.
+type JobsData struct {
+	Jobs []scraper.JobPosting `json:"jobs"`
+}
+
+// Sign returns the signature sent in the X-Webhook-Signature header: the hex
+// HMAC-SHA256, keyed by the webhook secret, of the X-Webhook-Timestamp value,
+// a dot and the request body.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}